@@ -0,0 +1,476 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// ErrBatchFailed is returned when one or more rows could not be generated
+	ErrBatchFailed = errors.New("rows failed")
+	// ErrBatchColumn is returned when the input has a column that is not a payment field
+	ErrBatchColumn = errors.New("unknown column")
+	// ErrBatchFields is returned when a CSV row has more fields than the header
+	ErrBatchFields = errors.New("row has more fields than the header")
+	// ErrBatchJSONL is returned when a JSONL line holds more than one JSON value
+	ErrBatchJSONL = errors.New("line should hold one JSON object")
+	// ErrBatchFormat is returned when the input format is not csv or jsonl
+	ErrBatchFormat = errors.New("input format should be csv, jsonl or auto")
+	// ErrBatchFileName is returned when the file name template results in an unusable file name
+	ErrBatchFileName = errors.New("file name should be a non-empty path inside the output directory")
+	// ErrBatchDuplicate is returned when the file name template results in the same file name for two rows
+	ErrBatchDuplicate = errors.New("file name is already used by row")
+)
+
+// maxJSONLLine is the longest line of a JSONL input, in bytes
+const maxJSONLLine = 1 << 20
+
+// batchColumns are the columns a row may have; each one overrides a flag of the base payment. They are the names of
+// the flags; see batchColumn for the JSON field names of the payment schema, which are accepted as well.
+var batchColumns = map[string]func(q *qrParams, v string) error{
+	"name":            func(q *qrParams, v string) error { q.Payment.NameBeneficiary = v; return nil },
+	"bic":             func(q *qrParams, v string) error { q.Payment.BICBeneficiary = v; return nil },
+	"iban":            func(q *qrParams, v string) error { q.Payment.IBANBeneficiary = v; return nil },
+	"amount":          func(q *qrParams, v string) error { q.Amount = v; return nil },
+	"purpose":         func(q *qrParams, v string) error { q.Payment.Purpose = v; return nil },
+	"remittance":      func(q *qrParams, v string) error { q.Payment.Remittance = v; return nil },
+	"b2o-information": func(q *qrParams, v string) error { q.Payment.B2OInformation = v; return nil },
+	"structured":      func(q *qrParams, v string) error { return q.Structured.Set(v) },
+	"character-set":   func(q *qrParams, v string) error { return q.CharacterSet.Set(v) },
+}
+
+// batchParams are the settings of a batch run, on top of the base payment
+type batchParams struct {
+	Input        string
+	InputFormat  string
+	Dir          string
+	FileTemplate string
+	Workers      int
+}
+
+// batchRow is one row of the input, with its values by column, or the error when the row is malformed
+type batchRow struct {
+	Number int
+	Values map[string]string
+	Err    error
+}
+
+// batchJob is a row that is ready to be rendered
+type batchJob struct {
+	Row  int
+	Path string
+	Q    *qrParams
+}
+
+// batchResult is the outcome of one row
+type batchResult struct {
+	Row  int
+	File string
+	Err  error
+}
+
+// batchTemplateData is passed to the file name template: the fields of the payment, and the row number
+type batchTemplateData struct {
+	*payment.Payment
+	Row int
+}
+
+func batchCmd(q *qrParams) *cobra.Command {
+	b := batchParams{}
+
+	cmd := &cobra.Command{
+		Use:   "batch",
+		Short: "Generate a QR code for every row of a CSV or JSONL file",
+		Long: `Generate a QR code for every row of a CSV or JSONL file, or stdin.
+
+Every row overrides the flags of the base payment; the columns (or JSON keys) are name, bic, iban, amount, purpose,
+remittance, b2o-information, structured and character-set. The field names of payme schema and --from files
+(b2o_information, character_set) are accepted as well. Empty values keep the value of the base payment. The files
+are named with a Go template, which gets the fields of the payment (eg. {{.Remittance}}, {{.NameBeneficiary}}) and
+the row number ({{.Row}}). Rows that fail, including CSV rows and JSON lines that cannot be parsed, are listed in a
+summary at the end, without stopping the other rows.`,
+		Example: `  payme batch --input members.csv --iban DE71110220330123456789 --name "Franz Mustermänn" \
+    --output png --file-template "{{.Remittance}}.png" --dir codes`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return q.applyConfig(cmd)
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return b.run(q, cmd.InOrStdin(), cmd.OutOrStdout())
+		},
+	}
+
+	cmd.Flags().StringVar(&b.Input, "input", "-", "CSV or JSONL file with one payment per row, - for stdin")
+	cmd.Flags().StringVar(&b.InputFormat, "input-format", "auto", "Format of the input: csv, jsonl or auto to detect it")
+	cmd.Flags().StringVar(&b.Dir, "dir", ".", "Directory to write the codes to")
+	cmd.Flags().StringVar(&b.FileTemplate, "file-template", "", "Go template for the file names (default \"payment-{{.Row}}\" with the extension of the output type)")
+	cmd.Flags().IntVar(&b.Workers, "workers", runtime.NumCPU(), "Number of codes to generate at the same time")
+
+	q.addOutputFlags(cmd.Flags())
+	q.addPaymentFlags(cmd.Flags())
+	q.addFormatFlags(cmd.Flags())
+
+	return cmd
+}
+
+// run generates the code for every row, and prints a summary
+func (b *batchParams) run(q *qrParams, stdin io.Reader, w io.Writer) error {
+	if err := q.checkFormat(); err != nil {
+		return err
+	}
+
+	if b.FileTemplate == "" {
+		b.FileTemplate = "payment-{{.Row}}" + outputExtension(q.OutputType)
+	}
+
+	tmpl, err := template.New("file").Option("missingkey=error").Parse(b.FileTemplate)
+	if err != nil {
+		return err
+	}
+
+	r := stdin
+
+	if b.Input != "-" {
+		f, err := os.Open(b.Input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		r = f
+	}
+
+	var (
+		jobs    = make(chan batchJob)
+		results = make(chan batchResult)
+		files   = map[string]int{}
+		wg      sync.WaitGroup
+		readErr error
+	)
+
+	// Rows are read, validated and named in order, so duplicate file names are reported
+	// consistently; rendering and writing the codes is spread over the workers
+	for range max(b.Workers, 1) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for job := range jobs {
+				results <- batchResult{Row: job.Row, File: job.Path, Err: job.write()}
+			}
+		}()
+	}
+
+	go func() {
+		readErr = readRows(r, b.InputFormat, func(row batchRow) {
+			if row.Err != nil {
+				results <- batchResult{Row: row.Number, Err: row.Err}
+				return
+			}
+
+			job, err := b.plan(q, row, tmpl, files)
+			if err != nil {
+				results <- batchResult{Row: row.Number, Err: err}
+				return
+			}
+
+			jobs <- job
+		})
+
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	var all []batchResult
+	for res := range results {
+		all = append(all, res)
+	}
+
+	slices.SortFunc(all, func(a, b batchResult) int { return a.Row - b.Row })
+
+	failed := printBatchSummary(w, all)
+
+	if readErr != nil {
+		return readErr
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d %w", failed, len(all), ErrBatchFailed)
+	}
+
+	return nil
+}
+
+// plan applies the row to the base payment, validates it in the selected format and selects the file name for its code
+func (b *batchParams) plan(base *qrParams, row batchRow, tmpl *template.Template, files map[string]int) (batchJob, error) {
+	q, err := base.withRow(row)
+	if err != nil {
+		return batchJob{}, err
+	}
+
+	if err := q.prepare(); err != nil {
+		return batchJob{}, err
+	}
+
+	c, err := q.code()
+	if err != nil {
+		return batchJob{}, err
+	}
+
+	if err := c.IsValid(); err != nil {
+		return batchJob{}, err
+	}
+
+	var name bytes.Buffer
+	if err := tmpl.Execute(&name, batchTemplateData{Payment: q.Payment, Row: row.Number}); err != nil {
+		return batchJob{}, err
+	}
+
+	file := filepath.Clean(name.String())
+	if name.Len() == 0 || !filepath.IsLocal(file) {
+		return batchJob{}, fmt.Errorf("%w: %q", ErrBatchFileName, name.String())
+	}
+
+	if other, ok := files[file]; ok {
+		return batchJob{}, fmt.Errorf("%w %d: %q", ErrBatchDuplicate, other, file)
+	}
+
+	files[file] = row.Number
+
+	return batchJob{Row: row.Number, Path: filepath.Join(b.Dir, file), Q: q}, nil
+}
+
+// write renders the code and writes it to its file
+func (j batchJob) write() error {
+	qr, err := j.Q.render()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(j.Path), 0o700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(j.Path, qr, 0o600)
+}
+
+// withRow returns a copy of the parameters, with the values of the row applied to the payment
+func (q *qrParams) withRow(row batchRow) (*qrParams, error) {
+	p := *q.Payment
+
+	c := *q
+	c.Payment = &p
+	c.Structured.structured = &p.RemittanceIsStructured
+	c.CharacterSet.characterSet = &p.CharacterSet
+
+	for k, v := range row.Values {
+		if v == "" {
+			continue
+		}
+
+		if err := batchColumns[k](&c, v); err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+	}
+
+	return &c, nil
+}
+
+// readRows reads the rows of the input, and calls fn for each of them
+func readRows(r io.Reader, format string, fn func(batchRow)) error {
+	br := bufio.NewReader(r)
+
+	if format == "auto" {
+		format = detectInputFormat(br)
+	}
+
+	switch format {
+	case "csv":
+		return readCSV(br, fn)
+	case "jsonl":
+		return readJSONL(br, fn)
+	}
+
+	return fmt.Errorf("%w: %q", ErrBatchFormat, format)
+}
+
+// detectInputFormat returns jsonl when the input starts with a JSON object, and csv otherwise
+func detectInputFormat(br *bufio.Reader) string {
+	for i := 1; ; i++ {
+		b, err := br.Peek(i)
+		if err != nil {
+			return "csv"
+		}
+
+		switch b[i-1] {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '{':
+			return "jsonl"
+		default:
+			return "csv"
+		}
+	}
+}
+
+// batchColumn returns the column of a CSV header or JSON key: the flag name, or the JSON field name of the
+// payment schema (eg. character_set for character-set)
+func batchColumn(key string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(key)), "_", "-")
+}
+
+// readCSV reads a CSV file with a header row of column names. Rows that cannot be parsed, or that have more
+// fields than the header, are passed on with their error; rows with fewer fields keep the base payment for the
+// missing columns.
+func readCSV(r io.Reader, fn func(batchRow)) error {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return err
+	}
+
+	for i, h := range header {
+		header[i] = batchColumn(h)
+
+		if _, ok := batchColumns[header[i]]; !ok {
+			return fmt.Errorf("%w: %q", ErrBatchColumn, h)
+		}
+	}
+
+	for n := 1; ; n++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			fn(batchRow{Number: n, Err: err})
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if len(record) > len(header) {
+			fn(batchRow{Number: n, Err: fmt.Errorf("%w: %d > %d", ErrBatchFields, len(record), len(header))})
+			continue
+		}
+
+		values := map[string]string{}
+		for i, v := range record {
+			values[header[i]] = v
+		}
+
+		fn(batchRow{Number: n, Values: values})
+	}
+}
+
+// readJSONL reads one JSON object per line; empty lines are skipped. Lines that are not a JSON object, or
+// that have an unknown key, are passed on with their error.
+func readJSONL(r io.Reader, fn func(batchRow)) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, maxJSONLLine)
+
+	for n := 0; sc.Scan(); {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		n++
+
+		values, err := jsonlValues(line)
+		fn(batchRow{Number: n, Values: values, Err: err})
+	}
+
+	return sc.Err()
+}
+
+// jsonlValues returns the values of a JSON object by their column
+func jsonlValues(line []byte) (map[string]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+
+	var object map[string]any
+	if err := dec.Decode(&object); err != nil {
+		return nil, err
+	}
+
+	if dec.More() {
+		return nil, ErrBatchJSONL
+	}
+
+	values := map[string]string{}
+
+	for k, v := range object {
+		key := batchColumn(k)
+		if _, ok := batchColumns[key]; !ok {
+			return nil, fmt.Errorf("%w: %q", ErrBatchColumn, k)
+		}
+
+		if v != nil {
+			values[key] = fmt.Sprint(v)
+		}
+	}
+
+	return values, nil
+}
+
+// printBatchSummary prints the rows that failed and why, followed by the totals; it returns the number of failed rows
+func printBatchSummary(w io.Writer, results []batchResult) int {
+	failed := 0
+
+	for _, res := range results {
+		if res.Err == nil {
+			continue
+		}
+
+		failed++
+
+		var errs payment.ValidationErrors
+		if !errors.As(res.Err, &errs) {
+			fmt.Fprintf(w, "row %d: %s\n", res.Row, res.Err)
+			continue
+		}
+
+		for _, e := range errs {
+			fmt.Fprintf(w, "row %d: %s\n", res.Row, e)
+		}
+	}
+
+	fmt.Fprintf(w, "%d generated, %d failed\n", len(results)-failed, failed)
+
+	return failed
+}
+
+// outputExtension returns the file extension for the output type
+func outputExtension(outputType string) string {
+	if outputType == "stdout" {
+		return ".txt"
+	}
+
+	return "." + outputType
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testBatchCSV = `name,amount,remittance
+,12.30,Invoice 1
+Jane Doe,5,Invoice 2
+,-1,Invoice 3
+,7.5,
+`
+
+func runBatch(t *testing.T, input string, args ...string) (string, string, error) {
+	t.Helper()
+
+	writeTestConfig(t, "")
+
+	q := qrParams{
+		Payment: payment.New(),
+	}
+
+	cmdRoot, err := newCommand(&q)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	out := new(bytes.Buffer)
+
+	cmdRoot.SetIn(strings.NewReader(input))
+	cmdRoot.SetOut(out)
+	cmdRoot.SetErr(new(bytes.Buffer))
+	cmdRoot.SetArgs(append([]string{
+		"batch", "--dir", dir, "--name", "Franz Mustermänn", "--iban", "DE71110220330123456789",
+	}, args...))
+
+	err = cmdRoot.Execute()
+
+	return dir, out.String(), err
+}
+
+func decodeFile(t *testing.T, path string) *payment.Payment {
+	t.Helper()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	p, err := payment.FromQRImage(f)
+	require.NoError(t, err)
+
+	return p
+}
+
+func TestBatchCSV(t *testing.T) {
+	dir, out, err := runBatch(t, testBatchCSV, "--output", "png", "--file-template", "{{.Remittance}}.png")
+	require.ErrorIs(t, err, ErrBatchFailed)
+	assert.Contains(t, err.Error(), "2 of 4")
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "row 3: "), lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "row 4: AT-05: "), lines[1])
+	assert.Equal(t, "2 generated, 2 failed", lines[2])
+
+	p := decodeFile(t, filepath.Join(dir, "Invoice 1.png"))
+	assert.Equal(t, "Franz Mustermänn", p.NameBeneficiary)
+	assert.Equal(t, payment.Amount(1230), p.EuroAmount)
+
+	p = decodeFile(t, filepath.Join(dir, "Invoice 2.png"))
+	assert.Equal(t, "Jane Doe", p.NameBeneficiary)
+	assert.Equal(t, payment.Amount(500), p.EuroAmount)
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 2)
+}
+
+func TestBatchJSONL(t *testing.T) {
+	input := `{"amount": 12.3, "remittance": "RF18539007547034"}
+{"amount": "1", "remittance": "Invoice 2", "structured": "auto"}
+`
+
+	dir, out, err := runBatch(t, input, "--structured=auto")
+	require.NoError(t, err)
+	assert.Equal(t, "2 generated, 0 failed\n", out)
+
+	for _, name := range []string{"payment-1.txt", "payment-2.txt"} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		require.NoError(t, err)
+	}
+}
+
+func TestBatchSchemaKeys(t *testing.T) {
+	input := `{"amount": 1, "remittance": "Invoice 1", "b2o_information": "Thanks", "character_set": 1}
+{"amount": 2, "remittance": "Invoice 2", "b2o-information": "Thanks", "character-set": 1}
+`
+
+	_, out, err := runBatch(t, input)
+	require.NoError(t, err)
+	assert.Equal(t, "2 generated, 0 failed\n", out)
+
+	_, out, err = runBatch(t, "amount,remittance,Character_Set\n1,Invoice,1\n")
+	require.NoError(t, err)
+	assert.Equal(t, "1 generated, 0 failed\n", out)
+}
+
+func TestBatchMalformedCSV(t *testing.T) {
+	input := "amount,remittance\n1,Invoice 1\n2,Invoice 2,extra\n3,In\"voice 3\n4\n5,Invoice 5\n"
+
+	dir, out, err := runBatch(t, input, "--file-template", "{{.Row}}", "--remittance", "Invoice")
+	require.ErrorIs(t, err, ErrBatchFailed)
+	assert.Contains(t, err.Error(), "2 of 5")
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, "row 2: "+ErrBatchFields.Error()+": 3 > 2", lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "row 3: "), lines[1])
+	assert.Contains(t, lines[1], "bare \"")
+	assert.Equal(t, "3 generated, 2 failed", lines[2])
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 3, "a row with fewer fields keeps the base payment for the others")
+}
+
+func TestBatchMalformedJSONL(t *testing.T) {
+	input := `{"amount": 1, "remittance": "Invoice 1"}
+{"amount": 2, "remittance": "Invoice 2"
+{"amount": 3, "remittance": "Invoice 3", "colour": "red"}
+
+{"amount": 4, "remittance": "Invoice 4"} {}
+{"amount": 5, "remittance": "Invoice 5"}
+`
+
+	dir, out, err := runBatch(t, input, "--file-template", "{{.Row}}")
+	require.ErrorIs(t, err, ErrBatchFailed)
+	assert.Contains(t, err.Error(), "3 of 5")
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 4)
+	assert.True(t, strings.HasPrefix(lines[0], "row 2: "), lines[0])
+	assert.Equal(t, "row 3: "+ErrBatchColumn.Error()+`: "colour"`, lines[1])
+	assert.Equal(t, "row 4: "+ErrBatchJSONL.Error(), lines[2])
+	assert.Equal(t, "2 generated, 3 failed", lines[3])
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 2, "the rows after a malformed one are generated")
+}
+
+func TestBatchWorkers(t *testing.T) {
+	var input strings.Builder
+
+	input.WriteString("amount,remittance\n")
+
+	for range 20 {
+		input.WriteString("1,Invoice\n")
+	}
+
+	dir, out, err := runBatch(t, input.String(), "--workers", "4")
+	require.NoError(t, err)
+	assert.Equal(t, "20 generated, 0 failed\n", out)
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 20)
+}
+
+func TestBatchDuplicateFileName(t *testing.T) {
+	_, out, err := runBatch(t, "amount,remittance\n1,Invoice\n2,Invoice\n", "--file-template", "{{.Remittance}}")
+	require.ErrorIs(t, err, ErrBatchFailed)
+	assert.Contains(t, out, "row 2: file name is already used by row 1")
+}
+
+func TestBatchFileNameOutsideDir(t *testing.T) {
+	_, out, err := runBatch(t, "amount,remittance\n1,../Invoice\n", "--file-template", "{{.Remittance}}")
+	require.ErrorIs(t, err, ErrBatchFailed)
+	assert.Contains(t, out, "row 1: "+ErrBatchFileName.Error())
+}
+
+func TestBatchUnknownColumn(t *testing.T) {
+	_, _, err := runBatch(t, "amount,colour\n1,red\n")
+	require.ErrorIs(t, err, ErrBatchColumn)
+
+	_, out, err := runBatch(t, `{"amount": 1, "colour": "red"}`)
+	require.ErrorIs(t, err, ErrBatchFailed)
+	assert.Contains(t, out, "row 1: "+ErrBatchColumn.Error()+`: "colour"`, "an unknown key fails the row of a JSONL input")
+}
+
+func TestBatchInputFormat(t *testing.T) {
+	_, _, err := runBatch(t, "", "--input-format", "xml")
+	require.ErrorIs(t, err, ErrBatchFormat)
+}
+
+func TestBatchFormat(t *testing.T) {
+	input := "amount,remittance\n,Faktura 1\n1,Faktura 2\n"
+
+	_, out, err := runBatch(t, input, "--format", "spayd", "--iban", "CZ6508000000192000145399", "--variable-symbol", "x")
+	require.ErrorIs(t, err, ErrBatchFailed)
+	assert.Contains(t, out, "row 1: ", "the rows are validated with the rules of the format")
+	assert.Contains(t, out, "X-VS: ")
+
+	dir, out, err := runBatch(t, input, "--format", "spayd", "--iban", "CZ6508000000192000145399")
+	require.NoError(t, err, "the amount of a SPAYD is optional")
+	assert.Equal(t, "2 generated, 0 failed\n", out)
+
+	b, err := os.ReadFile(filepath.Join(dir, "payment-2.txt"))
+	require.NoError(t, err)
+	assert.NotEmpty(t, b)
+
+	_, _, err = runBatch(t, input, "--format", "bogus")
+	require.ErrorIs(t, err, ErrFormat)
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// Flags can be set, from highest to lowest precedence:
+//   - on the command line, eg. --iban
+//   - in the payment file selected with --from, eg. "iban": ...
+//   - in the environment, eg. PAYME_IBAN (dashes become underscores)
+//   - in the selected profile of the config file, eg. iban: ...
+//   - by their default value
+
+const (
+	// envPrefix is the prefix of the environment variables that set flags
+	envPrefix = "PAYME_"
+	// keyProfiles is the key of the profiles in the config file
+	keyProfiles = "profiles"
+	// keyDefaultProfile is the key of the profile to use when none is selected with --profile
+	keyDefaultProfile = "profile"
+)
+
+var (
+	profileNameValidator = regexp.MustCompile(`^[\w-]+$`)
+
+	// ErrProfileNotFound is returned when the selected profile is not in the config file
+	ErrProfileNotFound = errors.New("profile not found")
+	// ErrProfileName is returned when a profile name contains other characters than letters, digits, - and _
+	ErrProfileName = errors.New("profile name should only contain letters, digits, - and _")
+	// ErrProfileSetting is returned when a profile contains a setting that is not a flag
+	ErrProfileSetting = errors.New("unknown profile setting")
+)
+
+// config is the config file with the profiles
+type config struct {
+	path string
+	v    *viper.Viper
+}
+
+// loadConfig reads the config file; when path is empty, $XDG_CONFIG_HOME/payme/config.yaml
+// is read if it exists. A config file that is selected explicitly must exist, unless create is set
+// because the config is about to be written.
+func loadConfig(path string, create bool) (*config, error) {
+	explicit := path != ""
+
+	if !explicit {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return nil, err
+		}
+
+		path = filepath.Join(dir, "payme", "config.yaml")
+	}
+
+	c := &config{path: path, v: viper.New()}
+	c.v.SetConfigFile(path)
+	c.v.SetConfigType("yaml")
+
+	if err := c.v.ReadInConfig(); err != nil && ((explicit && !create) || !errors.Is(err, fs.ErrNotExist)) {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// profiles returns the names of all profiles, sorted
+func (c *config) profiles() []string {
+	var names []string
+
+	for name := range c.v.GetStringMap(keyProfiles) {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+// defaultProfile returns the name of the profile to use when none is selected
+func (c *config) defaultProfile() string {
+	return c.v.GetString(keyDefaultProfile)
+}
+
+// profile returns the settings of the profile, by flag name
+func (c *config) profile(name string) (map[string]string, error) {
+	key := keyProfiles + "." + name
+	if !profileNameValidator.MatchString(name) || !c.v.IsSet(key) {
+		return nil, fmt.Errorf("%w: %q in %s", ErrProfileNotFound, name, c.path)
+	}
+
+	settings := map[string]string{}
+	for k, v := range c.v.GetStringMap(key) {
+		settings[k] = fmt.Sprint(v)
+	}
+
+	return settings, nil
+}
+
+// set changes a setting of the profile, creating the profile if needed
+func (c *config) set(name, key, value string) error {
+	if !profileNameValidator.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrProfileName, name)
+	}
+
+	c.v.Set(keyProfiles+"."+name+"."+key, value)
+
+	return nil
+}
+
+// setDefault selects the profile to use when none is selected with --profile
+func (c *config) setDefault(name string) {
+	c.v.Set(keyDefaultProfile, name)
+}
+
+// write saves the config file, creating its directory if needed
+func (c *config) write() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return err
+	}
+
+	return c.v.WriteConfigAs(c.path)
+}
+
+// envName returns the name of the environment variable for the flag
+func envName(flag string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// configurable returns whether the flag can be set in the environment or in a profile
+func configurable(f *pflag.Flag) bool {
+	return !slices.Contains([]string{"help", "version", "config", "profile", "from", "from-format"}, f.Name)
+}
+
+// selectedProfile returns the name of the profile selected with --profile or PAYME_PROFILE,
+// or else the default profile of the config file
+func (q *qrParams) selectedProfile(c *config) string {
+	if q.Profile != "" {
+		return q.Profile
+	}
+
+	if name := os.Getenv(envName("profile")); name != "" {
+		return name
+	}
+
+	return c.defaultProfile()
+}
+
+// loadConfig reads the config file selected with --config or PAYME_CONFIG; see loadConfig for create
+func (q *qrParams) loadConfig(create bool) (*config, error) {
+	path := q.ConfigFile
+	if path == "" {
+		path = os.Getenv(envName("config"))
+	}
+
+	return loadConfig(path, create)
+}
+
+// applyConfig sets every flag of the command that was not set on the command line from the payment file,
+// or else from the environment, or else from the selected profile; profiles may hold any flag of the root command
+func (q *qrParams) applyConfig(cmd *cobra.Command) error {
+	c, err := q.loadConfig(false)
+	if err != nil {
+		return err
+	}
+
+	fromFile, err := q.readFrom(cmd.Flags(), cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+
+	settings := map[string]string{}
+
+	if name := q.selectedProfile(c); name != "" {
+		if settings, err = c.profile(name); err != nil {
+			return err
+		}
+	}
+
+	for key := range settings {
+		if f := cmd.Root().Flags().Lookup(key); f == nil || !configurable(f) {
+			return fmt.Errorf("%w: %q in profile %q", ErrProfileSetting, key, q.selectedProfile(c))
+		}
+	}
+
+	var errs []error
+
+	flags := cmd.Flags()
+	flags.VisitAll(func(f *pflag.Flag) {
+		if f.Changed || !configurable(f) {
+			return
+		}
+
+		value, ok := fromFile[f.Name]
+		if !ok {
+			value, ok = os.LookupEnv(envName(f.Name))
+		}
+
+		if !ok {
+			value, ok = settings[f.Name]
+		}
+
+		if !ok {
+			return
+		}
+
+		if err := flags.Set(f.Name, value); err != nil {
+			errs = append(errs, &usageError{err: fmt.Errorf("%s: %w", f.Name, err)})
+		}
+	})
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `profile: association
+profiles:
+  association:
+    name: Franz Mustermänn
+    iban: DE71110220330123456789
+    purpose: CHAR
+    ec-level: Q
+  company:
+    name: Acme NV
+    iban: BE68539007547034
+    character-set: auto
+    qr-version: 1
+    bic: GEBABEBB
+    output: svg
+`
+
+// writeTestConfig writes the config file to a temporary $XDG_CONFIG_HOME, and clears the
+// environment variables that would take precedence over it
+func writeTestConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	for _, e := range []string{"NAME", "IBAN", "BIC", "PURPOSE", "B2O_INFORMATION", "EC_LEVEL", "PROFILE", "CONFIG"} {
+		t.Setenv(envPrefix+e, "")
+		os.Unsetenv(envPrefix + e)
+	}
+
+	path := filepath.Join(dir, "payme", "config.yaml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func runGenerate(t *testing.T, args ...string) (*qrParams, error) {
+	t.Helper()
+
+	q := qrParams{
+		Payment: payment.New(),
+	}
+
+	cmdRoot, err := newCommand(&q)
+	require.NoError(t, err)
+
+	cmdRoot.SetArgs(append(args, "--amount", "12.3", "--remittance", "Invoice 1234", "--file", filepath.Join(t.TempDir(), "qr")))
+	cmdRoot.SilenceErrors = true
+	cmdRoot.SilenceUsage = true
+
+	return &q, cmdRoot.Execute()
+}
+
+func TestConfigDefaultProfile(t *testing.T) {
+	writeTestConfig(t, testConfig)
+
+	q, err := runGenerate(t)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Franz Mustermänn", q.Payment.NameBeneficiary)
+	assert.Equal(t, "DE71110220330123456789", q.Payment.IBANBeneficiary)
+	assert.Equal(t, "CHAR", q.Payment.Purpose)
+	assert.Equal(t, "Q", string(q.QR.Level))
+	assert.Equal(t, "stdout", q.OutputType)
+}
+
+func TestConfigSelectedProfile(t *testing.T) {
+	writeTestConfig(t, testConfig)
+
+	q, err := runGenerate(t, "--profile", "company")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Acme NV", q.Payment.NameBeneficiary)
+	assert.Equal(t, "GEBABEBB", q.Payment.BICBeneficiary)
+	assert.Equal(t, 1, q.Payment.Version)
+	assert.Equal(t, 2, q.Payment.CharacterSet)
+	assert.Equal(t, "svg", q.OutputType)
+	assert.Empty(t, q.Payment.Purpose)
+
+	t.Setenv("PAYME_PROFILE", "company")
+
+	q, err = runGenerate(t)
+	require.NoError(t, err)
+	assert.Equal(t, "Acme NV", q.Payment.NameBeneficiary)
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	writeTestConfig(t, testConfig)
+	t.Setenv("PAYME_NAME", "Name from env")
+	t.Setenv("PAYME_EC_LEVEL", "H")
+
+	q, err := runGenerate(t, "--ec-level", "L")
+	require.NoError(t, err)
+
+	// flags > env > profile > defaults
+	assert.Equal(t, "L", string(q.QR.Level))
+	assert.Equal(t, "Name from env", q.Payment.NameBeneficiary)
+	assert.Equal(t, "DE71110220330123456789", q.Payment.IBANBeneficiary)
+	assert.Equal(t, 2, q.Payment.Version)
+}
+
+func TestConfigB2OInformation(t *testing.T) {
+	writeTestConfig(t, testConfig)
+	t.Setenv("PAYME_B2O_INFORMATION", "Thank you")
+
+	q, err := runGenerate(t)
+	require.NoError(t, err)
+	assert.Equal(t, "Thank you", q.Payment.B2OInformation)
+
+	q, err = runGenerate(t, "--b2o-information", "Thanks")
+	require.NoError(t, err)
+	assert.Equal(t, "Thanks", q.Payment.B2OInformation)
+}
+
+func TestConfigFlag(t *testing.T) {
+	writeTestConfig(t, testConfig)
+
+	path := filepath.Join(t.TempDir(), "other.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("profiles:\n  other:\n    name: Other\n    iban: NL91ABNA0417164300\n"), 0o600))
+
+	q, err := runGenerate(t, "--config", path, "--profile", "other")
+	require.NoError(t, err)
+	assert.Equal(t, "Other", q.Payment.NameBeneficiary)
+
+	_, err = runGenerate(t, "--config", filepath.Join(t.TempDir(), "missing.yaml"), "--name", "x", "--iban", "NL91ABNA0417164300")
+	require.Error(t, err)
+}
+
+func TestConfigErrors(t *testing.T) {
+	writeTestConfig(t, testConfig)
+
+	_, err := runGenerate(t, "--profile", "missing")
+	require.ErrorIs(t, err, ErrProfileNotFound)
+
+	writeTestConfig(t, "profiles:\n  typo:\n    nmae: Franz\n")
+
+	_, err = runGenerate(t, "--profile", "typo")
+	require.ErrorIs(t, err, ErrProfileSetting)
+}
+
+func TestNoConfig(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	q, err := runGenerate(t, "--name", "Franz Mustermänn", "--iban", "DE71110220330123456789")
+	require.NoError(t, err)
+	assert.Equal(t, "Franz Mustermänn", q.Payment.NameBeneficiary)
+}
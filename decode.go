@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/spf13/cobra"
+)
+
+func decodeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:          "decode <image>",
+		Short:        "Decode a SEPA payment QR code from a PNG or JPEG image",
+		Long:         "Decode a SEPA payment QR code from a PNG or JPEG image, print its fields and check whether they are valid. Use - to read the image from stdin.",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cmd.InOrStdin()
+
+			if args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+
+				r = f
+			}
+
+			p, err := payment.FromQRImage(r)
+			if err != nil {
+				return err
+			}
+
+			printPayment(cmd.OutOrStdout(), p)
+
+			return p.IsValid()
+		},
+	}
+}
+
+// printPayment prints the fields of the payment in a human-readable form
+func printPayment(w io.Writer, p *payment.Payment) {
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	defer tw.Flush()
+
+	remittanceType := "unstructured"
+	if p.RemittanceIsStructured {
+		remittanceType = "structured"
+	}
+
+	fmt.Fprintf(tw, "Version:\t%s\n", p.VersionString())
+	fmt.Fprintf(tw, "Character set:\t%s (%s)\n", p.CharacterSetString(), p.CharacterSetName())
+	fmt.Fprintf(tw, "BIC:\t%s\n", p.BICBeneficiary)
+	fmt.Fprintf(tw, "Name:\t%s\n", p.NameBeneficiary)
+	fmt.Fprintf(tw, "IBAN:\t%s\n", p.IBANBeneficiary)
+	fmt.Fprintf(tw, "Amount:\t%s\n", p.EuroAmountString())
+	fmt.Fprintf(tw, "Purpose:\t%s\n", p.Purpose)
+	fmt.Fprintf(tw, "Remittance (%s):\t%s\n", remittanceType, p.Remittance)
+	fmt.Fprintf(tw, "B2O information:\t%s\n", p.B2OInformation)
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeCommand(t *testing.T) {
+	q := qrParams{
+		Payment: payment.New(),
+	}
+
+	cmdRoot, err := newCommand(&q)
+	assert.NoError(t, err)
+
+	actualOut := new(bytes.Buffer)
+	actualErr := new(bytes.Buffer)
+
+	cmdRoot.SetOut(actualOut)
+	cmdRoot.SetErr(actualErr)
+	cmdRoot.SetArgs([]string{"decode", "payment/tests/test2.png"})
+
+	_, err = cmdRoot.ExecuteC()
+
+	assert.NoError(t, err)
+	assert.Contains(t, actualOut.String(), "Franz Mustermänn")
+	assert.Contains(t, actualOut.String(), "RF18539007547034")
+	assert.Empty(t, actualErr.String())
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"log"
+	"net"
+
+	"github.com/jovandeginste/payme/paybysquare"
+	"github.com/jovandeginste/payme/payment"
+	"github.com/jovandeginste/payme/qrcode"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of payme, by class of error
+const (
+	// exitOK means the command succeeded
+	exitOK = 0
+	// exitFailure means the command failed for another reason than below
+	exitFailure = 1
+	// exitUsage means the flags, arguments or settings are not valid
+	exitUsage = 2
+	// exitValidation means the payment is not valid, or the payload could not be parsed
+	exitValidation = 3
+	// exitIO means a file could not be read or written, or the network is not available
+	exitIO = 4
+)
+
+// exitCodesHelp documents the exit codes, for the help of the commands
+const exitCodesHelp = `Exit codes:
+  0  success
+  1  other error
+  2  usage: the flags, arguments or settings are not valid
+  3  validation: the payment is not valid, or the payload could not be parsed
+  4  I/O: a file could not be read or written`
+
+var (
+	// usageErrors are the errors about the flags, arguments or settings
+	usageErrors = []error{
+		ErrOutputType,
+		ErrFormat,
+		ErrCurrency,
+		ErrMessage,
+		ErrDueDate,
+		ErrLastDate,
+		ErrStandingOrder,
+		ErrProfileNotFound,
+		ErrProfileName,
+		ErrProfileSetting,
+		ErrProfileArgs,
+		ErrBatchFormat,
+		paybysquare.ErrValidationPeriodicity,
+		payment.ErrAmountLocale,
+		payment.ErrFileFormat,
+		payment.ErrFileFormatExtension,
+		payment.ErrBankDirectory,
+		qrcode.ErrLevel,
+		qrcode.ErrMode,
+		qrcode.ErrVersion,
+		qrcode.ErrMask,
+		qrcode.ErrQuietZone,
+		qrcode.ErrECI,
+	}
+
+	// validationErrors are the errors about the content of the payment, besides payment.ValidationErrors
+	validationErrors = []error{
+		ErrBatchFailed,
+		payment.ErrAmountFormat,
+		payment.ErrAmountDecimals,
+		payment.ErrBankUnknown,
+		qrcode.ErrModeData,
+		qrcode.ErrTooLong,
+	}
+)
+
+// usageError is an error about the flags or arguments of a command
+type usageError struct {
+	err error
+}
+
+func (e *usageError) Error() string {
+	return e.err.Error()
+}
+
+func (e *usageError) Unwrap() error {
+	return e.err
+}
+
+// reportedError is an error that the command has already reported in its output, so it is not logged again
+type reportedError struct {
+	err error
+}
+
+func (e *reportedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *reportedError) Unwrap() error {
+	return e.err
+}
+
+// exitCode returns the exit code for the error of the command
+func exitCode(cmd *cobra.Command, err error) int {
+	var (
+		usageErr      *usageError
+		validationErr payment.ValidationErrors
+		parseErr      *payment.ParseError
+		pathErr       *fs.PathError
+		netErr        *net.OpError
+	)
+
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &validationErr), errors.As(err, &parseErr), isOneOf(err, validationErrors):
+		return exitValidation
+	case errors.As(err, &pathErr), errors.As(err, &netErr):
+		return exitIO
+	case errors.As(err, &usageErr), isOneOf(err, usageErrors), isCommandLineError(cmd):
+		return exitUsage
+	}
+
+	return exitFailure
+}
+
+// isOneOf returns whether the error matches one of the targets
+func isOneOf(err error, targets []error) bool {
+	for _, target := range targets {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isCommandLineError returns whether the arguments or the required flags of the command are not valid;
+// cobra does not return these errors with a type of their own
+func isCommandLineError(cmd *cobra.Command) bool {
+	if cmd == nil {
+		return false
+	}
+
+	return cmd.ValidateArgs(cmd.Flags().Args()) != nil ||
+		cmd.ValidateRequiredFlags() != nil ||
+		cmd.ValidateFlagGroups() != nil
+}
+
+// logError logs the error, unless the command has reported it already; validation errors are logged one problem per line
+func logError(err error) {
+	var reported *reportedError
+	if errors.As(err, &reported) {
+		return
+	}
+
+	var errs payment.ValidationErrors
+	if !errors.As(err, &errs) {
+		log.Print(err)
+		return
+	}
+
+	for _, e := range errs {
+		log.Print(e)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runExit runs the command, and returns its output and exit code
+func runExit(t *testing.T, stdin string, args ...string) (string, int) {
+	t.Helper()
+
+	cmdRoot, err := newCommand(&qrParams{Payment: payment.New()})
+	require.NoError(t, err)
+
+	out := new(bytes.Buffer)
+
+	cmdRoot.SetIn(strings.NewReader(stdin))
+	cmdRoot.SetOut(out)
+	cmdRoot.SetErr(new(bytes.Buffer))
+	cmdRoot.SetArgs(args)
+
+	cmd, err := cmdRoot.ExecuteC()
+
+	return out.String(), exitCode(cmd, err)
+}
+
+func TestExitCodes(t *testing.T) {
+	writeTestConfig(t, "")
+
+	valid := []string{"--name", "Jane Doe", "--iban", "BE68539007547034", "--amount", "5", "--remittance", "Invoice 1234"}
+	file := filepath.Join(t.TempDir(), "qr.txt")
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected int
+	}{
+		{"valid", append(valid, "--file", file), exitOK},
+		{"unknown flag", append(valid, "--colour", "red"), exitUsage},
+		{"bad flag value", append(valid, "--qr-version", "two"), exitUsage},
+		{"unknown command", []string{"frobnicate"}, exitUsage},
+		{"missing amount", append(valid[:4:4], "--remittance", "Invoice 1234"), exitValidation},
+		{"amount and open amount", append(valid, "--open-amount"), exitUsage},
+		{"output type", append(valid, "--output", "gif"), exitUsage},
+		{"unknown profile", append(valid, "--profile", "missing"), exitUsage},
+		{"invalid IBAN", []string{"--name", "Jane Doe", "--iban", "BE00539007547034", "--amount", "5", "--remittance", "x"}, exitValidation},
+		{"invalid amount", []string{"--name", "Jane Doe", "--iban", "BE68539007547034", "--amount", "5.001", "--remittance", "x"}, exitValidation},
+		{"unwritable file", append(valid, "--file", filepath.Join(t.TempDir(), "missing", "qr.txt")), exitIO},
+		{"missing from file", append(valid, "--from", filepath.Join(t.TempDir(), "missing.json")), exitIO},
+		{"decode missing image", []string{"decode", filepath.Join(t.TempDir(), "missing.png")}, exitIO},
+		{"decode arguments", []string{"decode"}, exitUsage},
+	}
+
+	for _, tt := range tests {
+		_, code := runExit(t, "", tt.args...)
+		assert.Equal(t, tt.expected, code, tt.name)
+	}
+}
+
+func TestExitCodeErrors(t *testing.T) {
+	assert.Equal(t, exitOK, exitCode(nil, nil))
+	assert.Equal(t, exitFailure, exitCode(nil, errors.New("something else")))
+	assert.Equal(t, exitValidation, exitCode(nil, fmt.Errorf("row 1: %w", ErrBatchFailed)))
+	assert.Equal(t, exitValidation, exitCode(nil, &reportedError{err: payment.ValidationErrors{{Err: payment.ErrValidationPurpose}}}))
+	assert.Equal(t, exitUsage, exitCode(nil, &usageError{err: errors.New("bad flag")}))
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// formatFlagAnnotation marks the flags of the payload formats, which the help lists apart from the others
+const formatFlagAnnotation = "payme_format"
+
+// usageTemplate returns the usage template of the command, with the flags of the payload formats in a
+// section of their own
+func usageTemplate(cmd *cobra.Command) string {
+	return strings.Replace(cmd.UsageTemplate(), "{{.LocalFlags.FlagUsages | trimTrailingWhitespaces}}",
+		`{{flagUsages .LocalFlags false | trimTrailingWhitespaces}}{{with flagUsages .LocalFlags true}}
+
+Format flags:
+{{. | trimTrailingWhitespaces}}{{end}}`, 1)
+}
+
+// flagUsages returns the usage of the flags that are, or are not, flags of the payload formats
+func flagUsages(flags *pflag.FlagSet, format bool) string {
+	fs := pflag.NewFlagSet("", pflag.ContinueOnError)
+
+	flags.VisitAll(func(f *pflag.Flag) {
+		if _, ok := f.Annotations[formatFlagAnnotation]; ok == format {
+			fs.AddFlag(f)
+		}
+	})
+
+	return fs.FlagUsages()
+}
+
+// structuredValue is a boolean flag that also accepts "auto", to detect whether
+// the remittance is structured when generating the code
+type structuredValue struct {
+	structured *bool
+	auto       bool
+}
+
+func (s *structuredValue) String() string {
+	if s.auto {
+		return "auto"
+	}
+
+	return strconv.FormatBool(*s.structured)
+}
+
+func (s *structuredValue) Set(v string) error {
+	if strings.EqualFold(v, "auto") {
+		s.auto = true
+		return nil
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return err
+	}
+
+	s.auto = false
+	*s.structured = b
+
+	return nil
+}
+
+func (s *structuredValue) Type() string {
+	return "bool"
+}
+
+// characterSetValue is an integer flag that also accepts "auto", to select the
+// character set that fits the payment when generating the code
+type characterSetValue struct {
+	characterSet *int
+	auto         bool
+}
+
+func (c *characterSetValue) String() string {
+	if c.auto {
+		return "auto"
+	}
+
+	return strconv.Itoa(*c.characterSet)
+}
+
+func (c *characterSetValue) Set(v string) error {
+	if strings.EqualFold(v, "auto") {
+		c.auto = true
+		return nil
+	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return err
+	}
+
+	c.auto = false
+	*c.characterSet = i
+
+	return nil
+}
+
+func (c *characterSetValue) Type() string {
+	return "int"
+}
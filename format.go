@@ -0,0 +1,442 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jovandeginste/payme/hub3"
+	"github.com/jovandeginste/payme/paybysquare"
+	"github.com/jovandeginste/payme/payment"
+	"github.com/jovandeginste/payme/qrbill"
+	"github.com/jovandeginste/payme/qrcode"
+	"github.com/jovandeginste/payme/spayd"
+	"github.com/jovandeginste/payme/upn"
+	"github.com/spf13/pflag"
+)
+
+// Payload formats of the code
+const (
+	// formatEPC is the EPC QR code of the European Payments Council (BCD); this is the default
+	formatEPC = "epc"
+	// formatQRBill is the Swiss QR-bill (SPC)
+	formatQRBill = "qrbill"
+	// formatSPAYD is the Czech Short Payment Descriptor (SPD)
+	formatSPAYD = "spayd"
+	// formatPayBySquare is the Slovak PAY by square
+	formatPayBySquare = "paybysquare"
+	// formatHUB3 is the Croatian HUB3 PDF417 barcode
+	formatHUB3 = "hub3"
+	// formatUPN is the Slovenian UPN QR
+	formatUPN = "upn"
+)
+
+// hub3Width is the width of PNG images of HUB3 barcodes, in pixels
+const hub3Width = 900
+
+var (
+	// ErrFormat is returned when the format is not supported
+	ErrFormat = errors.New("format should be epc, qrbill, spayd, paybysquare, hub3 or upn")
+	// ErrCurrency is returned when the currency is not supported by the format
+	ErrCurrency = errors.New("currency is not supported by the format")
+	// ErrMessage is returned when both an unstructured remittance and a message are given for a QR-bill, HUB3 or UPN
+	ErrMessage = errors.New("the unstructured remittance is the message of a QR-bill or the description of HUB3 or UPN, so --message cannot be set as well")
+	// ErrDueDate is returned when the due date is not a date of the form YYYY-MM-DD
+	ErrDueDate = errors.New("due date should be a date of the form YYYY-MM-DD")
+	// ErrLastDate is returned when the last date of a standing order is not a date of the form YYYY-MM-DD
+	ErrLastDate = errors.New("last date should be a date of the form YYYY-MM-DD")
+	// ErrStandingOrder is returned when the day or last date of a standing order is set without its periodicity
+	ErrStandingOrder = errors.New("--standing-order-day and --last-date need the periodicity of --standing-order")
+)
+
+// addFormatFlags adds the flags that select the payload format, and the fields that only some formats have;
+// the help lists them under a heading of their own
+func (q *qrParams) addFormatFlags(to *pflag.FlagSet) {
+	flags := pflag.NewFlagSet("format", pflag.ContinueOnError)
+
+	flags.StringVar(&q.Format, "format", formatEPC, "Payload format: epc (SEPA), qrbill (Swiss QR-bill), spayd (Czech SPAYD), paybysquare (Slovak PAY by square), hub3 (Croatian HUB3) or upn (Slovenian UPN QR)")
+	flags.StringVar(&q.Currency, "currency", "", "Currency of the amount, empty for the default of the format: EUR for epc, paybysquare, hub3 and upn; CHF or EUR for qrbill; CZK for spayd")
+
+	c, d := &q.Creditor, &q.Debtor
+	flags.StringVar(&c.Street, "street", "", "Street of the beneficiary (qrbill, hub3, upn)")
+	flags.StringVar(&c.BuildingNumber, "building-number", "", "Building number of the beneficiary (qrbill, hub3, upn)")
+	flags.StringVar(&c.PostalCode, "postal-code", "", "Postal code of the beneficiary (qrbill, hub3, upn)")
+	flags.StringVar(&c.Town, "town", "", "Town of the beneficiary (qrbill, hub3, upn)")
+	flags.StringVar(&c.Country, "country", "", "2-letter country code of the beneficiary, eg. CH (qrbill)")
+	flags.StringVar(&d.Name, "debtor-name", "", "Name of the payer, empty for the payer to fill in (qrbill, hub3, upn)")
+	flags.StringVar(&d.Street, "debtor-street", "", "Street of the payer (qrbill, hub3, upn)")
+	flags.StringVar(&d.BuildingNumber, "debtor-building-number", "", "Building number of the payer (qrbill, hub3, upn)")
+	flags.StringVar(&d.PostalCode, "debtor-postal-code", "", "Postal code of the payer (qrbill, hub3, upn)")
+	flags.StringVar(&d.Town, "debtor-town", "", "Town of the payer (qrbill, hub3, upn)")
+	flags.StringVar(&d.Country, "debtor-country", "", "2-letter country code of the payer (qrbill)")
+	flags.StringVar(&q.Message, "message", "", "Unstructured message next to a structured remittance (qrbill), or its description (hub3) or purpose (upn)")
+	flags.StringVar(&q.BillInformation, "bill-information", "", "Structured bill information to automate the booking, eg. Swico S1 (qrbill)")
+
+	flags.StringVar(&q.VariableSymbol, "variable-symbol", "", "Variable symbol, eg. the invoice number, up to 10 digits (spayd, paybysquare)")
+	flags.StringVar(&q.ConstantSymbol, "constant-symbol", "", "Constant symbol, the kind of payment, up to 10 digits, or 4 for paybysquare (spayd, paybysquare)")
+	flags.StringVar(&q.SpecificSymbol, "specific-symbol", "", "Specific symbol, eg. the customer number, up to 10 digits (spayd, paybysquare)")
+	flags.StringVar(&q.DueDate, "due-date", "", "Date the payment is due, or of the first payment of a standing order, as YYYY-MM-DD (spayd, paybysquare, upn)")
+	flags.BoolVar(&q.CRC32, "crc32", false, "Add a CRC32 checksum of the payload (spayd)")
+	flags.StringVar(&q.StandingOrder, "standing-order", "", "Make a standing order that repeats: daily, weekly, biweekly, monthly, bimonthly, quarterly, semiannually or annually (paybysquare)")
+	flags.IntVar(&q.StandingOrderDay, "standing-order-day", 0, "Day of the standing order: 1..7 (Monday..Sunday) when weekly, 1..31 otherwise (paybysquare)")
+	flags.StringVar(&q.LastDate, "last-date", "", "Date of the last payment of the standing order, as YYYY-MM-DD (paybysquare)")
+
+	flags.VisitAll(func(f *pflag.Flag) {
+		f.Annotations = map[string][]string{formatFlagAnnotation: nil}
+	})
+
+	to.AddFlagSet(flags)
+}
+
+// address is the postal address of the beneficiary or the payer, which each format maps to its own fields;
+// the name of the beneficiary is that of the payment
+type address struct {
+	Name           string
+	Street         string
+	BuildingNumber string
+	PostalCode     string
+	Town           string
+	Country        string
+}
+
+// street returns the street and building number on one line
+func (a address) street() string {
+	return addressLine(a.Street, a.BuildingNumber)
+}
+
+// place returns the postal code and town on one line
+func (a address) place() string {
+	return addressLine(a.PostalCode, a.Town)
+}
+
+// checkFormat returns an error when the format or its currency is not supported
+func (q *qrParams) checkFormat() error {
+	switch q.Format {
+	case formatEPC, formatUPN:
+		if q.Currency != "" && q.Currency != "EUR" {
+			return fmt.Errorf("%w: %s: %q", ErrCurrency, q.Format, q.Currency)
+		}
+	case formatQRBill:
+		if q.Currency != "" && q.Currency != string(qrbill.CurrencyCHF) && q.Currency != string(qrbill.CurrencyEUR) {
+			return fmt.Errorf("%w: %s: %q", ErrCurrency, q.Format, q.Currency)
+		}
+	case formatSPAYD, formatPayBySquare, formatHUB3:
+		// Any ISO 4217 currency, which the payment validates
+	default:
+		return fmt.Errorf("%w: %q", ErrFormat, q.Format)
+	}
+
+	return nil
+}
+
+// code is the payment in one of the payload formats
+type code interface {
+	ToString() (string, error)
+	IsValid() error
+}
+
+// code returns the payment in the selected format
+func (q *qrParams) code() (code, error) {
+	switch q.Format {
+	case formatQRBill:
+		return q.bill()
+	case formatSPAYD:
+		return q.spayd()
+	case formatPayBySquare:
+		return q.payBySquare()
+	case formatHUB3:
+		return q.hub3()
+	case formatUPN:
+		return q.upn()
+	}
+
+	return q.Payment, nil
+}
+
+// bill returns the QR-bill of the payment: the IBAN is the account, the name is the creditor, and the
+// remittance is the reference when it is structured, and the message otherwise
+func (q *qrParams) bill() (*qrbill.Bill, error) {
+	p := q.Payment
+
+	b := qrbill.New()
+	b.Account = p.IBANBeneficiary
+	b.Creditor = qrbill.Address(q.Creditor)
+	b.Creditor.Name = p.NameBeneficiary
+	b.Debtor = qrbill.Address(q.Debtor)
+	b.Amount = p.EuroAmount
+	b.Message = q.Message
+	b.BillInformation = q.BillInformation
+
+	if q.Currency != "" {
+		b.Currency = qrbill.Currency(q.Currency)
+	}
+
+	switch {
+	case p.RemittanceIsStructured:
+		b.Reference = p.Remittance
+	case p.Remittance != "" && q.Message != "":
+		return nil, ErrMessage
+	case p.Remittance != "":
+		b.Message = p.Remittance
+	}
+
+	return b, nil
+}
+
+// renderBill returns the QR-bill in the selected output type: the QR code with the Swiss cross as PNG or
+// for the terminal, or the payment part with receipt as SVG or PDF
+func (q *qrParams) renderBill() ([]byte, error) {
+	b, err := q.bill()
+	if err != nil {
+		return nil, err
+	}
+
+	switch q.OutputType {
+	case "png":
+		return b.ToQRPNG(qrSize)
+	case "svg":
+		return b.ToSVG()
+	case "pdf":
+		return b.ToPDF(payment.PDFOptions{PageSize: payment.PageSize(q.PageSize)})
+	case "stdout":
+		return b.ToQRBytes()
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrOutputType, q.OutputType)
+}
+
+// isQRReference returns whether the remittance is a QR reference of a QR-bill, or a model and reference number
+// of HUB3 or UPN, for --structured auto
+func (q *qrParams) isQRReference() bool {
+	switch q.Format {
+	case formatQRBill:
+		return qrbill.IsQRReference(q.Payment.Remittance)
+	case formatHUB3:
+		return hub3.IsModelReference(q.Payment.Remittance)
+	case formatUPN:
+		return upn.IsModelReference(q.Payment.Remittance)
+	}
+
+	return false
+}
+
+// spayd returns the Czech SPAYD of the payment, with the symbols and the due date of the flags; the remittance
+// is the message
+func (q *qrParams) spayd() (*spayd.Payment, error) {
+	s := spayd.New()
+	s.Payment = q.Payment
+	s.VariableSymbol = q.VariableSymbol
+	s.ConstantSymbol = q.ConstantSymbol
+	s.SpecificSymbol = q.SpecificSymbol
+	s.CRC32 = q.CRC32
+
+	if q.Currency != "" {
+		s.Currency = q.Currency
+	}
+
+	t, err := parseDate(q.DueDate, ErrDueDate)
+	if err != nil {
+		return nil, err
+	}
+
+	s.DueDate = t
+
+	return s, nil
+}
+
+// parseDate returns the date of the flag, or the zero date when it is empty
+func parseDate(s string, errFormat error) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %q", errFormat, s)
+	}
+
+	return t, nil
+}
+
+// renderQR returns the QR code of a format in the selected output type: png, svg or stdout
+func (q *qrParams) renderQR(e qrcode.Encoder) ([]byte, error) {
+	switch q.OutputType {
+	case "png":
+		return qrcode.ToPNG(e, q.QR, qrSize)
+	case "svg":
+		return qrcode.ToSVG(e, q.QR, q.SVG)
+	case "stdout":
+		return qrcode.ToBytes(e, q.QR)
+	}
+
+	return nil, fmt.Errorf("%w: %q is not supported by %s", ErrOutputType, q.OutputType, q.Format)
+}
+
+// renderSPAYD returns the QR code of the Czech SPAYD in the selected output type
+func (q *qrParams) renderSPAYD() ([]byte, error) {
+	s, err := q.spayd()
+	if err != nil {
+		return nil, err
+	}
+
+	return q.renderQR(s)
+}
+
+// payBySquare returns the Slovak PAY by square of the payment, with the symbols and the due date of the flags,
+// and a standing order when its periodicity is set
+func (q *qrParams) payBySquare() (*paybysquare.Payment, error) {
+	s := paybysquare.New()
+	s.Payment = q.Payment
+	s.VariableSymbol = q.VariableSymbol
+	s.ConstantSymbol = q.ConstantSymbol
+	s.SpecificSymbol = q.SpecificSymbol
+
+	if q.Currency != "" {
+		s.Currency = q.Currency
+	}
+
+	dueDate, err := parseDate(q.DueDate, ErrDueDate)
+	if err != nil {
+		return nil, err
+	}
+
+	lastDate, err := parseDate(q.LastDate, ErrLastDate)
+	if err != nil {
+		return nil, err
+	}
+
+	s.DueDate = dueDate
+
+	if q.StandingOrder == "" {
+		if q.StandingOrderDay != 0 || q.LastDate != "" {
+			return nil, ErrStandingOrder
+		}
+
+		return s, nil
+	}
+
+	periodicity, err := paybysquare.ParsePeriodicity(q.StandingOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	s.StandingOrder = &paybysquare.StandingOrder{Periodicity: periodicity, Day: q.StandingOrderDay, LastDate: lastDate}
+
+	return s, nil
+}
+
+// renderPayBySquare returns the QR code of the Slovak PAY by square in the selected output type
+func (q *qrParams) renderPayBySquare() ([]byte, error) {
+	s, err := q.payBySquare()
+	if err != nil {
+		return nil, err
+	}
+
+	return q.renderQR(s)
+}
+
+// hub3 returns the Croatian HUB3 payment of the payment: the beneficiary is the payee, the debtor the payer, and
+// the message the description next to a structured remittance
+func (q *qrParams) hub3() (*hub3.Payment, error) {
+	p := q.Payment
+
+	if !p.RemittanceIsStructured && p.Remittance != "" && q.Message != "" {
+		return nil, ErrMessage
+	}
+
+	h := hub3.New()
+	h.Payment = p
+	h.Payer = hub3.Party{Name: q.Debtor.Name, Street: q.Debtor.street(), Place: q.Debtor.place()}
+	h.PayeeStreet = q.Creditor.street()
+	h.PayeePlace = q.Creditor.place()
+	h.Description = q.Message
+
+	if q.Currency != "" {
+		h.Currency = q.Currency
+	}
+
+	return h, nil
+}
+
+// addressLine joins the parts of an address line that are set, eg. the postal code and the town
+func addressLine(parts ...string) string {
+	return strings.Join(slices.DeleteFunc(parts, func(s string) bool { return s == "" }), " ")
+}
+
+// renderHUB3 returns the PDF417 barcode of the Croatian HUB3 payment as PNG or SVG
+func (q *qrParams) renderHUB3() ([]byte, error) {
+	h, err := q.hub3()
+	if err != nil {
+		return nil, err
+	}
+
+	switch q.OutputType {
+	case "png":
+		return h.ToPNG(hub3Width)
+	case "svg":
+		return h.ToSVG(q.SVG)
+	}
+
+	return nil, fmt.Errorf("%w: %q is not supported by %s", ErrOutputType, q.OutputType, q.Format)
+}
+
+// upn returns the Slovenian UPN payment of the payment: the beneficiary is the payee, the debtor the payer, the
+// message the purpose next to a structured remittance, and the due date the deadline of the payment
+func (q *qrParams) upn() (*upn.Payment, error) {
+	p := q.Payment
+
+	if !p.RemittanceIsStructured && p.Remittance != "" && q.Message != "" {
+		return nil, ErrMessage
+	}
+
+	dueDate, err := parseDate(q.DueDate, ErrDueDate)
+	if err != nil {
+		return nil, err
+	}
+
+	u := upn.New()
+	u.Payment = p
+	u.Payer = upn.Payer{Name: q.Debtor.Name, Street: q.Debtor.street(), Place: q.Debtor.place()}
+	u.PayeeStreet = q.Creditor.street()
+	u.PayeePlace = q.Creditor.place()
+	u.Description = q.Message
+	u.DueDate = dueDate
+
+	return u, nil
+}
+
+// renderUPN returns the QR code of the Slovenian UPN payment in the selected output type
+func (q *qrParams) renderUPN() ([]byte, error) {
+	u, err := q.upn()
+	if err != nil {
+		return nil, err
+	}
+
+	return q.renderQR(u)
+}
+
+// printDebug prints the payload for --debug: line by line for an EPC payload, as payme inspect does, and
+// as it is for the other formats
+func (q *qrParams) printDebug(w io.Writer) error {
+	if q.Format == formatEPC {
+		printInspection(w, q.Payment, q.QR)
+		return nil
+	}
+
+	c, err := q.code()
+	if err != nil {
+		return err
+	}
+
+	s, err := c.ToString()
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "Payload: %s\n", s)
+
+	return err
+}
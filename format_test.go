@@ -0,0 +1,314 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jovandeginste/payme/paybysquare"
+	"github.com/jovandeginste/payme/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// billArgs are the flags of a valid QR-bill with a QR-IBAN
+var billArgs = []string{
+	"--format", "qrbill", "--name", "Robert Schneider AG", "--iban", "CH4431999123000889012",
+	"--street", "Rue du Lac", "--building-number", "1268", "--postal-code", "2501", "--town", "Biel", "--country", "CH",
+	"--amount", "1949.75", "--remittance", "210000000003139471430009017", "--structured=auto",
+}
+
+// payBySquareArgs are the flags of a valid PAY by square
+var payBySquareArgs = []string{"--format", "paybysquare", "--name", "Jan Novak", "--iban", "SK3112000000198742637541", "--amount", "5"}
+
+// hub3Args are the flags of a valid HUB3 payment with a model and reference
+var hub3Args = []string{
+	"--format", "hub3", "--name", "Čistoća d.o.o.", "--iban", "HR1210010051863000160", "--amount", "123.55",
+	"--street", "Ilica", "--building-number", "1", "--postal-code", "10000", "--town", "Zagreb",
+	"--remittance", "HR01 7269-68-0800", "--structured=auto", "--message", "Račun 2026-01", "--purpose", "COST", "--output", "svg",
+}
+
+// upnArgs are the flags of a valid UPN payment with a reference with model SI12
+var upnArgs = []string{
+	"--format", "upn", "--name", "Elektro d.d.", "--iban", "SI56 0201 7001 4356 205", "--amount", "123.55",
+	"--street", "Slovenska cesta", "--building-number", "58", "--postal-code", "1000", "--town", "Ljubljana",
+	"--remittance", "SI12 1234560", "--structured=auto", "--message", "Račun za elektriko 01/2026", "--purpose", "ELEC",
+	"--due-date", "2026-02-15", "--output", "svg",
+}
+
+func TestFormatQRBill(t *testing.T) {
+	writeTestConfig(t, "")
+
+	file := filepath.Join(t.TempDir(), "qr.png")
+
+	_, code := runExit(t, "", append(billArgs, "--output", "png", "--file", file, "--message", "Order of 15 June 2020")...)
+	require.Equal(t, exitOK, code)
+
+	f, err := os.Open(file)
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	data, err := payment.DecodeQRImage(f)
+	require.NoError(t, err)
+
+	lines := strings.Split(string(data), "\n")
+	assert.Equal(t, "SPC", lines[0])
+	assert.Equal(t, []string{"1949.75", "CHF"}, lines[18:20])
+	assert.Equal(t, []string{"QRR", "210000000003139471430009017", "Order of 15 June 2020", "EPD"}, lines[27:31])
+}
+
+func TestFormatQRBillOutput(t *testing.T) {
+	writeTestConfig(t, "")
+
+	out, code := runExit(t, "", append(billArgs, "--output", "svg")...)
+	require.Equal(t, exitOK, code)
+	assert.Contains(t, out, ">Payment part</text>")
+
+	out, code = runExit(t, "", append(billArgs, "--output", "pdf", "--page-size", "a6")...)
+	require.Equal(t, exitOK, code)
+	assert.Contains(t, out, "(Payment part)")
+	assert.NotContains(t, out, "(Receipt)")
+}
+
+func TestFormatQRBillRemittance(t *testing.T) {
+	writeTestConfig(t, "")
+
+	args := []string{
+		"--format", "qrbill", "--name", "Robert Schneider AG", "--iban", "CH5800791123000889012",
+		"--postal-code", "2501", "--town", "Biel", "--country", "CH", "--currency", "EUR",
+	}
+
+	q, err := runGenerate(t, args...)
+	require.NoError(t, err)
+
+	b, err := q.bill()
+	require.NoError(t, err)
+	assert.Equal(t, "Invoice 1234", b.Message, "an unstructured remittance is the message")
+	assert.Empty(t, b.Reference)
+	assert.EqualValues(t, "EUR", b.Currency)
+
+	_, err = runGenerate(t, append(args, "--message", "Thank you")...)
+	require.ErrorIs(t, err, ErrMessage)
+}
+
+func TestFormatSPAYD(t *testing.T) {
+	writeTestConfig(t, "")
+
+	file := filepath.Join(t.TempDir(), "qr.png")
+
+	_, code := runExit(t, "", "--format", "spayd", "--iban", "CZ2806000000000168540115", "--amount", "450",
+		"--remittance", "PLATBA ZA ZBOZI", "--variable-symbol", "1234567890", "--due-date", "2026-11-30", "--crc32",
+		"--output", "png", "--file", file)
+	require.Equal(t, exitOK, code)
+
+	f, err := os.Open(file)
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	data, err := payment.DecodeQRImage(f)
+	require.NoError(t, err)
+	assert.Regexp(t, `^SPD\*1\.0\*ACC:CZ2806000000000168540115\*AM:450\.00\*CC:CZK\*DT:20261130\*MSG:PLATBA ZA ZBOZI\*X-VS:1234567890\*CRC32:[0-9A-F]{8}$`, string(data))
+}
+
+func TestFormatPayBySquare(t *testing.T) {
+	writeTestConfig(t, "")
+
+	file := filepath.Join(t.TempDir(), "qr.png")
+
+	_, code := runExit(t, "", "--format", "paybysquare", "--name", "Ján Novák", "--iban", "SK3112000000198742637541",
+		"--amount", "25.30", "--remittance", "Faktúra 2026-001", "--variable-symbol", "2026001", "--constant-symbol", "0308",
+		"--due-date", "2026-11-15", "--standing-order", "monthly", "--standing-order-day", "15", "--last-date", "2027-11-15",
+		"--output", "png", "--file", file)
+	require.Equal(t, exitOK, code)
+
+	f, err := os.Open(file)
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	data, err := payment.DecodeQRImage(f)
+	require.NoError(t, err)
+
+	s, err := paybysquare.Parse(string(data))
+	require.NoError(t, err)
+	assert.Equal(t, "SK3112000000198742637541", s.Payment.IBANBeneficiary)
+	assert.Equal(t, "Ján Novák", s.Payment.NameBeneficiary)
+	assert.Equal(t, payment.Amount(2530), s.Payment.EuroAmount)
+	assert.Equal(t, "EUR", s.Currency)
+	assert.Equal(t, "Faktúra 2026-001", s.Payment.Remittance)
+	assert.Equal(t, "2026001", s.VariableSymbol)
+	assert.Equal(t, "0308", s.ConstantSymbol)
+	assert.Equal(t, "2026-11-15", s.DueDate.Format(time.DateOnly))
+	require.NotNil(t, s.StandingOrder)
+	assert.Equal(t, paybysquare.Monthly, s.StandingOrder.Periodicity)
+	assert.Equal(t, 15, s.StandingOrder.Day)
+	assert.Equal(t, "2027-11-15", s.StandingOrder.LastDate.Format(time.DateOnly))
+}
+
+func TestFormatHUB3(t *testing.T) {
+	writeTestConfig(t, "")
+
+	dir := t.TempDir()
+	args := append(hub3Args, "--debtor-name", "Zlatko Marić", "--debtor-street", "Palih boraca", "--debtor-building-number", "1",
+		"--debtor-postal-code", "10000", "--debtor-town", "Zagreb")
+
+	for _, output := range []string{"png", "svg"} {
+		file := filepath.Join(dir, "slip."+output)
+
+		_, code := runExit(t, "", append(args, "--output", output, "--file", file)...)
+		require.Equal(t, exitOK, code, output)
+
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.NotEmpty(t, data)
+	}
+
+	q := qrParams{Payment: payment.New(), Format: formatHUB3}
+	q.Payment.Remittance = "HR01 7269-68-0800"
+	q.Creditor.Street = "Ilica"
+	q.Creditor.BuildingNumber = "1"
+	q.Creditor.Town = "Zagreb"
+	q.Message = "Račun 2026-01"
+	q.Structured.auto = true
+
+	require.NoError(t, q.prepare())
+	assert.True(t, q.Payment.RemittanceIsStructured, "a model and reference is structured for hub3")
+
+	h, err := q.hub3()
+	require.NoError(t, err)
+	assert.Equal(t, "Ilica 1", h.PayeeStreet)
+	assert.Equal(t, "Zagreb", h.PayeePlace)
+	assert.Equal(t, "Račun 2026-01", h.Description)
+
+	q.Payment.RemittanceIsStructured = false
+
+	_, err = q.hub3()
+	require.ErrorIs(t, err, ErrMessage)
+}
+
+func TestFormatUPN(t *testing.T) {
+	writeTestConfig(t, "")
+
+	dir := t.TempDir()
+	args := append(upnArgs, "--debtor-name", "Janez Novak", "--debtor-street", "Dunajska cesta", "--debtor-building-number", "1",
+		"--debtor-postal-code", "1000", "--debtor-town", "Ljubljana")
+
+	for _, output := range []string{"png", "svg"} {
+		file := filepath.Join(dir, "upn."+output)
+
+		_, code := runExit(t, "", append(args, "--output", output, "--file", file)...)
+		require.Equal(t, exitOK, code, output)
+
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.NotEmpty(t, data)
+	}
+
+	q := qrParams{Payment: payment.New(), Format: formatUPN, DueDate: "2026-02-15"}
+	q.Payment.Remittance = "SI12 1234560"
+	q.Creditor.Street = "Slovenska cesta"
+	q.Creditor.BuildingNumber = "58"
+	q.Creditor.PostalCode = "1000"
+	q.Creditor.Town = "Ljubljana"
+	q.Message = "Račun za elektriko 01/2026"
+	q.Structured.auto = true
+
+	require.NoError(t, q.prepare())
+	assert.True(t, q.Payment.RemittanceIsStructured, "a model and reference is structured for upn")
+
+	u, err := q.upn()
+	require.NoError(t, err)
+	assert.Equal(t, "Slovenska cesta 58", u.PayeeStreet)
+	assert.Equal(t, "1000 Ljubljana", u.PayeePlace)
+	assert.Equal(t, "Račun za elektriko 01/2026", u.Description)
+	assert.Equal(t, "2026-02-15", u.DueDate.Format(time.DateOnly))
+
+	q.Payment.RemittanceIsStructured = false
+
+	_, err = q.upn()
+	require.ErrorIs(t, err, ErrMessage)
+}
+
+func TestFormatErrors(t *testing.T) {
+	writeTestConfig(t, "")
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected int
+	}{
+		{"format", append(billArgs, "--format", "mt940"), exitUsage},
+		{"epc currency", []string{"--name", "Jane Doe", "--iban", "BE68539007547034", "--amount", "5", "--currency", "CHF"}, exitUsage},
+		{"qrbill currency", append(billArgs, "--currency", "USD"), exitUsage},
+		{"foreign IBAN", append(billArgs, "--iban", "BE68539007547034"), exitValidation},
+		{"missing town", append(billArgs, "--town", ""), exitValidation},
+		{"due date", []string{"--format", "spayd", "--iban", "CZ2806000000000168540115", "--amount", "5", "--due-date", "30.11.2026"}, exitUsage},
+		{"spayd pdf", []string{"--format", "spayd", "--iban", "CZ2806000000000168540115", "--amount", "5", "--output", "pdf"}, exitUsage},
+		{"variable symbol", []string{"--format", "spayd", "--iban", "CZ2806000000000168540115", "--amount", "5", "--variable-symbol", "INV-1"}, exitValidation},
+		{"paybysquare periodicity", append(payBySquareArgs, "--standing-order", "yearly"), exitUsage},
+		{"paybysquare day", append(payBySquareArgs, "--standing-order-day", "15"), exitUsage},
+		{"paybysquare last date", append(payBySquareArgs, "--standing-order", "monthly", "--last-date", "15.11.2027"), exitUsage},
+		{"paybysquare pdf", append(payBySquareArgs, "--output", "pdf"), exitUsage},
+		{"paybysquare weekday", append(payBySquareArgs, "--standing-order", "weekly", "--standing-order-day", "15"), exitValidation},
+		{"paybysquare constant symbol", append(payBySquareArgs, "--constant-symbol", "03080"), exitValidation},
+		{"hub3 stdout", append(hub3Args, "--output", "stdout"), exitUsage},
+		{"hub3 message", append(hub3Args, "--remittance", "Račun", "--structured=false"), exitUsage},
+		{"hub3 reference", append(hub3Args, "--remittance", "HR01 12A4"), exitValidation},
+		{"hub3 IBAN", append(hub3Args, "--iban", "DE71110220330123456789"), exitValidation},
+		{"upn currency", append(upnArgs, "--currency", "CHF"), exitUsage},
+		{"upn pdf", append(upnArgs, "--output", "pdf"), exitUsage},
+		{"upn due date", append(upnArgs, "--due-date", "15.02.2026"), exitUsage},
+		{"upn check digit", append(upnArgs, "--remittance", "SI12 1234561"), exitValidation},
+		{"upn purpose", append(upnArgs, "--message", ""), exitValidation},
+	}
+
+	for _, tc := range tests {
+		_, code := runExit(t, "", tc.args...)
+		assert.Equal(t, tc.expected, code, tc.name)
+	}
+}
+
+func TestFormatFlagsHelp(t *testing.T) {
+	q := qrParams{
+		Payment: payment.New(),
+	}
+
+	cmdRoot, err := newCommand(&q)
+	require.NoError(t, err)
+
+	for _, args := range [][]string{{"--help"}, {"batch", "--help"}} {
+		out := new(strings.Builder)
+
+		cmdRoot.SetArgs(args)
+		cmdRoot.SetOut(out)
+		require.NoError(t, cmdRoot.Execute())
+
+		flags, format, ok := strings.Cut(out.String(), "\nFormat flags:\n")
+		require.True(t, ok, args)
+		assert.Contains(t, flags, "--iban string")
+		assert.NotContains(t, flags, "--street string", args)
+		assert.Contains(t, format, "--street string", args)
+		assert.NotContains(t, format, "--iban string", args)
+	}
+}
+
+func TestFormatDoesNotChangeFlags(t *testing.T) {
+	writeTestConfig(t, "")
+
+	q, err := runGenerate(t, "--format", "qrbill", "--name", "Robert Schneider AG", "--iban", "CH5800791123000889012",
+		"--postal-code", "2501", "--town", "Biel", "--country", "CH")
+	require.NoError(t, err)
+
+	for range 2 {
+		b, err := q.bill()
+		require.NoError(t, err, "the bill is built anew every time")
+		assert.Equal(t, "Invoice 1234", b.Message)
+		assert.Equal(t, "Biel", b.Creditor.Town)
+	}
+
+	assert.Empty(t, q.Message)
+}
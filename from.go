@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/spf13/pflag"
+)
+
+// fromFlags are the flags that the fields of a payment file set, by field name; they return the flag name and its value.
+// Fields without a flag are set on the payment directly.
+var fromFlags = map[string]func(p *payment.Payment) (string, string){
+	"name":       func(p *payment.Payment) (string, string) { return "name", p.NameBeneficiary },
+	"iban":       func(p *payment.Payment) (string, string) { return "iban", p.IBANBeneficiary },
+	"bic":        func(p *payment.Payment) (string, string) { return "bic", p.BICBeneficiary },
+	"purpose":    func(p *payment.Payment) (string, string) { return "purpose", p.Purpose },
+	"remittance": func(p *payment.Payment) (string, string) { return "remittance", p.Remittance },
+	"b2o_information": func(p *payment.Payment) (string, string) {
+		return "b2o-information", p.B2OInformation
+	},
+	"structured": func(p *payment.Payment) (string, string) {
+		return "structured", strconv.FormatBool(p.RemittanceIsStructured)
+	},
+	"character_set": func(p *payment.Payment) (string, string) { return "character-set", strconv.Itoa(p.CharacterSet) },
+	"version":       func(p *payment.Payment) (string, string) { return "qr-version", strconv.Itoa(p.Version) },
+	"amount": func(p *payment.Payment) (string, string) {
+		if p.IsOpenAmount() {
+			return "open-amount", "true"
+		}
+
+		return "amount", p.EuroAmount.String()
+	},
+}
+
+// readFrom reads the payment file selected with --from (- for stdin), and returns the flags its fields set;
+// the fields that have no flag are set on the payment
+func (q *qrParams) readFrom(flags *pflag.FlagSet, stdin io.Reader) (map[string]string, error) {
+	if q.From == "" {
+		return nil, nil
+	}
+
+	data, err := readInput(q.From, stdin)
+	if err != nil {
+		return nil, err
+	}
+
+	format := payment.FileFormat(q.FromFormat)
+
+	switch {
+	case format != "auto":
+	case q.From == "-":
+		// YAML reads JSON as well
+		format = payment.FileFormatYAML
+	default:
+		if format, err = payment.FileFormatFor(q.From); err != nil {
+			return nil, err
+		}
+	}
+
+	p := *q.Payment
+
+	keys, err := p.UnmarshalFile(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", q.From, err)
+	}
+
+	settings := map[string]string{}
+
+	for _, key := range keys {
+		fromFlag, ok := fromFlags[key]
+		if !ok {
+			continue
+		}
+
+		// Either flag on the command line replaces the amount of the file
+		if key == "amount" && (flags.Changed("amount") || flags.Changed("open-amount")) {
+			continue
+		}
+
+		name, value := fromFlag(&p)
+		settings[name] = value
+	}
+
+	q.Payment.ServiceTag = p.ServiceTag
+	q.Payment.IdentificationCode = p.IdentificationCode
+
+	return settings, nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runFrom(t *testing.T, stdin string, args ...string) (*qrParams, error) {
+	t.Helper()
+
+	q := qrParams{
+		Payment: payment.New(),
+	}
+
+	cmdRoot, err := newCommand(&q)
+	require.NoError(t, err)
+
+	cmdRoot.SetIn(strings.NewReader(stdin))
+	cmdRoot.SetArgs(append(args, "--file", filepath.Join(t.TempDir(), "qr")))
+	cmdRoot.SilenceErrors = true
+	cmdRoot.SilenceUsage = true
+
+	return &q, cmdRoot.Execute()
+}
+
+func writeFromFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestFromJSON(t *testing.T) {
+	writeTestConfig(t, "")
+
+	path := writeFromFile(t, "payment.json", `{
+		"name": "Franz Mustermänn",
+		"iban": "DE71110220330123456789",
+		"amount": "12.30",
+		"remittance": "RF18539007547034",
+		"structured": true,
+		"b2o_information": "Thank you"
+	}`)
+
+	q, err := runFrom(t, "", "--from", path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Franz Mustermänn", q.Payment.NameBeneficiary)
+	assert.Equal(t, payment.Amount(1230), q.Payment.EuroAmount)
+	assert.True(t, q.Payment.RemittanceIsStructured)
+	assert.Equal(t, "Thank you", q.Payment.B2OInformation)
+}
+
+func TestFromStdin(t *testing.T) {
+	writeTestConfig(t, "")
+
+	yaml := `
+name: Jane Doe
+iban: BE68539007547034
+amount: 5
+remittance: Invoice 1234
+`
+
+	q, err := runFrom(t, yaml, "--from", "-")
+	require.NoError(t, err)
+	assert.Equal(t, "Jane Doe", q.Payment.NameBeneficiary)
+	assert.Equal(t, payment.Amount(500), q.Payment.EuroAmount)
+
+	toml := `
+name = "Jane Doe"
+iban = "BE68539007547034"
+amount = 5
+remittance = "Invoice 1234"
+`
+
+	q, err = runFrom(t, toml, "--from", "-", "--from-format", "toml")
+	require.NoError(t, err)
+	assert.Equal(t, payment.Amount(500), q.Payment.EuroAmount)
+}
+
+func TestFromPrecedence(t *testing.T) {
+	writeTestConfig(t, testConfig)
+
+	path := writeFromFile(t, "payment.toml", `
+name = "Jane Doe"
+amount = "12.30"
+remittance = "Invoice 1234"
+`)
+
+	t.Setenv("PAYME_REMITTANCE", "Invoice 5678")
+
+	q, err := runFrom(t, "", "--from", path, "--amount", "5")
+	require.NoError(t, err)
+
+	// Flag > file > environment > profile
+	assert.Equal(t, payment.Amount(500), q.Payment.EuroAmount)
+	assert.Equal(t, "Jane Doe", q.Payment.NameBeneficiary)
+	assert.Equal(t, "Invoice 1234", q.Payment.Remittance)
+	assert.Equal(t, "DE71110220330123456789", q.Payment.IBANBeneficiary)
+	assert.Equal(t, "CHAR", q.Payment.Purpose)
+}
+
+func TestFromOpenAmount(t *testing.T) {
+	writeTestConfig(t, "")
+
+	path := writeFromFile(t, "payment.yaml", `
+name: Stichting Het Goede Doel
+iban: NL91ABNA0417164300
+amount: 0
+remittance: Donation
+`)
+
+	q, err := runFrom(t, "", "--from", path)
+	require.NoError(t, err)
+	assert.True(t, q.Payment.IsOpenAmount())
+	assert.True(t, q.OpenAmount)
+
+	q, err = runFrom(t, "", "--from", path, "--amount", "10")
+	require.NoError(t, err)
+	assert.Equal(t, payment.Amount(1000), q.Payment.EuroAmount)
+}
+
+func TestFromErrors(t *testing.T) {
+	writeTestConfig(t, "")
+
+	_, err := runFrom(t, "", "--from", writeFromFile(t, "payment.txt", `name: Jane Doe`))
+	require.ErrorIs(t, err, payment.ErrFileFormatExtension)
+
+	_, err = runFrom(t, "", "--from", writeFromFile(t, "payment.yaml", `nmae: Jane Doe`))
+	require.Error(t, err)
+
+	_, err = runFrom(t, "", "--from", filepath.Join(t.TempDir(), "missing.json"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
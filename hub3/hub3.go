@@ -0,0 +1,302 @@
+// Package hub3 encodes the 2D barcode of Croatian payment slips (HUB3, by the Croatian Banking Association): the
+// fields of the payment on lines of their own, in ISO 8859-2, rendered as a PDF417 barcode.
+//
+// See: https://www.hub.hr/sites/default/files/inline-files/2dbc_0.pdf
+package hub3
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/jovandeginste/payme/payment"
+	"golang.org/x/text/encoding/charmap"
+)
+
+const (
+	// header is the first field of every payload: the HUB3 tag and version
+	header = "HRVHUB30"
+
+	// CurrencyEUR is the euro; this is the default
+	CurrencyEUR = "EUR"
+	// ModelNone is the model of a payment without a reference number
+	ModelNone = "HR99"
+)
+
+// Largest fields, in characters
+const (
+	maxPayerName    = 30
+	maxPayerStreet  = 27
+	maxPayerPlace   = 27
+	maxPayeeName    = 25
+	maxPayeeStreet  = 25
+	maxPayeePlace   = 27
+	maxAccount      = 21
+	maxReference    = 22
+	maxDescription  = 35
+	amountDigits    = 15
+	referenceGroups = 3
+)
+
+var (
+	currencyValidator  = regexp.MustCompile(`^[A-Z]{3}$`)
+	purposeValidator   = regexp.MustCompile(`^[A-Z]{4}$`)
+	modelValidator     = regexp.MustCompile(`^(HR\d{2}|RF\d{2})$`)
+	referenceValidator = regexp.MustCompile(`^\d+(-\d+)*$`)
+
+	// ErrValidationAccount is returned when the account is not a valid IBAN of at most 21 characters
+	ErrValidationAccount = errors.New("field 'IBANBeneficiary' should be a valid IBAN of at most 21 characters, eg. HR1210010051863000160")
+	// ErrValidationAmount is returned when the amount is not 0 (open amount), or 0.01 or more and 999999999.99 or less
+	ErrValidationAmount = errors.New("field 'EuroAmount' must be 0 (open amount), or 0.01 or more and 999999999.99 or less")
+	// ErrValidationCurrency is returned when the currency is not a 3-letter ISO 4217 code
+	ErrValidationCurrency = errors.New("field 'Currency' should be a 3-letter currency code (ISO 4217), eg. EUR")
+	// ErrValidationRequired is returned when a required field is empty
+	ErrValidationRequired = errors.New("field is required")
+	// ErrValidationTooLong is returned when a field exceeds its number of characters
+	ErrValidationTooLong = errors.New("field is too long")
+	// ErrValidationCharacters is returned when a field has characters outside ISO 8859-2, or control characters
+	ErrValidationCharacters = errors.New("field should only contain printable characters of ISO 8859-2")
+	// ErrValidationPurpose is returned when the purpose code is not 4 upper case letters
+	ErrValidationPurpose = errors.New("field 'Purpose' should be a purpose code of 4 upper case letters (ISO 20022), eg. COST")
+	// ErrValidationModel is returned when the model of the reference is not HR00..HR99 or RF followed by its
+	// check digits
+	ErrValidationModel = errors.New("model of the reference should be HR00..HR99, or RF and the check digits of an RF creditor reference")
+	// ErrValidationReference is returned when the reference number does not fit its model
+	ErrValidationReference = errors.New("reference number should be up to 22 digits, in at most 3 groups separated by -, and empty for model HR99")
+	// ErrValidationRFReference is returned when a reference with model RF is not a valid ISO 11649 reference
+	ErrValidationRFReference = errors.New("reference with model RF is not a valid RF creditor reference")
+)
+
+// Party is the name and address of the payer, as printed on the slip
+type Party struct {
+	// Name is the name of the payer (up to 30 characters)
+	Name string
+	// Street is the street and building number (up to 27 characters)
+	Street string
+	// Place is the postal code and town (up to 27 characters)
+	Place string
+}
+
+// Payment is a payment in the HUB3 format: the account, name, amount, purpose and remittance of a
+// payment.Payment, with the addresses of the payer and payee
+type Payment struct {
+	// Payment holds the IBAN and name of the payee, the amount and the purpose code, and the remittance: the
+	// model and reference number when it is structured (eg. HR01 7269-68-0800), and the description otherwise;
+	// its other fields are not part of the payload
+	Payment *payment.Payment
+	// Currency is the 3-letter ISO 4217 code of the amount, eg. EUR
+	Currency string
+	// Payer is the name and address of the payer; leave it empty for the payer to fill in
+	Payer Party
+	// PayeeStreet is the street and building number of the payee (up to 25 characters)
+	PayeeStreet string
+	// PayeePlace is the postal code and town of the payee (up to 27 characters)
+	PayeePlace string
+	// Description is the description of a payment with a structured remittance (up to 35 characters)
+	Description string
+}
+
+// New returns a new Payment in euro
+func New() *Payment {
+	return &Payment{Payment: payment.New(), Currency: CurrencyEUR}
+}
+
+// Reference returns the model and the reference number: the structured remittance split after its model, or
+// HR99 without a reference number when the remittance is unstructured
+func (h *Payment) Reference() (string, string) {
+	p := h.Payment
+	if !p.RemittanceIsStructured {
+		return ModelNone, ""
+	}
+
+	ref := strings.ToUpper(strings.TrimSpace(p.Remittance))
+	if strings.HasPrefix(ref, "RF") {
+		ref = p.RemittanceStructured()
+	}
+
+	if len(ref) < 4 {
+		return ref, ""
+	}
+
+	return ref[:4], strings.TrimSpace(ref[4:])
+}
+
+// IsModelReference returns whether the remittance starts with a Croatian model, HR00..HR99, so it is a structured
+// remittance of a HUB3 payment
+func IsModelReference(remittance string) bool {
+	ref := strings.ToUpper(strings.TrimSpace(remittance))
+
+	return len(ref) >= 4 && modelValidator.MatchString(ref[:4]) && strings.HasPrefix(ref, "HR")
+}
+
+// DescriptionString returns the description: the unstructured remittance, or the description next to a
+// structured one
+func (h *Payment) DescriptionString() string {
+	if h.Payment.RemittanceIsStructured {
+		return h.Description
+	}
+
+	return h.Payment.Remittance
+}
+
+// fields returns the fields of the payload, in order
+func (h *Payment) fields() []string {
+	p := h.Payment
+
+	account := p.IBANBeneficiary
+	if i, err := p.IBAN(); err == nil {
+		account = i.Code
+	}
+
+	model, ref := h.Reference()
+
+	return []string{
+		header,
+		h.Currency,
+		fmt.Sprintf("%0*d", amountDigits, int64(p.EuroAmount)),
+		h.Payer.Name,
+		h.Payer.Street,
+		h.Payer.Place,
+		p.NameBeneficiary,
+		h.PayeeStreet,
+		h.PayeePlace,
+		account,
+		model,
+		ref,
+		p.PurposeString(),
+		h.DescriptionString(),
+	}
+}
+
+// ToString returns the payload, after validating the payment; every field ends with a line feed
+func (h *Payment) ToString() (string, error) {
+	if err := h.IsValid(); err != nil {
+		return "", err
+	}
+
+	return strings.Join(h.fields(), "\n") + "\n", nil
+}
+
+// ToBytes returns the payload in ISO 8859-2, as the barcode holds it
+func (h *Payment) ToBytes() ([]byte, error) {
+	s, err := h.ToString()
+	if err != nil {
+		return nil, err
+	}
+
+	return charmap.ISO8859_2.NewEncoder().Bytes([]byte(s))
+}
+
+// IsValid checks the payment against the HUB3 specification.
+// It returns nil if all is well, or payment.ValidationErrors with every problem it finds.
+func (h *Payment) IsValid() error {
+	p := h.Payment
+
+	var errs payment.ValidationErrors
+
+	if i, err := p.IBAN(); err != nil || len(i.Code) > maxAccount {
+		if err == nil {
+			err = fmt.Errorf("%d characters", len(i.Code))
+		}
+
+		errs = append(errs, payment.NewValidationError("IBANBeneficiary", "", payment.RuleFormat, "IBAN",
+			fmt.Errorf("%w: %w", ErrValidationAccount, err)))
+	}
+
+	if p.EuroAmount < 0 || p.EuroAmount > payment.MaxAmount {
+		errs = append(errs, payment.NewValidationError("EuroAmount", "", payment.RuleRange, "0.01..999999999.99", ErrValidationAmount))
+	}
+
+	if !currencyValidator.MatchString(h.Currency) {
+		errs = append(errs, payment.NewValidationError("Currency", "", payment.RuleFormat, "ISO 4217",
+			fmt.Errorf("%w: %q", ErrValidationCurrency, h.Currency)))
+	}
+
+	if purpose := p.PurposeString(); purpose != "" && !purposeValidator.MatchString(purpose) {
+		errs = append(errs, payment.NewValidationError("Purpose", "", payment.RuleFormat, "ISO 20022",
+			fmt.Errorf("%w: %q", ErrValidationPurpose, purpose)))
+	}
+
+	for _, f := range []struct {
+		field    string
+		value    string
+		limit    int
+		required bool
+	}{
+		{"Payer.Name", h.Payer.Name, maxPayerName, false},
+		{"Payer.Street", h.Payer.Street, maxPayerStreet, false},
+		{"Payer.Place", h.Payer.Place, maxPayerPlace, false},
+		{"NameBeneficiary", p.NameBeneficiary, maxPayeeName, true},
+		{"PayeeStreet", h.PayeeStreet, maxPayeeStreet, false},
+		{"PayeePlace", h.PayeePlace, maxPayeePlace, false},
+		{h.descriptionField(), h.DescriptionString(), maxDescription, true},
+	} {
+		errs = append(errs, textRule(f.limit, f.required).Validate(f.field, f.value)...)
+	}
+
+	errs = append(errs, h.validateReference()...)
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+// descriptionField returns the name of the field that holds the description
+func (h *Payment) descriptionField() string {
+	if h.Payment.RemittanceIsStructured {
+		return "Description"
+	}
+
+	return "Remittance"
+}
+
+// validateReference checks the model and the reference number of a structured remittance
+func (h *Payment) validateReference() payment.ValidationErrors {
+	model, ref := h.Reference()
+
+	switch {
+	case !modelValidator.MatchString(model):
+		return payment.ValidationErrors{payment.NewValidationError("Remittance", "", payment.RuleFormat, "HR00..HR99, RF",
+			fmt.Errorf("%w: %q", ErrValidationModel, model))}
+	case strings.HasPrefix(model, "RF"):
+		if err := payment.ValidateRFReference(model + ref); err != nil {
+			return payment.ValidationErrors{payment.NewValidationError("Remittance", "", payment.RuleFormat, "ISO 11649",
+				fmt.Errorf("%w: %w", ErrValidationRFReference, err))}
+		}
+
+		return nil
+	case model == ModelNone:
+		if ref == "" {
+			return nil
+		}
+	case len(ref) <= maxReference && referenceValidator.MatchString(ref) && strings.Count(ref, "-") < referenceGroups:
+		return nil
+	}
+
+	return payment.ValidationErrors{payment.NewValidationError("Remittance", "", payment.RuleFormat, strconv.Itoa(maxReference),
+		fmt.Errorf("%w: %s %q", ErrValidationReference, model, ref))}
+}
+
+// textRule returns the rule of a text field: at most limit printable characters of ISO 8859-2
+func textRule(limit int, required bool) payment.TextRule {
+	return payment.TextRule{
+		Required:      required,
+		MaxLength:     limit,
+		Charset:       "ISO 8859-2",
+		Allowed:       func(r rune) bool { return unicode.IsPrint(r) && fitsISO88592(r) },
+		ErrRequired:   ErrValidationRequired,
+		ErrTooLong:    ErrValidationTooLong,
+		ErrCharacters: ErrValidationCharacters,
+	}
+}
+
+// fitsISO88592 returns whether the character is part of ISO 8859-2
+func fitsISO88592(r rune) bool {
+	_, ok := charmap.ISO8859_2.EncodeRune(r)
+	return ok
+}
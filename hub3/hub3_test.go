@@ -0,0 +1,145 @@
+package hub3_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jovandeginste/payme/hub3"
+	"github.com/jovandeginste/payme/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// examplePayment returns a payment with both addresses, a reference with model HR01 and a purpose code
+func examplePayment() *hub3.Payment {
+	h := hub3.New()
+	h.Payment.IBANBeneficiary = "HR12 1001 0051 8630 0016 0"
+	h.Payment.NameBeneficiary = "Čistoća d.o.o."
+	h.Payment.EuroAmount = 12355
+	h.Payment.Purpose = "COST"
+	h.Payment.Remittance = "HR01 7269-68-0800"
+	h.Payment.RemittanceIsStructured = true
+	h.PayeeStreet = "Ilica 1"
+	h.PayeePlace = "10000 Zagreb"
+	h.Payer = hub3.Party{Name: "Zlatko Marić", Street: "Palih boraca 1", Place: "10000 Zagreb"}
+	h.Description = "Račun 2026-01"
+
+	return h
+}
+
+func TestToString(t *testing.T) {
+	s, err := examplePayment().ToString()
+	require.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{
+		"HRVHUB30",
+		"EUR",
+		"000000000012355",
+		"Zlatko Marić",
+		"Palih boraca 1",
+		"10000 Zagreb",
+		"Čistoća d.o.o.",
+		"Ilica 1",
+		"10000 Zagreb",
+		"HR1210010051863000160",
+		"HR01",
+		"7269-68-0800",
+		"COST",
+		"Račun 2026-01",
+	}, "\n")+"\n", s)
+}
+
+func TestToStringUnstructured(t *testing.T) {
+	h := hub3.New()
+	h.Payment.IBANBeneficiary = "HR1210010051863000160"
+	h.Payment.NameBeneficiary = "Udruga"
+	h.Payment.Remittance = "Donacija"
+
+	s, err := h.ToString()
+	require.NoError(t, err)
+	assert.Equal(t, "HRVHUB30\nEUR\n000000000000000\n\n\n\nUdruga\n\n\nHR1210010051863000160\nHR99\n\n\nDonacija\n", s,
+		"an open amount is zero, and an unstructured remittance is the description without a reference")
+}
+
+func TestToBytes(t *testing.T) {
+	b, err := examplePayment().ToBytes()
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "\xC8isto\xE6a d.o.o.\n", "the payload is ISO 8859-2")
+}
+
+func TestReference(t *testing.T) {
+	h := examplePayment()
+
+	model, ref := h.Reference()
+	assert.Equal(t, "HR01", model)
+	assert.Equal(t, "7269-68-0800", ref)
+
+	h.Payment.Remittance = "RF18 5390 0754 7034"
+	model, ref = h.Reference()
+	assert.Equal(t, "RF18", model)
+	assert.Equal(t, "539007547034", ref)
+	require.NoError(t, h.IsValid())
+
+	h.Payment.Remittance = "hr0012345"
+	model, ref = h.Reference()
+	assert.Equal(t, "HR00", model)
+	assert.Equal(t, "12345", ref)
+	require.NoError(t, h.IsValid())
+
+	h.Payment.RemittanceIsStructured = false
+	model, ref = h.Reference()
+	assert.Equal(t, hub3.ModelNone, model)
+	assert.Empty(t, ref)
+}
+
+func TestIsModelReference(t *testing.T) {
+	assert.True(t, hub3.IsModelReference("HR01 7269-68-0800"))
+	assert.True(t, hub3.IsModelReference(" hr00123"))
+	assert.False(t, hub3.IsModelReference("RF18 5390 0754 7034"), "RF references are structured on their own")
+	assert.False(t, hub3.IsModelReference("HRK 100"))
+	assert.False(t, hub3.IsModelReference("HR1"))
+}
+
+func TestIsValid(t *testing.T) {
+	require.NoError(t, examplePayment().IsValid())
+
+	tests := map[string]struct {
+		change func(h *hub3.Payment)
+		err    error
+	}{
+		"IBAN":             {func(h *hub3.Payment) { h.Payment.IBANBeneficiary = "HR12 1001" }, hub3.ErrValidationAccount},
+		"long IBAN":        {func(h *hub3.Payment) { h.Payment.IBANBeneficiary = "DE71110220330123456789" }, hub3.ErrValidationAccount},
+		"amount":           {func(h *hub3.Payment) { h.Payment.EuroAmount = payment.MaxAmount + 1 }, hub3.ErrValidationAmount},
+		"currency":         {func(h *hub3.Payment) { h.Currency = "kn" }, hub3.ErrValidationCurrency},
+		"purpose":          {func(h *hub3.Payment) { h.Payment.Purpose = "cost" }, hub3.ErrValidationPurpose},
+		"payee required":   {func(h *hub3.Payment) { h.Payment.NameBeneficiary = "" }, hub3.ErrValidationRequired},
+		"payee too long":   {func(h *hub3.Payment) { h.Payment.NameBeneficiary = strings.Repeat("a", 26) }, hub3.ErrValidationTooLong},
+		"payer too long":   {func(h *hub3.Payment) { h.Payer.Name = strings.Repeat("a", 31) }, hub3.ErrValidationTooLong},
+		"street too long":  {func(h *hub3.Payment) { h.PayeeStreet = strings.Repeat("a", 26) }, hub3.ErrValidationTooLong},
+		"place characters": {func(h *hub3.Payment) { h.PayeePlace = "10000\nZagreb" }, hub3.ErrValidationCharacters},
+		"ISO 8859-2":       {func(h *hub3.Payment) { h.Payer.Name = "Zlatko €" }, hub3.ErrValidationCharacters},
+		"description":      {func(h *hub3.Payment) { h.Description = "" }, hub3.ErrValidationRequired},
+		"remittance":       {func(h *hub3.Payment) { h.Payment.RemittanceIsStructured = false; h.Payment.Remittance = "" }, hub3.ErrValidationRequired},
+		"model":            {func(h *hub3.Payment) { h.Payment.Remittance = "HRx1 1234" }, hub3.ErrValidationModel},
+		"reference":        {func(h *hub3.Payment) { h.Payment.Remittance = "HR01 12A4" }, hub3.ErrValidationReference},
+		"reference groups": {func(h *hub3.Payment) { h.Payment.Remittance = "HR01 1-2-3-4" }, hub3.ErrValidationReference},
+		"reference length": {func(h *hub3.Payment) { h.Payment.Remittance = "HR01 " + strings.Repeat("1", 23) }, hub3.ErrValidationReference},
+		"HR99 reference":   {func(h *hub3.Payment) { h.Payment.Remittance = "HR99 1234" }, hub3.ErrValidationReference},
+		"RF reference":     {func(h *hub3.Payment) { h.Payment.Remittance = "RF19 5390 0754 7034" }, hub3.ErrValidationRFReference},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			h := examplePayment()
+			tt.change(h)
+
+			err := h.IsValid()
+			require.ErrorIs(t, err, tt.err)
+
+			var errs payment.ValidationErrors
+			require.ErrorAs(t, err, &errs)
+
+			_, err = h.ToString()
+			require.ErrorIs(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package hub3
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/pdf417"
+	"github.com/jovandeginste/payme/qrcode"
+)
+
+const (
+	// securityLevel is the error correction level of the barcode that the specification requires
+	securityLevel = 4
+	// quietZone is the width of the blank border around the barcode, in modules
+	quietZone = 2
+)
+
+// ErrImageSize is returned when the requested image is too narrow to hold one pixel per module
+var ErrImageSize = errors.New("image width is smaller than the barcode")
+
+// Barcode returns the PDF417 barcode of the payload, with error correction level 4
+func (h *Payment) Barcode() (barcode.Barcode, error) {
+	b, err := h.ToBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	return pdf417.Encode(string(b), securityLevel)
+}
+
+// Image returns the barcode, including its quiet zone, with one pixel per module; a row of the barcode is a
+// few modules high
+func (h *Payment) Image() (*image.Gray, error) {
+	code, err := h.Barcode()
+	if err != nil {
+		return nil, err
+	}
+
+	r := code.Bounds()
+	img := image.NewGray(image.Rect(0, 0, r.Dx()+2*quietZone, r.Dy()+2*quietZone))
+
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.Set(x-r.Min.X+quietZone, y-r.Min.Y+quietZone, color.GrayModel.Convert(code.At(x, y)))
+		}
+	}
+
+	return img, nil
+}
+
+// ToPNG returns a PNG image of the barcode that is width pixels wide; every module is scaled to the same
+// (integer) number of pixels, and the barcode is centered horizontally
+func (h *Payment) ToPNG(width int) ([]byte, error) {
+	src, err := h.Image()
+	if err != nil {
+		return nil, err
+	}
+
+	w, ht := src.Rect.Dx(), src.Rect.Dy()
+
+	factor := width / w
+	if factor < 1 {
+		return nil, fmt.Errorf("%w: %d < %d", ErrImageSize, width, w)
+	}
+
+	offset := (width - w*factor) / 2
+	img := image.NewGray(image.Rect(0, 0, width, ht*factor))
+
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+
+	for y := range ht * factor {
+		for x := range w * factor {
+			img.SetGray(offset+x, y, src.GrayAt(x/factor, y/factor))
+		}
+	}
+
+	var b bytes.Buffer
+
+	if err := png.Encode(&b, img); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// ToSVG returns an SVG image of the barcode, with one path per run of dark modules. Every module is one unit in
+// the viewBox, so the image can be scaled to any size; the Size of the options is the width of the image.
+func (h *Payment) ToSVG(opts qrcode.SVGOptions) ([]byte, error) {
+	img, err := h.Image()
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+
+	w, ht := img.Rect.Dx(), img.Rect.Dy()
+
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" version="1.1" viewBox="0 0 %d %d"`, w, ht)
+
+	if opts.Size != "" {
+		fmt.Fprintf(&b, ` width="%s"`, html.EscapeString(opts.Size))
+	}
+
+	b.WriteString(` shape-rendering="crispEdges">` + "\n")
+
+	if opts.Background != "" {
+		fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="%s"/>`+"\n", w, ht, html.EscapeString(opts.Background))
+	}
+
+	fmt.Fprintf(&b, `<path fill="%s" d="`, html.EscapeString(opts.Foreground))
+
+	for y := range ht {
+		for x := 0; x < w; {
+			if img.GrayAt(x, y).Y >= 0x80 {
+				x++
+				continue
+			}
+
+			start := x
+			for x < w && img.GrayAt(x, y).Y < 0x80 {
+				x++
+			}
+
+			fmt.Fprintf(&b, "M%d %dh%dv1h-%[3]dz", start, y, x-start)
+		}
+	}
+
+	b.WriteString(`"/>` + "\n</svg>\n")
+
+	return b.Bytes(), nil
+}
@@ -0,0 +1,106 @@
+package hub3_test
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jovandeginste/payme/hub3"
+	"github.com/jovandeginste/payme/payment"
+	"github.com/jovandeginste/payme/qrcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBarcode(t *testing.T) {
+	code, err := examplePayment().Barcode()
+	require.NoError(t, err)
+
+	b, err := examplePayment().ToBytes()
+	require.NoError(t, err)
+	assert.Equal(t, string(b), code.Content())
+
+	_, err = hub3.New().Barcode()
+
+	var errs payment.ValidationErrors
+	require.ErrorAs(t, err, &errs)
+}
+
+// latin2Payment returns a payment with Č, Ć, Š and Ž, which are bytes above 0x7f in ISO 8859-2
+func latin2Payment() *hub3.Payment {
+	h := examplePayment()
+	h.PayeeStreet = "Šubićeva 12"
+	h.Payer = hub3.Party{Name: "Željko Šarić", Street: "Ćirilometodska 3", Place: "21000 Split"}
+	h.Description = "Račun Žuta Čaša"
+
+	return h
+}
+
+func TestBarcodeLatin2(t *testing.T) {
+	h := latin2Payment()
+
+	code, err := h.Barcode()
+	require.NoError(t, err)
+
+	for _, b := range []byte{0xc8, 0xc6, 0xa9, 0xae} {
+		assert.Contains(t, []byte(code.Content()), b, "Č, Ć, Š and Ž are encoded as single ISO 8859-2 bytes")
+	}
+
+	img, err := h.Image()
+	require.NoError(t, err)
+
+	// The golden image was read back with a PDF417 reader: it holds the ISO 8859-2 payload, byte for byte
+	f, err := os.Open("tests/latin2.png")
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	expected, err := png.Decode(f)
+	require.NoError(t, err)
+	require.IsType(t, &image.Gray{}, expected)
+	assert.Equal(t, expected.Bounds(), img.Bounds())
+	assert.Equal(t, expected.(*image.Gray).Pix, img.Pix)
+}
+
+func TestImage(t *testing.T) {
+	img, err := examplePayment().Image()
+	require.NoError(t, err)
+
+	r := img.Bounds()
+	assert.Greater(t, r.Dx(), 2*r.Dy(), "a PDF417 barcode is wider than it is high")
+
+	for x := range r.Dx() {
+		assert.Equal(t, uint8(0xff), img.GrayAt(x, 0).Y, "the quiet zone is blank")
+	}
+
+	assert.Equal(t, uint8(0), img.GrayAt(2, 2).Y, "the barcode starts with a bar")
+}
+
+func TestToPNG(t *testing.T) {
+	result, err := examplePayment().ToPNG(900)
+	require.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(result))
+	require.NoError(t, err)
+	assert.Equal(t, 900, img.Bounds().Dx())
+
+	_, err = examplePayment().ToPNG(100)
+	require.ErrorIs(t, err, hub3.ErrImageSize)
+}
+
+func TestToSVG(t *testing.T) {
+	opts := qrcode.DefaultSVGOptions()
+	opts.Size = "60mm"
+
+	result, err := examplePayment().ToSVG(opts)
+	require.NoError(t, err)
+
+	s := string(result)
+	assert.True(t, strings.HasPrefix(s, "<?xml"))
+	assert.Contains(t, s, `width="60mm"`)
+	assert.NotContains(t, s, "height=\"60mm\"")
+	assert.Contains(t, s, `<path fill="#000000" d="M2 2h8v1h-8z`, "the start pattern begins with a bar of 8 modules")
+}
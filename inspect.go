@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/jovandeginste/payme/qrcode"
+	"github.com/spf13/cobra"
+)
+
+// inspectParams are the settings of the inspect command
+type inspectParams struct {
+	Payload string
+	Image   string
+}
+
+func inspectCmd(q *qrParams) *cobra.Command {
+	i := inspectParams{}
+
+	cmd := &cobra.Command{
+		Use:   "inspect",
+		Short: "Explain the payload of a payment line by line",
+		Long: `Explain the payload of a payment line by line: the EPC attribute code and meaning of every line, its length in
+bytes and how many characters are left, and any problems. The total size of the payload, encoded in the character set
+of the payment, is compared to the limit of 331 bytes, and the resulting QR code version and size are shown for every
+error correction level.
+
+The payment is given by the flags and --from, as for generating a code, as the raw EPC payload with --payload, or as
+an image of a QR code with --image.`,
+		Example: `  payme inspect --name "Franz Mustermänn" --iban DE71110220330123456789 --amount 12.3 --remittance "Invoice 1234"
+  payme inspect --image invoice.png`,
+		Args: cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return q.applyConfig(cmd)
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cmd.SilenceUsage = true
+
+			p, err := i.payment(q, cmd.InOrStdin())
+			if err != nil {
+				return err
+			}
+
+			printInspection(cmd.OutOrStdout(), p, q.QR)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&i.Payload, "payload", "", "File with the raw EPC payload to inspect instead of the flags, or - for stdin")
+	cmd.Flags().StringVar(&i.Image, "image", "", "PNG or JPEG image of the QR code to inspect instead of the flags, or - for stdin")
+	cmd.MarkFlagsMutuallyExclusive("payload", "image")
+
+	q.addPaymentFlags(cmd.Flags())
+
+	return cmd
+}
+
+// payment returns the payment of the image, or else of the payload, or else of the flags
+func (i *inspectParams) payment(q *qrParams, stdin io.Reader) (*payment.Payment, error) {
+	switch {
+	case i.Image != "":
+		b, err := readInput(i.Image, stdin)
+		if err != nil {
+			return nil, err
+		}
+
+		return payment.FromQRImage(bytes.NewReader(b))
+	case i.Payload != "":
+		b, err := readInput(i.Payload, stdin)
+		if err != nil {
+			return nil, err
+		}
+
+		return payment.ParseBytes(b)
+	}
+
+	if err := q.prepare(); err != nil {
+		return nil, err
+	}
+
+	return q.Payment, nil
+}
+
+// printInspection prints the lines of the payload with their attributes, the size of the payload and
+// of the QR code with the options, and the problems of the payment
+func printInspection(w io.Writer, p *payment.Payment, opts qrcode.Options) {
+	lines := p.Lines()
+	size := p.Size()
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Line\tCode\tMeaning\tBytes\tLeft\tValue")
+
+	for _, l := range lines {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%d\t%s\n", l.Number, l.Code, l.Meaning, l.Bytes, l.Remaining(), l.Value)
+	}
+
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Payload:  %d of %d bytes, %d left\n", size.Bytes, payment.MaxPayloadBytes, size.Remaining)
+	printQRSize(w, p, opts, size)
+
+	warnings := inspectWarnings(p, lines)
+	if len(warnings) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Warnings:")
+
+	for _, warning := range warnings {
+		fmt.Fprintf(w, "  %s\n", warning)
+	}
+}
+
+// printQRSize prints the QR code of the payment, or the code it would have if it was valid, and the
+// versions at every error correction level
+func printQRSize(w io.Writer, p *payment.Payment, opts qrcode.Options, size payment.Size) {
+	level := opts.Level
+
+	switch code, err := p.QRCode(opts); {
+	case err == nil:
+		fmt.Fprintf(w, "QR code:  version %d, %dx%d modules, error correction level %s\n",
+			code.Version, code.Size(), code.Size(), code.Level)
+	case size.Modules(level) > 0:
+		fmt.Fprintf(w, "QR code:  version %d, %dx%d modules, error correction level %s, but the payment is not valid\n",
+			size.Versions[level], size.Modules(level), size.Modules(level), level)
+	default:
+		fmt.Fprintf(w, "QR code:  none, the payload does not fit at error correction level %s\n", level)
+	}
+
+	versions := make([]string, 0, len(qrcode.Levels))
+
+	for _, l := range qrcode.Levels {
+		if v, ok := size.Versions[l]; ok {
+			versions = append(versions, fmt.Sprintf("%s %d (%dx%d)", l, v, size.Modules(l), size.Modules(l)))
+		} else {
+			versions = append(versions, fmt.Sprintf("%s none", l))
+		}
+	}
+
+	fmt.Fprintf(w, "Versions: %s\n", strings.Join(versions, ", "))
+}
+
+// inspectWarnings returns the problems of the payment, by line
+func inspectWarnings(p *payment.Payment, lines []payment.Line) []string {
+	var warnings []string
+
+	var errs payment.ValidationErrors
+	errors.As(p.IsValid(), &errs)
+
+	for _, e := range errs {
+		if n := lineNumber(p, lines, e.Field); n > 0 {
+			warnings = append(warnings, fmt.Sprintf("line %d: %s", n, e))
+		} else {
+			warnings = append(warnings, e.Error())
+		}
+	}
+
+	if !p.RemittanceIsStructured && payment.IsStructuredReference(p.Remittance) {
+		warnings = append(warnings, fmt.Sprintf("line %d: AT-05: the remittance is a structured reference, but it is not marked as structured",
+			lineNumber(p, lines, "Remittance")))
+	}
+
+	if p.IsOpenAmount() {
+		warnings = append(warnings, fmt.Sprintf("line %d: AT-04: there is no amount, the payer fills it in", lineNumber(p, lines, "EuroAmount")))
+	}
+
+	return warnings
+}
+
+// lineNumber returns the number of the line that holds the field; the remittance is on the structured
+// or the unstructured line
+func lineNumber(p *payment.Payment, lines []payment.Line, field string) int {
+	n := 0
+
+	for _, l := range lines {
+		if l.Field != field {
+			continue
+		}
+
+		n = l.Number
+
+		if field != "Remittance" || p.RemittanceIsStructured {
+			break
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspectFlags(t *testing.T) {
+	writeTestConfig(t, "")
+
+	out, code := runExit(t, "", "inspect",
+		"--name", "Franz Mustermänn", "--iban", "DE71110220330123456789", "--amount", "12.3", "--remittance", "Invoice 1234")
+	require.Equal(t, exitOK, code, out)
+
+	lines := strings.Split(out, "\n")
+	assert.Regexp(t, `^Line\s+Code\s+Meaning\s+Bytes\s+Left\s+Value$`, lines[0])
+	assert.Regexp(t, `^6\s+AT-21\s+Name of the beneficiary\s+16\s+54\s+Franz Mustermänn$`, lines[6])
+	assert.Regexp(t, `^8\s+AT-04\s+Amount of the credit transfer in euro\s+8\s+7\s+EUR12.30$`, lines[8])
+	assert.Contains(t, out, "Payload:  83 of 331 bytes, 248 left\n")
+	assert.Contains(t, out, "QR code:  version 5, 37x37 modules, error correction level M\n")
+	assert.Contains(t, out, "Versions: L 5 (37x37), M 5 (37x37), Q 7 (45x45), H 8 (49x49)\n")
+	assert.NotContains(t, out, "Warnings:")
+}
+
+func TestInspectWarnings(t *testing.T) {
+	writeTestConfig(t, "")
+
+	out, code := runExit(t, "", "inspect",
+		"--name", "Franz Mustermänn", "--iban", "DE71110220330123456789", "--purpose", "ABCDEF", "--remittance", "RF18539007547034")
+	require.Equal(t, exitOK, code, out)
+
+	assert.Contains(t, out, "QR code:  version 6, 41x41 modules, error correction level M, but the payment is not valid\n")
+	assert.Contains(t, out, "Warnings:\n")
+	assert.Contains(t, out, "  line 9: AT-44: field 'Purpose' should not exceed 4 characters\n")
+	assert.Contains(t, out, "  line 11: AT-05: the remittance is a structured reference, but it is not marked as structured\n")
+	assert.Contains(t, out, "  line 8: AT-04: there is no amount, the payer fills it in\n")
+}
+
+func TestInspectB2OInformation(t *testing.T) {
+	writeTestConfig(t, "")
+
+	out, code := runExit(t, "", "inspect", "--name", "Franz Mustermänn", "--iban", "DE71110220330123456789",
+		"--amount", "12.3", "--remittance", "Invoice 1234", "--b2o-information", "Thank you #1")
+	require.Equal(t, exitOK, code, out)
+
+	assert.Regexp(t, `\n12\s+Beneficiary to originator information\s+12\s+58\s+Thank you #1\n`, out)
+	assert.Contains(t, out, "  line 12: field 'B2OInformation' should only contain alpha-numerics")
+}
+
+func TestInspectTooLong(t *testing.T) {
+	writeTestConfig(t, "")
+
+	out, code := runExit(t, "", "inspect", "--character-set", "1", "--name", strings.Repeat("Ä", 70),
+		"--iban", "DE71110220330123456789", "--amount", "1", "--remittance", strings.Repeat("ä", 140))
+	require.Equal(t, exitOK, code, out)
+
+	assert.Contains(t, out, "Payload:  474 of 331 bytes, -143 left\n")
+	assert.Contains(t, out, "  the payload should not exceed 331 bytes: it is 474 bytes in UTF-8\n")
+}
+
+func TestInspectPayload(t *testing.T) {
+	writeTestConfig(t, "")
+
+	out, code := runExit(t, testPayload, "inspect", "--payload", "-")
+	require.Equal(t, exitOK, code, out)
+	assert.Contains(t, out, "Franz Mustermänn")
+
+	_, code = runExit(t, "BCD\n", "inspect", "--payload", "-")
+	assert.Equal(t, exitValidation, code)
+}
+
+func TestInspectImage(t *testing.T) {
+	writeTestConfig(t, "")
+
+	out, code := runExit(t, "", "inspect", "--image", filepath.Join("payment", "tests", "test1.png"))
+	require.Equal(t, exitOK, code, out)
+	assert.Contains(t, out, "François D'Alsace S.A.")
+	assert.Contains(t, out, "QR code:  version 7")
+
+	image, err := os.ReadFile(filepath.Join("payment", "tests", "test1.png"))
+	require.NoError(t, err)
+
+	out, code = runExit(t, string(image), "inspect", "--image", "-")
+	require.Equal(t, exitOK, code, out)
+	assert.Contains(t, out, "François D'Alsace S.A.")
+}
+
+func TestDebugOutput(t *testing.T) {
+	writeTestConfig(t, "")
+
+	var logs bytes.Buffer
+
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	for _, args := range [][]string{
+		{"--name", "Jane Doe", "--iban", "BE68539007547034"},
+		{"--format", "spayd", "--name", "Jane Doe", "--iban", "CZ6508000000192000145399"},
+	} {
+		for _, output := range []string{"stdout", "png", "svg"} {
+			q := qrParams{
+				Payment: payment.New(),
+			}
+
+			cmdRoot, err := newCommand(&q)
+			require.NoError(t, err)
+
+			stderr := new(bytes.Buffer)
+
+			cmdRoot.SetArgs(append(args, "--amount", "12.3", "--remittance", "Invoice 1234", "--output", output, "--debug", "--file", filepath.Join(t.TempDir(), "qr")))
+			cmdRoot.SetErr(stderr)
+			require.NoError(t, cmdRoot.Execute(), args)
+
+			if q.Format == formatEPC {
+				assert.True(t, strings.HasPrefix(stderr.String(), "Line  Code"), stderr.String())
+			} else {
+				assert.Equal(t, "Payload: SPD*1.0*ACC:CZ6508000000192000145399*AM:12.30*CC:CZK*MSG:Invoice 1234*RN:Jane Doe\n", stderr.String())
+			}
+		}
+	}
+
+	assert.Empty(t, logs.String(), "the debug output goes to the stderr of the command")
+}
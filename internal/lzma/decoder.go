@@ -0,0 +1,284 @@
+package lzma
+
+// rangeDecoder reads the bits of the model from an arithmetic code
+type rangeDecoder struct {
+	in   []byte
+	rng  uint32
+	code uint32
+	err  error
+}
+
+func newRangeDecoder(in []byte) (*rangeDecoder, error) {
+	if len(in) < 5 {
+		return nil, ErrTruncated
+	}
+
+	d := &rangeDecoder{in: in[5:], rng: 0xFFFFFFFF}
+
+	for _, b := range in[1:5] {
+		d.code = d.code<<8 | uint32(b)
+	}
+
+	if in[0] != 0 || d.code == d.rng {
+		return nil, ErrCorrupt
+	}
+
+	return d, nil
+}
+
+func (d *rangeDecoder) normalize() {
+	if d.rng >= topValue {
+		return
+	}
+
+	d.rng <<= 8
+	d.code <<= 8
+
+	if len(d.in) == 0 {
+		d.err = ErrTruncated
+		return
+	}
+
+	d.code |= uint32(d.in[0])
+	d.in = d.in[1:]
+}
+
+func (d *rangeDecoder) decodeBit(p *prob) uint32 {
+	bound := (d.rng >> numBitModelTotalBits) * uint32(*p)
+
+	var bit uint32
+
+	if d.code < bound {
+		d.rng = bound
+		*p += (bitModelTotal - *p) >> numMoveBits
+	} else {
+		d.code -= bound
+		d.rng -= bound
+		*p -= *p >> numMoveBits
+		bit = 1
+	}
+
+	d.normalize()
+
+	return bit
+}
+
+func (d *rangeDecoder) decodeDirectBits(n int) uint32 {
+	var v uint32
+
+	for range n {
+		d.rng >>= 1
+
+		var bit uint32
+		if d.code >= d.rng {
+			d.code -= d.rng
+			bit = 1
+		}
+
+		v = v<<1 | bit
+		d.normalize()
+	}
+
+	return v
+}
+
+func (d *rangeDecoder) decodeTree(probs []prob, n int) uint32 {
+	m := uint32(1)
+
+	for range n {
+		m = m<<1 | d.decodeBit(&probs[m])
+	}
+
+	return m - 1<<n
+}
+
+func (d *rangeDecoder) decodeReverseTree(probs []prob, n int) uint32 {
+	m := uint32(1)
+
+	var v uint32
+
+	for i := range n {
+		bit := d.decodeBit(&probs[m])
+		m = m<<1 | bit
+		v |= bit << i
+	}
+
+	return v
+}
+
+func (l *lenCoder) decode(d *rangeDecoder, posState int) int {
+	switch {
+	case d.decodeBit(&l.choice) == 0:
+		return minMatchLen + int(d.decodeTree(l.low[posState], 3))
+	case d.decodeBit(&l.choice2) == 0:
+		return minMatchLen + 8 + int(d.decodeTree(l.mid[posState], 3))
+	}
+
+	return minMatchLen + 16 + int(d.decodeTree(l.high, 8))
+}
+
+// decoder decompresses data with the model
+type decoder struct {
+	*model
+	rc  *rangeDecoder
+	out []byte
+}
+
+// Decompress returns the size bytes of data of the raw LZMA stream. The stream may end with an end of stream
+// marker, which is required when the data is shorter than size.
+func Decompress(data []byte, props Properties, size int) ([]byte, error) {
+	if err := props.check(); err != nil {
+		return nil, err
+	}
+
+	rc, err := newRangeDecoder(data)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &decoder{model: newModel(props), rc: rc, out: make([]byte, 0, size)}
+
+	for len(d.out) < size {
+		end, err := d.decodeNext(size)
+		if err != nil {
+			return nil, err
+		}
+
+		if rc.err != nil {
+			return nil, rc.err
+		}
+
+		if end {
+			break
+		}
+	}
+
+	if len(d.out) != size {
+		return nil, ErrTruncated
+	}
+
+	return d.out, nil
+}
+
+// decodeNext decodes a literal or a match, and returns whether it was the end of stream marker
+func (d *decoder) decodeNext(size int) (bool, error) {
+	rc := d.rc
+	pos := len(d.out)
+	posState := d.posState(pos)
+
+	if rc.decodeBit(&d.isMatch[d.state<<d.props.PB+posState]) == 0 {
+		d.decodeLiteral()
+		return false, nil
+	}
+
+	var length int
+
+	switch {
+	case rc.decodeBit(&d.isRep[d.state]) == 0:
+		length = d.lenCoder.decode(rc, posState)
+
+		dist := d.decodeDistance(length)
+		if dist == endMarker {
+			return true, nil
+		}
+
+		d.reps = [4]uint32{dist, d.reps[0], d.reps[1], d.reps[2]}
+		d.updateMatch()
+	case pos == 0:
+		return false, ErrCorrupt
+	case rc.decodeBit(&d.isRepG0[d.state]) == 0:
+		if rc.decodeBit(&d.isRep0Long[d.state<<d.props.PB+posState]) == 0 {
+			d.updateShortRep()
+			d.out = append(d.out, d.out[pos-int(d.reps[0])-1])
+
+			return false, nil
+		}
+
+		length = d.repLen.decode(rc, posState)
+		d.updateRep()
+	default:
+		var dist uint32
+
+		switch {
+		case rc.decodeBit(&d.isRepG1[d.state]) == 0:
+			dist = d.reps[1]
+		case rc.decodeBit(&d.isRepG2[d.state]) == 0:
+			dist = d.reps[2]
+			d.reps[2] = d.reps[1]
+		default:
+			dist = d.reps[3]
+			d.reps[3] = d.reps[2]
+			d.reps[2] = d.reps[1]
+		}
+
+		d.reps[1] = d.reps[0]
+		d.reps[0] = dist
+
+		length = d.repLen.decode(rc, posState)
+		d.updateRep()
+	}
+
+	dist := int(d.reps[0]) + 1
+	if dist > pos || uint32(dist) > d.props.DictSize || pos+length > size {
+		return false, ErrCorrupt
+	}
+
+	for range length {
+		d.out = append(d.out, d.out[len(d.out)-dist])
+	}
+
+	return false, nil
+}
+
+func (d *decoder) decodeLiteral() {
+	pos := len(d.out)
+
+	var prev byte
+	if pos > 0 {
+		prev = d.out[pos-1]
+	}
+
+	probs := d.literalProbs(pos, prev)
+	symbol := uint32(1)
+
+	if d.state >= 7 && int(d.reps[0]) < pos {
+		matchByte := uint32(d.out[pos-int(d.reps[0])-1])
+
+		for symbol < 0x100 {
+			matchBit := matchByte >> 7 & 1
+			matchByte <<= 1
+
+			bit := d.rc.decodeBit(&probs[(1+matchBit)<<8+symbol])
+			symbol = symbol<<1 | bit
+
+			if matchBit != bit {
+				break
+			}
+		}
+	}
+
+	for symbol < 0x100 {
+		symbol = symbol<<1 | d.rc.decodeBit(&probs[symbol])
+	}
+
+	d.out = append(d.out, byte(symbol))
+	d.updateLiteral()
+}
+
+func (d *decoder) decodeDistance(length int) uint32 {
+	slot := d.rc.decodeTree(d.posSlot[lenToPosState(length)], 6)
+	if slot < 4 {
+		return slot
+	}
+
+	footerBits := int(slot>>1) - 1
+	dist := (2 | slot&1) << footerBits
+
+	if slot < endPosModelIndex {
+		return dist + d.rc.decodeReverseTree(d.posSpecial[dist-slot:], footerBits)
+	}
+
+	dist += d.rc.decodeDirectBits(footerBits-numAlignBits) << numAlignBits
+
+	return dist + d.rc.decodeReverseTree(d.align, numAlignBits)
+}
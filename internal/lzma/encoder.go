@@ -0,0 +1,251 @@
+package lzma
+
+import "math/bits"
+
+// rangeEncoder writes the bits of the model as an arithmetic code
+type rangeEncoder struct {
+	out       []byte
+	low       uint64
+	rng       uint32
+	cache     byte
+	cacheSize int
+}
+
+func newRangeEncoder() *rangeEncoder {
+	return &rangeEncoder{rng: 0xFFFFFFFF, cacheSize: 1}
+}
+
+// shiftLow writes the top byte of low, holding back 0xFF bytes until it is known whether a carry reaches them
+func (e *rangeEncoder) shiftLow() {
+	if uint32(e.low) < 0xFF000000 || e.low>>32 != 0 {
+		carry := byte(e.low >> 32)
+		temp := e.cache
+
+		for ; e.cacheSize > 0; e.cacheSize-- {
+			e.out = append(e.out, temp+carry)
+			temp = 0xFF
+		}
+
+		e.cache = byte(e.low >> 24)
+	}
+
+	e.cacheSize++
+	e.low = uint64(uint32(e.low) << 8)
+}
+
+func (e *rangeEncoder) normalize() {
+	for e.rng < topValue {
+		e.rng <<= 8
+		e.shiftLow()
+	}
+}
+
+func (e *rangeEncoder) encodeBit(p *prob, bit uint32) {
+	bound := (e.rng >> numBitModelTotalBits) * uint32(*p)
+
+	if bit == 0 {
+		e.rng = bound
+		*p += (bitModelTotal - *p) >> numMoveBits
+	} else {
+		e.low += uint64(bound)
+		e.rng -= bound
+		*p -= *p >> numMoveBits
+	}
+
+	e.normalize()
+}
+
+// encodeDirectBits writes the low n bits of v, each with a probability of 1/2
+func (e *rangeEncoder) encodeDirectBits(v uint32, n int) {
+	for i := n - 1; i >= 0; i-- {
+		e.rng >>= 1
+		if v>>i&1 == 1 {
+			e.low += uint64(e.rng)
+		}
+
+		e.normalize()
+	}
+}
+
+// encodeTree writes the low n bits of v, high bit first, with the probabilities of a bit tree
+func (e *rangeEncoder) encodeTree(probs []prob, n int, v uint32) {
+	m := uint32(1)
+
+	for i := n - 1; i >= 0; i-- {
+		bit := v >> i & 1
+		e.encodeBit(&probs[m], bit)
+		m = m<<1 | bit
+	}
+}
+
+// encodeReverseTree writes the low n bits of v, low bit first, with the probabilities of a bit tree
+func (e *rangeEncoder) encodeReverseTree(probs []prob, n int, v uint32) {
+	m := uint32(1)
+
+	for range n {
+		bit := v & 1
+		v >>= 1
+		e.encodeBit(&probs[m], bit)
+		m = m<<1 | bit
+	}
+}
+
+func (e *rangeEncoder) flush() []byte {
+	for range 5 {
+		e.shiftLow()
+	}
+
+	return e.out
+}
+
+func (l *lenCoder) encode(e *rangeEncoder, length, posState int) {
+	v := uint32(length - minMatchLen)
+
+	switch {
+	case v < 8:
+		e.encodeBit(&l.choice, 0)
+		e.encodeTree(l.low[posState], 3, v)
+	case v < 16:
+		e.encodeBit(&l.choice, 1)
+		e.encodeBit(&l.choice2, 0)
+		e.encodeTree(l.mid[posState], 3, v-8)
+	default:
+		e.encodeBit(&l.choice, 1)
+		e.encodeBit(&l.choice2, 1)
+		e.encodeTree(l.high, 8, v-16)
+	}
+}
+
+// encoder compresses data with the model
+type encoder struct {
+	*model
+	rc *rangeEncoder
+}
+
+// Compress returns the raw LZMA stream of the data, ending with an end of stream marker
+func Compress(data []byte, props Properties) ([]byte, error) {
+	if err := props.check(); err != nil {
+		return nil, err
+	}
+
+	e := &encoder{model: newModel(props), rc: newRangeEncoder()}
+
+	for pos := 0; pos < len(data); {
+		length, dist := e.longestMatch(data, pos)
+
+		if length == 0 {
+			e.encodeLiteral(data, pos)
+			pos++
+
+			continue
+		}
+
+		e.encodeMatch(pos, length, dist)
+		pos += length
+	}
+
+	e.encodeMatch(len(data), minMatchLen, endMarker)
+
+	return e.rc.flush(), nil
+}
+
+// longestMatch returns the length and distance (minus 1) of the longest earlier occurrence of the data at pos, or
+// 0 when there is none worth coding as a match
+func (e *encoder) longestMatch(data []byte, pos int) (int, uint32) {
+	limit := min(len(data)-pos, maxMatchLen)
+	best, bestDist := 0, uint32(0)
+
+	for start := max(0, pos-int(e.props.DictSize)); start < pos; start++ {
+		n := 0
+		for n < limit && data[start+n] == data[pos+n] {
+			n++
+		}
+
+		// Prefer the nearest of equally long matches, as its distance is cheaper
+		if n >= best {
+			best, bestDist = n, uint32(pos-start-1)
+		}
+	}
+
+	// A short match far back costs more than its literals
+	if best < 3 && (best < minMatchLen || bestDist >= 128) {
+		return 0, 0
+	}
+
+	return best, bestDist
+}
+
+func (e *encoder) encodeLiteral(data []byte, pos int) {
+	e.rc.encodeBit(&e.isMatch[e.state<<e.props.PB+e.posState(pos)], 0)
+
+	var prev byte
+	if pos > 0 {
+		prev = data[pos-1]
+	}
+
+	probs := e.literalProbs(pos, prev)
+	b := uint32(data[pos])
+
+	if e.state < 7 {
+		e.rc.encodeTree(probs, 8, b)
+	} else {
+		// After a match, the byte at the distance of the last match predicts the literal, until a bit differs
+		matchByte := uint32(data[pos-int(e.reps[0])-1])
+		symbol := uint32(1)
+		matched := true
+
+		for i := 7; i >= 0; i-- {
+			bit := b >> i & 1
+
+			if matched {
+				matchBit := matchByte >> i & 1
+				e.rc.encodeBit(&probs[(1+matchBit)<<8+symbol], bit)
+				matched = matchBit == bit
+			} else {
+				e.rc.encodeBit(&probs[symbol], bit)
+			}
+
+			symbol = symbol<<1 | bit
+		}
+	}
+
+	e.updateLiteral()
+}
+
+func (e *encoder) encodeMatch(pos, length int, dist uint32) {
+	posState := e.posState(pos)
+
+	e.rc.encodeBit(&e.isMatch[e.state<<e.props.PB+posState], 1)
+	e.rc.encodeBit(&e.isRep[e.state], 0)
+	e.lenCoder.encode(e.rc, length, posState)
+	e.encodeDistance(dist, length)
+
+	e.reps = [4]uint32{dist, e.reps[0], e.reps[1], e.reps[2]}
+	e.updateMatch()
+}
+
+func (e *encoder) encodeDistance(dist uint32, length int) {
+	probs := e.posSlot[lenToPosState(length)]
+
+	if dist < 4 {
+		e.rc.encodeTree(probs, 6, dist)
+		return
+	}
+
+	// The slot is the position of the highest bit and the bit below it
+	n := bits.Len32(dist) - 1
+	slot := uint32(2*n) | dist>>(n-1)&1
+	e.rc.encodeTree(probs, 6, slot)
+
+	footerBits := int(slot>>1) - 1
+	base := (2 | slot&1) << footerBits
+	reduced := dist - base
+
+	if slot < endPosModelIndex {
+		e.rc.encodeReverseTree(e.posSpecial[base-slot:], footerBits, reduced)
+		return
+	}
+
+	e.rc.encodeDirectBits(reduced>>numAlignBits, footerBits-numAlignBits)
+	e.rc.encodeReverseTree(e.align, numAlignBits, reduced&(1<<numAlignBits-1))
+}
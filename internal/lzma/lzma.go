@@ -0,0 +1,190 @@
+// Package lzma compresses and decompresses raw LZMA streams: the range coded data of the LZMA1 format, without
+// the header of .lzma files, as PAY by square stores it. The properties and the size of the data are kept by the
+// caller. The encoder is small rather than optimal: it finds the longest match by brute force, which suits the
+// few hundred bytes of a QR code.
+package lzma
+
+import "errors"
+
+// Properties are the parameters of the LZMA model, which the encoder and the decoder must agree on
+type Properties struct {
+	// LC is the number of high bits of the previous byte that select the literal coder (0..8)
+	LC int
+	// LP is the number of low bits of the position that select the literal coder (0..4)
+	LP int
+	// PB is the number of low bits of the position that select the coders of matches (0..4)
+	PB int
+	// DictSize is the size of the dictionary: matches are at most this far back
+	DictSize uint32
+}
+
+// DefaultProperties returns the properties of xz and PAY by square: lc=3, lp=0, pb=2 and a dictionary of 128 KiB
+func DefaultProperties() Properties {
+	return Properties{LC: 3, LP: 0, PB: 2, DictSize: 1 << 17}
+}
+
+var (
+	// ErrProperties is returned when the properties are out of range
+	ErrProperties = errors.New("lzma: properties should be lc 0..8, lp 0..4 and pb 0..4")
+	// ErrCorrupt is returned when the compressed data is not a valid LZMA stream
+	ErrCorrupt = errors.New("lzma: corrupt data")
+	// ErrTruncated is returned when the compressed data ends before the data is complete
+	ErrTruncated = errors.New("lzma: unexpected end of data")
+)
+
+func (p Properties) check() error {
+	if p.LC < 0 || p.LC > 8 || p.LP < 0 || p.LP > 4 || p.PB < 0 || p.PB > 4 {
+		return ErrProperties
+	}
+
+	return nil
+}
+
+const (
+	numStates = 12
+	// minMatchLen and maxMatchLen are the shortest and longest match
+	minMatchLen = 2
+	maxMatchLen = 273
+	// endPosModelIndex is the first distance slot whose low bits are coded as direct bits and an aligned part
+	endPosModelIndex = 14
+	numFullDistances = 1 << (endPosModelIndex >> 1)
+	numAlignBits     = 4
+	numLenToPosState = 4
+	// endMarker is the distance of the end of stream marker
+	endMarker = 0xFFFFFFFF
+)
+
+// prob is the probability of a bit being 0, in 1/2048
+type prob uint16
+
+const (
+	numBitModelTotalBits = 11
+	bitModelTotal        = 1 << numBitModelTotalBits
+	numMoveBits          = 5
+	probInit             = bitModelTotal / 2
+	topValue             = 1 << 24
+)
+
+func newProbs(n int) []prob {
+	probs := make([]prob, n)
+	for i := range probs {
+		probs[i] = probInit
+	}
+
+	return probs
+}
+
+// lenCoder holds the probabilities of match lengths: 2..9, 10..17 and 18..273
+type lenCoder struct {
+	choice  prob
+	choice2 prob
+	low     [][]prob
+	mid     [][]prob
+	high    []prob
+}
+
+func newLenCoder(posStates int) *lenCoder {
+	l := &lenCoder{choice: probInit, choice2: probInit, high: newProbs(1 << 8)}
+
+	for range posStates {
+		l.low = append(l.low, newProbs(1<<3))
+		l.mid = append(l.mid, newProbs(1<<3))
+	}
+
+	return l
+}
+
+// model holds the probabilities of the LZMA model, and the state that the encoder and the decoder share
+type model struct {
+	props Properties
+
+	isMatch    []prob
+	isRep      []prob
+	isRepG0    []prob
+	isRepG1    []prob
+	isRepG2    []prob
+	isRep0Long []prob
+	literal    []prob
+	posSlot    [][]prob
+	posSpecial []prob
+	align      []prob
+	lenCoder   *lenCoder
+	repLen     *lenCoder
+
+	state int
+	reps  [4]uint32
+}
+
+func newModel(props Properties) *model {
+	posStates := 1 << props.PB
+
+	m := &model{
+		props:      props,
+		isMatch:    newProbs(numStates * posStates),
+		isRep:      newProbs(numStates),
+		isRepG0:    newProbs(numStates),
+		isRepG1:    newProbs(numStates),
+		isRepG2:    newProbs(numStates),
+		isRep0Long: newProbs(numStates * posStates),
+		literal:    newProbs(0x300 << (props.LC + props.LP)),
+		posSpecial: newProbs(1 + numFullDistances - endPosModelIndex),
+		align:      newProbs(1 << numAlignBits),
+		lenCoder:   newLenCoder(posStates),
+		repLen:     newLenCoder(posStates),
+	}
+
+	for range numLenToPosState {
+		m.posSlot = append(m.posSlot, newProbs(1<<6))
+	}
+
+	return m
+}
+
+// posState returns the low bits of the position that select the coders of matches
+func (m *model) posState(pos int) int {
+	return pos & (1<<m.props.PB - 1)
+}
+
+// literalProbs returns the probabilities of the literal at the position, after the previous byte
+func (m *model) literalProbs(pos int, prev byte) []prob {
+	state := (pos&(1<<m.props.LP-1))<<m.props.LC + int(prev)>>(8-m.props.LC)
+
+	return m.literal[0x300*state : 0x300*(state+1)]
+}
+
+func (m *model) updateLiteral() {
+	switch {
+	case m.state < 4:
+		m.state = 0
+	case m.state < 10:
+		m.state -= 3
+	default:
+		m.state -= 6
+	}
+}
+
+func (m *model) updateMatch() {
+	m.state = stateAfter(m.state, 7, 10)
+}
+
+func (m *model) updateRep() {
+	m.state = stateAfter(m.state, 8, 11)
+}
+
+func (m *model) updateShortRep() {
+	m.state = stateAfter(m.state, 9, 11)
+}
+
+// stateAfter returns the state after a match: after a literal state, or after a match state
+func stateAfter(state, afterLiteral, afterMatch int) int {
+	if state < 7 {
+		return afterLiteral
+	}
+
+	return afterMatch
+}
+
+// lenToPosState returns the distance coder of the match length
+func lenToPosState(length int) int {
+	return min(length-minMatchLen, numLenToPosState-1)
+}
@@ -0,0 +1,105 @@
+package lzma
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pythonHello is "hello hello hello" compressed by Python's lzma module (FORMAT_RAW, FILTER_LZMA1, lc3 lp0 pb2)
+const pythonHello = "00341949ee8de94f7f35c5a3ffff78a40000"
+
+func TestDecompressReference(t *testing.T) {
+	for expected, compressed := range map[string]string{
+		"hello hello hello": pythonHello,
+		// Python's encoder codes repeated distances, which Compress never does
+		"abcabcabd abcabcabd xyzabcxyzabc 0123 0123 0124 0123 abcabcabd": "00309888a5836d3ae7574cd9459cfbd9799f18639d43014514829dbd0143ffffcc6d0000",
+	} {
+		data, err := hex.DecodeString(compressed)
+		require.NoError(t, err)
+
+		out, err := Decompress(data, DefaultProperties(), len(expected))
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(out))
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+
+	random := make([]byte, 2000)
+	for i := range random {
+		random[i] = byte(r.UintN(256))
+	}
+
+	skewed := make([]byte, 5000)
+	for i := range skewed {
+		skewed[i] = "abcab\t0123"[r.UintN(10)]
+	}
+
+	for name, data := range map[string][]byte{
+		"empty":    {},
+		"byte":     {'x'},
+		"hello":    []byte("hello hello hello"),
+		"zeros":    make([]byte, 1000),
+		"long run": bytes.Repeat([]byte("0123456789"), 100),
+		"random":   random,
+		"skewed":   skewed,
+		"payment":  []byte("\t1\t1\t25.30\tEUR\t20261031\t1234567890\t0308\t\t\tInvoice 2026-001\t1\tSK3112000000198742637541\tTATRSKBX\t0\t0\tJohn Doe\t\t"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			for _, props := range []Properties{
+				DefaultProperties(),
+				{LC: 0, LP: 2, PB: 0, DictSize: 1 << 12},
+				{LC: 8, LP: 4, PB: 4, DictSize: 64},
+			} {
+				compressed, err := Compress(data, props)
+				require.NoError(t, err)
+
+				out, err := Decompress(compressed, props, len(data))
+				require.NoError(t, err)
+				assert.Equal(t, data, out)
+			}
+		})
+	}
+}
+
+func TestCompressSmaller(t *testing.T) {
+	data := bytes.Repeat([]byte("PAY by square "), 20)
+
+	compressed, err := Compress(data, DefaultProperties())
+	require.NoError(t, err)
+	assert.Less(t, len(compressed), 40)
+}
+
+func TestProperties(t *testing.T) {
+	_, err := Compress(nil, Properties{LC: 9})
+	require.ErrorIs(t, err, ErrProperties)
+
+	_, err = Decompress(nil, Properties{PB: 5}, 0)
+	require.ErrorIs(t, err, ErrProperties)
+}
+
+func TestDecompressErrors(t *testing.T) {
+	data, err := hex.DecodeString(pythonHello)
+	require.NoError(t, err)
+
+	_, err = Decompress(data[:3], DefaultProperties(), 17)
+	require.ErrorIs(t, err, ErrTruncated)
+
+	_, err = Decompress(data[:8], DefaultProperties(), 17)
+	require.ErrorIs(t, err, ErrTruncated)
+
+	_, err = Decompress(data, DefaultProperties(), 30)
+	require.ErrorIs(t, err, ErrTruncated)
+
+	corrupt := bytes.Clone(data)
+	corrupt[0] = 1
+
+	_, err = Decompress(corrupt, DefaultProperties(), 17)
+	require.ErrorIs(t, err, ErrCorrupt)
+}
@@ -0,0 +1,261 @@
+// Package pdf writes minimal PDF documents with vector shapes and text,
+// using only the standard Type 1 fonts so nothing needs to be embedded.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Page sizes in points (1/72 inch)
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+	A6Width  = 297.64
+	A6Height = 419.53
+)
+
+// Font is one of the standard PDF fonts
+type Font int
+
+const (
+	// Helvetica is the regular sans-serif font
+	Helvetica Font = iota
+	// HelveticaBold is the bold sans-serif font
+	HelveticaBold
+)
+
+var fontNames = []string{"Helvetica", "Helvetica-Bold"}
+
+// MM converts millimeters to points
+func MM(v float64) float64 {
+	return v * 72 / 25.4
+}
+
+// Document is a PDF document consisting of one or more pages
+type Document struct {
+	pages []*Page
+}
+
+// Page is a single page of a document; coordinates are in points,
+// with the origin in the bottom left corner
+type Page struct {
+	Width   float64
+	Height  float64
+	content bytes.Buffer
+}
+
+// New returns an empty document
+func New() *Document {
+	return &Document{}
+}
+
+// AddPage appends a new, empty page to the document
+func (d *Document) AddPage(width, height float64) *Page {
+	p := &Page{Width: width, Height: height}
+	d.pages = append(d.pages, p)
+
+	return p
+}
+
+// Rect draws a filled rectangle with its bottom left corner at x, y
+func (p *Page) Rect(x, y, w, h float64) {
+	fmt.Fprintf(&p.content, "%s %s %s %s re f\n", num(x), num(y), num(w), num(h))
+}
+
+// Fill sets the gray level of the shapes and text that are drawn next, from 0 (black, the default) to 1 (white)
+func (p *Page) Fill(gray float64) {
+	fmt.Fprintf(&p.content, "%s g\n", num(gray))
+}
+
+// Line draws a straight line; when dashed is set, the line is drawn as a dashed cutting line
+func (p *Page) Line(x1, y1, x2, y2, width float64, dashed bool) {
+	dash := "[] 0 d"
+	if dashed {
+		dash = "[3 3] 0 d"
+	}
+
+	fmt.Fprintf(&p.content, "%s w %s %s %s m %s %s l S\n", num(width), dash, num(x1), num(y1), num(x2), num(y2))
+}
+
+// Text draws a single line of text with its baseline starting at x, y
+func (p *Page) Text(x, y float64, font Font, size float64, s string) {
+	fmt.Fprintf(&p.content, "BT /F%d %s Tf %s %s Td (%s) Tj ET\n", font+1, num(size), num(x), num(y), escape(s))
+}
+
+// TextWidth returns the approximate width of the text in points
+func TextWidth(font Font, size float64, s string) float64 {
+	w := 0
+
+	for _, r := range s {
+		if r >= ' ' && r <= '~' {
+			w += helveticaWidths[r-' ']
+		} else {
+			w += 556
+		}
+	}
+
+	if font == HelveticaBold {
+		w = w * 106 / 100
+	}
+
+	return float64(w) * size / 1000
+}
+
+// Wrap splits the text into lines that fit within the given width, breaking at spaces where possible
+func Wrap(font Font, size, width float64, s string) []string {
+	var (
+		lines []string
+		line  string
+	)
+
+	for _, word := range strings.Fields(s) {
+		candidate := strings.TrimSpace(line + " " + word)
+		if TextWidth(font, size, candidate) <= width {
+			line = candidate
+			continue
+		}
+
+		if line != "" {
+			lines = append(lines, line)
+		}
+
+		// Break words that are too long on their own
+		line = ""
+		for _, r := range word {
+			if TextWidth(font, size, line+string(r)) > width && line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+
+			line += string(r)
+		}
+	}
+
+	if line != "" {
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// Bytes returns the serialized document
+func (d *Document) Bytes() []byte {
+	var (
+		b       bytes.Buffer
+		offsets []int
+	)
+
+	object := func(format string, a ...any) {
+		offsets = append(offsets, b.Len())
+		fmt.Fprintf(&b, "%d 0 obj\n", len(offsets))
+		fmt.Fprintf(&b, format, a...)
+		b.WriteString("\nendobj\n")
+	}
+
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1 and 2 are the catalog and the page tree, 3 and 4 the fonts;
+	// every page is followed by its content stream
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages))
+
+	for _, f := range fontNames {
+		object("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", f)
+	}
+
+	for i, p := range d.pages {
+		object("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			num(p.Width), num(p.Height), 6+2*i)
+		object("<< /Length %d >>\nstream\n%sendstream", p.content.Len(), p.content.Bytes())
+	}
+
+	xref := b.Len()
+
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+
+	for _, o := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", o)
+	}
+
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return b.Bytes()
+}
+
+// num formats a number with at most 2 decimals, without trailing zeros
+func num(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	s = strings.TrimRight(s, "0")
+
+	return strings.TrimSuffix(s, ".")
+}
+
+// escape converts the text to WinAnsiEncoding and escapes it for use in a string literal;
+// characters that can not be represented are replaced by a question mark
+func escape(s string) string {
+	var b strings.Builder
+
+	for _, r := range s {
+		c, ok := winAnsi(r)
+		if !ok {
+			c = '?'
+		}
+
+		switch c {
+		case '(', ')', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			if c < ' ' || c > '~' {
+				fmt.Fprintf(&b, "\\%03o", c)
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// winAnsi returns the WinAnsiEncoding byte for a rune
+func winAnsi(r rune) (byte, bool) {
+	switch {
+	case r >= ' ' && r <= '~', r >= 0xa0 && r <= 0xff:
+		return byte(r), true
+	case r == '€':
+		return 0x80, true
+	case r == 'Š':
+		return 0x8a, true
+	case r == 'Œ':
+		return 0x8c, true
+	case r == 'Ž':
+		return 0x8e, true
+	case r == 'š':
+		return 0x9a, true
+	case r == 'œ':
+		return 0x9c, true
+	case r == 'ž':
+		return 0x9e, true
+	case r == 'Ÿ':
+		return 0x9f, true
+	}
+
+	return 0, false
+}
+
+// helveticaWidths are the glyph widths of the printable ASCII characters in Helvetica, per 1000 units
+var helveticaWidths = [...]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space - /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556, // 0 - ?
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778, // @ - O
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556, // P - _
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556, // ` - o
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584, // p - ~
+}
@@ -0,0 +1,74 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNum(t *testing.T) {
+	assert.Equal(t, "0", num(0))
+	assert.Equal(t, "1.5", num(1.5))
+	assert.Equal(t, "2.83", num(MM(1)))
+	assert.Equal(t, "595.28", num(A4Width))
+}
+
+func TestEscape(t *testing.T) {
+	assert.Equal(t, `Hello \(world\) \\`, escape(`Hello (world) \`))
+	assert.Equal(t, `Musterm\344nn \200`, escape("Mustermänn €"))
+	assert.Equal(t, "?? ok", escape("老子 ok"))
+}
+
+func TestWrap(t *testing.T) {
+	lines := Wrap(Helvetica, 10, 100, "Client: Marie Louise La Lune, invoice 2024-001")
+	require.Greater(t, len(lines), 1)
+
+	for _, l := range lines {
+		assert.LessOrEqual(t, TextWidth(Helvetica, 10, l), 100.0, l)
+	}
+
+	assert.Equal(t, []string{"1234567890", "1234567890"}, Wrap(Helvetica, 10, 60, "12345678901234567890"))
+	assert.Empty(t, Wrap(Helvetica, 10, 60, ""))
+}
+
+func TestDocument(t *testing.T) {
+	d := New()
+
+	p := d.AddPage(A6Height, A6Width)
+	p.Rect(10, 10, 20, 20)
+	p.Fill(1)
+	p.Rect(15, 15, 10, 10)
+	p.Fill(0)
+	p.Line(0, 50, p.Width, 50, 0.5, true)
+	p.Text(10, 100, HelveticaBold, 12, "Payment (test)")
+
+	d.AddPage(A4Width, A4Height)
+
+	b := d.Bytes()
+
+	assert.True(t, bytes.HasPrefix(b, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(b, []byte("%%EOF\n")))
+	assert.Contains(t, string(b), "/Count 2")
+	assert.Contains(t, string(b), "10 10 20 20 re f\n1 g\n15 15 10 10 re f\n0 g\n")
+	assert.Contains(t, string(b), "0.5 w [3 3] 0 d 0 50 m 419.53 50 l S\n")
+	assert.Contains(t, string(b), `BT /F2 12 Tf 10 100 Td (Payment \(test\)) Tj ET`)
+
+	// Every entry in the cross-reference table should point to the start of its object
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(b)
+	require.NotNil(t, m)
+
+	xref, err := strconv.Atoi(string(m[1]))
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(b[xref:], []byte("xref\n0 9\n")))
+
+	for i, o := range regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(b[xref:], -1) {
+		offset, err := strconv.Atoi(string(o[1]))
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(b[offset:], fmt.Appendf(nil, "%d 0 obj\n", i+1)), "Object %d", i+1)
+	}
+}
@@ -1,19 +1,25 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 
 	"github.com/jovandeginste/payme/payment"
+	"github.com/jovandeginste/payme/qrcode"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
+	"github.com/spf13/pflag"
 )
 
 // CLI to generate SEPA payment QR codes, either as ASCII or PNG
 
 const qrSize = 300
 
+// bicAuto is the value of --bic that derives the BIC from the IBAN
+const bicAuto = "auto"
+
 var (
 	// gitRef     = "0.0.0-dev"
 	// gitRefType = "local"
@@ -22,11 +28,49 @@ var (
 	buildTime  = "manually"
 )
 
+var (
+	// ErrOutputType is returned when the output type is not supported
+	ErrOutputType = errors.New("output type should be png, svg, pdf or stdout")
+	// ErrAmountRequired is returned when an EPC payment has no amount, and --open-amount is not set
+	ErrAmountRequired = errors.New("amount is required; use --open-amount to leave it for the payer to fill in")
+)
+
 type qrParams struct {
 	Payment    *payment.Payment
 	OutputType string
 	OutputFile string
+	PageSize   string
+	SVG        qrcode.SVGOptions
+	QR         qrcode.Options
 	Debug      bool
+	ConfigFile string
+	Profile    string
+	Structured structuredValue
+
+	CharacterSet  characterSetValue
+	Transliterate bool
+	Amount        string
+	AmountLocale  string
+	OpenAmount    bool
+	From          string
+	FromFormat    string
+	BankDirectory string
+
+	Format          string
+	Currency        string
+	Creditor        address
+	Debtor          address
+	Message         string
+	BillInformation string
+	VariableSymbol  string
+	ConstantSymbol  string
+	SpecificSymbol  string
+	DueDate         string
+	CRC32           bool
+
+	StandingOrder    string
+	StandingOrderDay int
+	LastDate         string
 }
 
 func main() {
@@ -39,8 +83,10 @@ func main() {
 		log.Fatal(err)
 	}
 
-	if err := cmdRoot.Execute(); err != nil {
-		log.Fatal(err)
+	cmd, err := cmdRoot.ExecuteC()
+	if err != nil {
+		logError(err)
+		os.Exit(exitCode(cmd, err))
 	}
 }
 
@@ -49,13 +95,34 @@ func newCommand(q *qrParams) (*cobra.Command, error) {
 		Use:     "payme",
 		Version: fmt.Sprintf("%s (%s), built %s\n", gitRefName, gitCommit, buildTime),
 		Short:   "Generate SEPA payment QR code",
+		Long:    "Generate SEPA payment QR code\n\n" + exitCodesHelp,
 		Args:    cobra.NoArgs,
-		Run: func(_ *cobra.Command, _ []string) {
-			q.generate()
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return q.applyConfig(cmd)
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cmd.SilenceUsage = true
+			return q.generate(cmd.OutOrStdout(), cmd.ErrOrStderr())
 		},
+		SilenceErrors: true,
 	}
 
+	cmdRoot.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return &usageError{err: err}
+	})
+
+	cobra.AddTemplateFunc("flagUsages", flagUsages)
+	cmdRoot.SetUsageTemplate(usageTemplate(cmdRoot))
+
 	cmdRoot.AddCommand(completionCmd(cmdRoot))
+	cmdRoot.AddCommand(decodeCmd())
+	cmdRoot.AddCommand(referenceCmd())
+	cmdRoot.AddCommand(profileCmd(q))
+	cmdRoot.AddCommand(batchCmd(q))
+	cmdRoot.AddCommand(serveCmd(q))
+	cmdRoot.AddCommand(schemaCmd())
+	cmdRoot.AddCommand(validateCmd(q))
+	cmdRoot.AddCommand(inspectCmd(q))
 
 	if err := q.init(cmdRoot); err != nil {
 		return nil, err
@@ -65,89 +132,217 @@ func newCommand(q *qrParams) (*cobra.Command, error) {
 }
 
 func (q *qrParams) init(cmdRoot *cobra.Command) error {
-	viper.SetEnvPrefix("PAYME")
-
-	for _, e := range []string{"name", "bic", "iban"} {
-		if err := viper.BindEnv(e); err != nil {
-			return err
-		}
-	}
+	cmdRoot.PersistentFlags().StringVar(&q.ConfigFile, "config", "", "Config file (default $XDG_CONFIG_HOME/payme/config.yaml)")
+	cmdRoot.PersistentFlags().StringVar(&q.Profile, "profile", "", "Profile from the config file to take default values from")
 
-	cmdRoot.Flags().StringVar(&q.OutputType, "output", "stdout", "output type: png or stdout")
-	cmdRoot.Flags().StringVar(&q.OutputFile, "file", "", "write code to file, leave empty for stdout")
-	cmdRoot.Flags().BoolVar(&q.Debug, "debug", false, "print debug output")
+	q.addOutputFlags(cmdRoot.Flags())
+	cmdRoot.Flags().StringVar(&q.OutputFile, "file", "", "Write code to file, leave empty for stdout")
+	q.addPaymentFlags(cmdRoot.Flags())
+	q.addFormatFlags(cmdRoot.Flags())
 
-	cmdRoot.Flags().IntVar(&q.Payment.CharacterSet, "character-set", 2, "QR code character set")
-	cmdRoot.Flags().IntVar(&q.Payment.Version, "qr-version", 2, "QR code version")
-	cmdRoot.Flags().StringVar(&q.Payment.NameBeneficiary, "name", viper.GetString("name"), "Name of the beneficiary")
-	cmdRoot.Flags().StringVar(&q.Payment.BICBeneficiary, "bic", viper.GetString("bic"), "BIC of the beneficiary")
-	cmdRoot.Flags().StringVar(&q.Payment.IBANBeneficiary, "iban", viper.GetString("iban"), "IBAN of the beneficiary")
-	cmdRoot.Flags().Float64Var(&q.Payment.EuroAmount, "amount", 0, "Amount of the transaction")
-	cmdRoot.Flags().StringVar(&q.Payment.Remittance, "remittance", "", "Remittance (message)")
-	cmdRoot.Flags().StringVar(&q.Payment.Purpose, "purpose", "", "Purpose of the transaction")
-	cmdRoot.Flags().BoolVar(&q.Payment.RemittanceIsStructured, "structured", false, "Make the remittance (message) structured")
+	cmdRoot.MarkFlagsMutuallyExclusive("amount", "open-amount")
 
 	return nil
 }
 
-func (q *qrParams) generate() {
-	var (
-		qr  []byte
-		err error
-	)
+// addOutputFlags adds the flags that select the output type
+func (q *qrParams) addOutputFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&q.OutputType, "output", "stdout", "Output type: png, svg, pdf or stdout")
+	flags.StringVar(&q.PageSize, "page-size", string(payment.PageSizeA4), "Page size for pdf output: a4 or a6")
 
-	if q.Debug {
-		log.Printf("%#v\n", q)
+	svg := qrcode.DefaultSVGOptions()
+	flags.StringVar(&q.SVG.Size, "svg-size", svg.Size, "Width and height of svg output, in any SVG length (eg. 300, 4cm), empty to scale to its container")
+	flags.StringVar(&q.SVG.Foreground, "svg-foreground", svg.Foreground, "Color of the dark modules of svg output")
+	flags.StringVar(&q.SVG.Background, "svg-background", svg.Background, "Color of the background of svg output, empty for transparent")
+	flags.BoolVar(&q.Debug, "debug", false, "Print the payload to stderr: line by line for epc, as payme inspect does, and as it is for the other formats")
+}
+
+// addPaymentFlags adds the flags for the fields of the payment and the QR code options
+func (q *qrParams) addPaymentFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&q.From, "from", "", "Read the payment from a JSON, YAML or TOML file, or - for stdin; other flags take precedence")
+	flags.StringVar(&q.FromFormat, "from-format", "auto", "Format of the payment file: json, yaml, toml or auto (by its extension; yaml, which includes json, for stdin)")
+	q.CharacterSet.characterSet = &q.Payment.CharacterSet
+	flags.Var(&q.CharacterSet, "character-set", "QR code character set: 1 (UTF-8), 2..8 (ISO 8859) or auto for the first one that fits")
+	flags.BoolVar(&q.Transliterate, "transliterate", false, "Replace characters that do not fit in the character set, instead of failing")
+	flags.IntVar(&q.Payment.Version, "qr-version", 2, "QR code version")
+	flags.StringVar(&q.Payment.NameBeneficiary, "name", "", "Name of the beneficiary")
+	flags.StringVar(&q.Payment.BICBeneficiary, "bic", "", "BIC of the beneficiary, or auto to derive it from the IBAN when the code requires it (version 1, or outside the EEA)")
+	flags.StringVar(&q.BankDirectory, "bank-directory", "", "CSV file with banks (country, bank code, BIC, name) that take precedence over the built-in ones for --bic auto")
+	flags.StringVar(&q.Payment.IBANBeneficiary, "iban", "", "IBAN of the beneficiary")
+	flags.StringVar(&q.Amount, "amount", "", "Amount of the transaction, with at most 2 decimals")
+	flags.StringVar(&q.AmountLocale, "amount-locale", "", "Format of the amount: point (1,234.56), comma (1.234,56) or empty (1234.56)")
+	flags.BoolVar(&q.OpenAmount, "open-amount", false, "Leave the amount for the payer to fill in (eg. for donations)")
+	flags.StringVar(&q.Payment.Remittance, "remittance", "", "Remittance (message)")
+	flags.StringVar(&q.Payment.Purpose, "purpose", "", "Purpose of the transaction")
+	flags.StringVar(&q.Payment.B2OInformation, "b2o-information", "", "Beneficiary to originator information, shown to the payer")
+	q.Structured.structured = &q.Payment.RemittanceIsStructured
+	flags.Var(&q.Structured, "structured", "Make the remittance (message) structured: true, false or auto (when it is a valid RF reference or OGM, a QR reference for qrbill, or a model and reference such as HR01 1234 for hub3 or SI12 1234560 for upn)")
+	flags.Lookup("structured").NoOptDefVal = "true"
+
+	o := &q.QR
+	flags.StringVar((*string)(&o.Level), "ec-level", string(qrcode.LevelM), "QR code error correction level: L, M, Q or H")
+	flags.IntVar(&o.MinVersion, "min-symbol-version", 0, "Minimum QR code symbol version (1..40), 0 for the smallest that fits")
+	flags.StringVar((*string)(&o.Mode), "encoding-mode", string(qrcode.ModeAuto), "QR code encoding mode: auto, numeric, alphanumeric or byte")
+	flags.IntVar(&o.Mask, "mask", qrcode.MaskAuto, "QR code mask pattern (0..7), -1 to select the best one")
+	flags.IntVar(&o.QuietZone, "quiet-zone", qrcode.DefaultQuietZone, "Width of the blank border around the QR code, in modules")
+}
+
+// generate writes the code to the output file, or else to w, and the debug output to stderr
+func (q *qrParams) generate(w, stderr io.Writer) error {
+	if err := q.checkFormat(); err != nil {
+		return err
 	}
 
-	switch q.OutputType {
-	case "png":
-		qr, err = q.generateQRPNG()
-	case "stdout":
-		qr, err = q.generateQRStdout()
+	if err := q.prepare(); err != nil {
+		return err
+	}
+
+	if err := q.checkAmount(); err != nil {
+		return err
+	}
+
+	if q.Debug {
+		if err := q.printDebug(stderr); err != nil {
+			return err
+		}
 	}
 
+	qr, err := q.render()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	if q.OutputFile == "" {
-		fmt.Fprintf(os.Stdout, "%s", qr)
-		return
+		_, err = w.Write(qr)
+		return err
 	}
 
-	err = os.WriteFile(q.OutputFile, qr, 0o600)
-	if err != nil {
-		log.Fatal(err)
+	return os.WriteFile(q.OutputFile, qr, 0o600)
+}
+
+// readInput reads the file, or stdin when the path is -
+func readInput(path string, stdin io.Reader) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(stdin)
 	}
+
+	return os.ReadFile(path)
 }
 
-func (q *qrParams) generateQRStdout() ([]byte, error) {
+// render returns the code in the selected format and output type
+func (q *qrParams) render() ([]byte, error) {
+	switch q.Format {
+	case formatQRBill:
+		return q.renderBill()
+	case formatSPAYD:
+		return q.renderSPAYD()
+	case formatPayBySquare:
+		return q.renderPayBySquare()
+	case formatHUB3:
+		return q.renderHUB3()
+	case formatUPN:
+		return q.renderUPN()
+	}
+
+	switch q.OutputType {
+	case "png":
+		return q.generateQRPNG()
+	case "svg":
+		return q.generateQRSVG()
+	case "pdf":
+		return q.generateQRPDF()
+	case "stdout":
+		return q.generateQRStdout()
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrOutputType, q.OutputType)
+}
+
+// prepare parses the amount and resolves the flags that depend on the content of the payment
+func (q *qrParams) prepare() error {
 	p := q.Payment
 
-	if q.Debug {
-		s, err := p.ToString()
+	if q.Amount != "" {
+		a, err := payment.ParseAmount(q.Amount, payment.Locale(q.AmountLocale))
 		if err != nil {
-			return nil, err
+			return err
+		}
+
+		p.EuroAmount = a
+	}
+
+	if p.BICBeneficiary == bicAuto && q.Format == formatEPC {
+		if err := q.deriveBIC(); err != nil {
+			return err
 		}
+	}
+
+	if q.Structured.auto {
+		p.RemittanceIsStructured = payment.IsStructuredReference(p.Remittance) || q.isQRReference()
+	}
+
+	if q.CharacterSet.auto {
+		p.CharacterSet = p.DetectCharacterSet()
+	}
 
-		log.Print("Data: ", s)
+	if q.Transliterate {
+		p.Transliterate()
 	}
 
-	return p.ToQRBytes()
+	return nil
 }
 
-func (q *qrParams) generateQRPNG() ([]byte, error) {
+// checkAmount returns a validation error when an EPC payment has no amount, unless --open-amount leaves it
+// for the payer to fill in; the amount of the other formats is optional
+func (q *qrParams) checkAmount() error {
+	if q.Format != formatEPC || q.OpenAmount || !q.Payment.IsOpenAmount() {
+		return nil
+	}
+
+	return payment.ValidationErrors{payment.NewValidationError("EuroAmount", "AT-04", payment.RuleRequired, "", ErrAmountRequired)}
+}
+
+// deriveBIC sets the BIC of the bank of the IBAN when the code requires it, and clears it otherwise
+func (q *qrParams) deriveBIC() error {
 	p := q.Payment
 
-	if q.Debug {
-		s, err := p.ToString()
+	if !p.RequiresBIC() {
+		p.BICBeneficiary = ""
+		return nil
+	}
+
+	d := payment.DefaultBankDirectory()
+
+	if q.BankDirectory != "" {
+		f, err := os.Open(q.BankDirectory)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		custom, err := payment.ReadBankDirectory(f)
 		if err != nil {
-			return nil, err
+			return fmt.Errorf("%s: %w", q.BankDirectory, err)
 		}
 
-		log.Print("Data: ", s)
+		d = d.Extend(custom)
 	}
 
-	return p.ToQRPNG(qrSize)
+	return p.SetBICFromIBAN(d)
+}
+
+func (q *qrParams) generateQRStdout() ([]byte, error) {
+	return qrcode.ToBytes(q.Payment, q.QR)
+}
+
+func (q *qrParams) generateQRPNG() ([]byte, error) {
+	return qrcode.ToPNG(q.Payment, q.QR, qrSize)
+}
+
+func (q *qrParams) generateQRSVG() ([]byte, error) {
+	return qrcode.ToSVG(q.Payment, q.QR, q.SVG)
+}
+
+func (q *qrParams) generateQRPDF() ([]byte, error) {
+	return q.Payment.ToQRPDF(payment.PDFOptions{PageSize: payment.PageSize(q.PageSize), QR: q.QR})
 }
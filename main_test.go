@@ -0,0 +1,231 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCharacterSetAuto(t *testing.T) {
+	for name, characterSet := range map[string]int{
+		"Franz Mustermänn":       2,
+		"Łódź Spółka":            3,
+		"Лев Николаевич Толстой": 5,
+		"老子": 1,
+	} {
+		q := qrParams{
+			Payment: payment.New(),
+		}
+
+		cmdRoot, err := newCommand(&q)
+		require.NoError(t, err)
+
+		cmdRoot.SetArgs([]string{
+			"--character-set", "auto",
+			"--name", name,
+			"--iban", "DE71110220330123456789",
+			"--amount", "12.3",
+			"--remittance", "Invoice 1234",
+			"--file", filepath.Join(t.TempDir(), "qr.txt"),
+		})
+
+		require.NoError(t, cmdRoot.Execute())
+		assert.Equal(t, characterSet, q.Payment.CharacterSet, name)
+	}
+}
+
+func TestTransliterate(t *testing.T) {
+	q := qrParams{
+		Payment: payment.New(),
+	}
+
+	cmdRoot, err := newCommand(&q)
+	require.NoError(t, err)
+
+	file := filepath.Join(t.TempDir(), "qr.png")
+
+	cmdRoot.SetArgs([]string{
+		"--transliterate",
+		"--name", "Łódź Spółka",
+		"--iban", "PL61109010140000071219812874",
+		"--amount", "12.3",
+		"--remittance", "Faktura 1234",
+		"--output", "png",
+		"--file", file,
+	})
+
+	require.NoError(t, cmdRoot.Execute())
+	assert.Equal(t, 2, q.Payment.CharacterSet)
+	assert.Equal(t, "Lódz Spólka", q.Payment.NameBeneficiary)
+
+	f, err := os.Open(file)
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	p, err := payment.FromQRImage(f)
+	require.NoError(t, err)
+	assert.Equal(t, "Lódz Spólka", p.NameBeneficiary)
+}
+
+func TestOpenAmount(t *testing.T) {
+	q := qrParams{
+		Payment: payment.New(),
+	}
+
+	cmdRoot, err := newCommand(&q)
+	require.NoError(t, err)
+
+	file := filepath.Join(t.TempDir(), "qr.png")
+
+	cmdRoot.SetArgs([]string{
+		"--open-amount",
+		"--name", "Stichting Het Goede Doel",
+		"--iban", "NL91ABNA0417164300",
+		"--remittance", "Donation",
+		"--output", "png",
+		"--file", file,
+	})
+
+	require.NoError(t, cmdRoot.Execute())
+
+	f, err := os.Open(file)
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	p, err := payment.FromQRImage(f)
+	require.NoError(t, err)
+	assert.True(t, p.IsOpenAmount())
+	assert.Empty(t, p.EuroAmountString())
+}
+
+func TestSVGOptions(t *testing.T) {
+	writeTestConfig(t, "")
+
+	file := filepath.Join(t.TempDir(), "qr.svg")
+
+	q := qrParams{
+		Payment: payment.New(),
+	}
+
+	cmdRoot, err := newCommand(&q)
+	require.NoError(t, err)
+
+	cmdRoot.SetArgs([]string{
+		"--name", "Franz Mustermänn",
+		"--iban", "DE71110220330123456789",
+		"--amount", "12.3",
+		"--remittance", "Invoice 1234",
+		"--output", "svg",
+		"--svg-size", "4cm",
+		"--svg-foreground", "#1a237e",
+		"--svg-background", "",
+		"--file", file,
+	})
+
+	require.NoError(t, cmdRoot.Execute())
+
+	b, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `width="4cm"`)
+	assert.Contains(t, string(b), `fill="#1a237e"`)
+	assert.NotContains(t, string(b), "<rect", "an empty background is transparent")
+}
+
+func TestAmountRequired(t *testing.T) {
+	writeTestConfig(t, "")
+
+	args := []string{"--name", "Stichting Het Goede Doel", "--iban", "NL91ABNA0417164300", "--remittance", "Donation"}
+
+	_, err := runGenerate(t, args...)
+	require.NoError(t, err, "runGenerate sets an amount")
+
+	q := qrParams{
+		Payment: payment.New(),
+	}
+
+	cmdRoot, err := newCommand(&q)
+	require.NoError(t, err)
+
+	cmdRoot.SetArgs(args)
+	cmdRoot.SetOut(io.Discard)
+	cmdRoot.SetErr(io.Discard)
+
+	err = cmdRoot.Execute()
+	require.ErrorIs(t, err, ErrAmountRequired)
+
+	var errs payment.ValidationErrors
+	require.ErrorAs(t, err, &errs)
+	assert.Len(t, errs, 1)
+
+	_, code := runExit(t, "", args...)
+	assert.Equal(t, exitValidation, code)
+
+	_, code = runExit(t, "", append(args, "--amount", "5", "--open-amount")...)
+	assert.Equal(t, exitUsage, code)
+
+	_, code = runExit(t, "", "--format", "spayd", "--iban", "CZ6508000000192000145399", "--remittance", "Dar")
+	assert.Equal(t, exitOK, code, "the amount of the other formats is optional")
+}
+
+func TestAmountLocale(t *testing.T) {
+	for _, args := range [][]string{
+		{"--amount", "1234.56"},
+		{"--amount", "1,234.56", "--amount-locale", "point"},
+		{"--amount", "1.234,56", "--amount-locale", "comma"},
+	} {
+		q := qrParams{
+			Payment: payment.New(),
+		}
+
+		cmdRoot, err := newCommand(&q)
+		require.NoError(t, err)
+
+		cmdRoot.SetArgs(append(args,
+			"--name", "Franz Mustermänn",
+			"--iban", "DE71110220330123456789",
+			"--remittance", "Invoice 1234",
+			"--file", filepath.Join(t.TempDir(), "qr.txt"),
+		))
+
+		require.NoError(t, cmdRoot.Execute())
+		assert.Equal(t, payment.Amount(123456), q.Payment.EuroAmount, args)
+	}
+
+	q := qrParams{
+		Payment: payment.New(),
+		Amount:  "0.005",
+	}
+	require.ErrorIs(t, q.prepare(), payment.ErrAmountDecimals)
+
+	q.Amount, q.AmountLocale = "12,30", "point"
+	require.ErrorIs(t, q.prepare(), payment.ErrAmountFormat)
+}
+
+func TestBICAuto(t *testing.T) {
+	writeTestConfig(t, "")
+
+	q, err := runGenerate(t, "--name", "Jane Doe", "--iban", "DE89 3704 0044 0532 0130 00", "--qr-version", "1", "--bic", "auto")
+	require.NoError(t, err)
+	assert.Equal(t, "COBADEFFXXX", q.Payment.BICBeneficiary)
+
+	q, err = runGenerate(t, "--name", "Jane Doe", "--iban", "NL91ABNA0417164300", "--bic", "auto")
+	require.NoError(t, err)
+	assert.Empty(t, q.Payment.BICBeneficiary, "the BIC is optional for version 2 in the EEA")
+
+	_, err = runGenerate(t, "--name", "Jane Doe", "--iban", "CH9300762011623852957", "--bic", "auto")
+	require.ErrorIs(t, err, payment.ErrBankUnknown)
+
+	path := filepath.Join(t.TempDir(), "banks.csv")
+	require.NoError(t, os.WriteFile(path, []byte("CH,00762,ABCDCHZZXXX,Test Bank\n"), 0o600))
+
+	q, err = runGenerate(t, "--name", "Jane Doe", "--iban", "CH9300762011623852957", "--bic", "auto", "--bank-directory", path)
+	require.NoError(t, err)
+	assert.Equal(t, "ABCDCHZZXXX", q.Payment.BICBeneficiary)
+}
@@ -0,0 +1,192 @@
+package paybysquare
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jovandeginste/payme/internal/lzma"
+	"github.com/jovandeginste/payme/payment"
+)
+
+// Parse reads a code into a Payment. Only the first payment of the document is read, with its first bank
+// account. The originator's reference information becomes a structured remittance; when it is empty, the
+// payment note is the unstructured remittance. Parse only checks the syntax of the code; use IsValid to check
+// the content.
+func Parse(s string) (*Payment, error) {
+	b, err := encoding.DecodeString(strings.ToUpper(strings.TrimSpace(s)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrParseEncoding, err)
+	}
+
+	// Type 0 is PAY by square, and the document type 0 is a payment order
+	if len(b) < 4 || b[0]>>4 != 0 || b[0]&0x0F > version || b[1] != 0 {
+		return nil, ErrParseHeader
+	}
+
+	data, err := lzma.Decompress(b[4:], lzma.DefaultProperties(), int(binary.LittleEndian.Uint16(b[2:4])))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrParseCompression, err)
+	}
+
+	if len(data) < 4 {
+		return nil, ErrParseFields
+	}
+
+	if binary.LittleEndian.Uint32(data) != crc32.ChecksumIEEE(data[4:]) {
+		return nil, ErrParseCRC32
+	}
+
+	sp := New()
+
+	if err := sp.set(&fieldReader{fields: strings.Split(string(data[4:]), "\t")}); err != nil {
+		return nil, err
+	}
+
+	return sp, nil
+}
+
+// fieldReader reads the tab separated fields of a document in order
+type fieldReader struct {
+	fields []string
+	err    error
+}
+
+// next returns the next field, or an empty string when there are no more fields
+func (r *fieldReader) next() string {
+	if len(r.fields) == 0 {
+		r.err = ErrParseFields
+		return ""
+	}
+
+	f := r.fields[0]
+	r.fields = r.fields[1:]
+
+	return f
+}
+
+// number returns the next field as a number, where an empty field is 0
+func (r *fieldReader) number() int {
+	f := r.next()
+	if f == "" {
+		return 0
+	}
+
+	n, err := strconv.Atoi(f)
+	if err != nil || n < 0 {
+		r.err = fmt.Errorf("%w: %q is not a number", ErrParseFields, f)
+	}
+
+	return n
+}
+
+// date returns the next field as a date, where an empty field is the zero date
+func (r *fieldReader) date() time.Time {
+	f := r.next()
+	if f == "" {
+		return time.Time{}
+	}
+
+	t, err := time.Parse(dateLayout, f)
+	if err != nil {
+		r.err = fmt.Errorf("%w: %q", ErrParseDate, f)
+	}
+
+	return t
+}
+
+// set sets the fields of the payment from the fields of a document
+func (s *Payment) set(r *fieldReader) error {
+	p := s.Payment
+
+	r.next() // invoice ID
+
+	if r.number() < 1 {
+		return fmt.Errorf("%w: no payments", ErrParseFields)
+	}
+
+	paymentType := PaymentType(r.number())
+	amount := r.next()
+	s.Currency = r.next()
+	s.DueDate = r.date()
+	s.VariableSymbol = r.next()
+	s.ConstantSymbol = r.next()
+	s.SpecificSymbol = r.next()
+	reference := r.next()
+	note := r.next()
+
+	if r.err != nil {
+		return r.err
+	}
+
+	if paymentType != PaymentOrder && paymentType != StandingOrderPayment {
+		return fmt.Errorf("%w: %d", ErrParsePaymentType, paymentType)
+	}
+
+	if amount != "" {
+		a, err := payment.ParseAmount(amount, payment.LocaleNone)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrParseAmount, err)
+		}
+
+		p.EuroAmount = a
+	}
+
+	p.Remittance = note
+
+	if reference != "" {
+		p.Remittance, p.RemittanceIsStructured = reference, true
+	}
+
+	accounts := r.number()
+	if accounts < 1 && r.err == nil {
+		return fmt.Errorf("%w: no bank accounts", ErrParseFields)
+	}
+
+	p.IBANBeneficiary = r.next()
+	p.BICBeneficiary = r.next()
+
+	for range accounts - 1 {
+		r.next()
+		r.next()
+	}
+
+	if r.next() == "1" {
+		s.StandingOrder = r.standingOrder()
+	}
+
+	if paymentType == StandingOrderPayment && s.StandingOrder == nil && r.err == nil {
+		return fmt.Errorf("%w: the standing order has no schedule", ErrParseFields)
+	}
+
+	if r.next() == "1" {
+		return fmt.Errorf("%w: %d", ErrParsePaymentType, DirectDebit)
+	}
+
+	// Documents of version 1.0.0 end with the direct debit extension, without the beneficiary
+	if len(r.fields) > 0 {
+		p.NameBeneficiary = r.next()
+	}
+
+	return r.err
+}
+
+// standingOrder returns the standing order extension
+func (r *fieldReader) standingOrder() *StandingOrder {
+	o := &StandingOrder{Day: r.number()}
+
+	mask := r.number()
+	for m := time.January; m <= time.December; m++ {
+		if mask&(1<<(m-1)) != 0 {
+			o.Months = append(o.Months, m)
+		}
+	}
+
+	o.Periodicity = Periodicity(r.next())
+	o.LastDate = r.date()
+
+	return o
+}
@@ -0,0 +1,116 @@
+package paybysquare_test
+
+import (
+	"encoding/base32"
+	"encoding/binary"
+	"hash/crc32"
+	"strings"
+	"testing"
+
+	"github.com/jovandeginste/payme/internal/lzma"
+	"github.com/jovandeginste/payme/paybysquare"
+	"github.com/jovandeginste/payme/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pythonCode is a document of version 1.0.0, without the beneficiary, compressed by Python's lzma module
+const pythonCode = "0005U000AGOPAABVRBRR6T6D822EVRDRMI39TCOJL1SS2QEHGRRIIP6ROK8HJG1JA7AEP1RQ363NMC3R4FECM2FAI25VBLBK04LPVRJHNIDKSCTSLOU1VOO8NNFFPMCAMP3US16F3SRLL2P1VVPG4E00"
+
+func TestParseReference(t *testing.T) {
+	s, err := paybysquare.Parse(pythonCode)
+	require.NoError(t, err)
+	assert.Equal(t, "SK7283300000009111111118", s.Payment.IBANBeneficiary)
+	assert.Equal(t, "FIOZSKBAXXX", s.Payment.BICBeneficiary)
+	assert.Equal(t, payment.Amount(1050), s.Payment.EuroAmount)
+	assert.Equal(t, "EUR", s.Currency)
+	assert.Equal(t, "20261031", s.DueDate.Format("20060102"))
+	assert.Equal(t, "47", s.VariableSymbol)
+	assert.Equal(t, "0308", s.ConstantSymbol)
+	assert.Equal(t, "1234", s.SpecificSymbol)
+	assert.Equal(t, "hello world", s.Payment.Remittance)
+	assert.Empty(t, s.Payment.NameBeneficiary)
+}
+
+func TestParseLowerCase(t *testing.T) {
+	code, err := examplePayment().ToString()
+	require.NoError(t, err)
+
+	_, err = paybysquare.Parse(" " + strings.ToLower(code) + "\n")
+	require.NoError(t, err)
+}
+
+func TestParseErrors(t *testing.T) {
+	code, err := examplePayment().ToString()
+	require.NoError(t, err)
+
+	raw, err := base32.HexEncoding.WithPadding(base32.NoPadding).DecodeString(code)
+	require.NoError(t, err)
+
+	encode := func(b []byte) string {
+		return base32.HexEncoding.WithPadding(base32.NoPadding).EncodeToString(b)
+	}
+
+	_, err = paybysquare.Parse("XYZ")
+	require.ErrorIs(t, err, paybysquare.ErrParseEncoding)
+
+	_, err = paybysquare.Parse("00")
+	require.ErrorIs(t, err, paybysquare.ErrParseHeader)
+
+	for _, header := range [][]byte{{0x10, 0}, {0x02, 0}, {0x01, 0x10}} {
+		b := append(append([]byte{}, header...), raw[2:]...)
+
+		_, err = paybysquare.Parse(encode(b))
+		require.ErrorIs(t, err, paybysquare.ErrParseHeader)
+	}
+
+	_, err = paybysquare.Parse(encode(raw[:len(raw)-10]))
+	require.ErrorIs(t, err, paybysquare.ErrParseCompression)
+
+	// A longer size leaves the data short of the end of stream marker
+	long := append([]byte{}, raw...)
+	long[2]++
+
+	_, err = paybysquare.Parse(encode(long))
+	require.ErrorIs(t, err, paybysquare.ErrParseCompression)
+
+	_, err = paybysquare.Parse(document(t, []byte{0, 0, 0, 0}, "\t1\t1"))
+	require.ErrorIs(t, err, paybysquare.ErrParseCRC32)
+
+	for _, fields := range []string{
+		"\t0",
+		"\t1\t1\t1.00\tEUR",
+		"\t1\tx",
+		"\t1\t4\t\tEUR\t\t\t\t\t\t\t1\tSK7283300000009111111118\t\t0\t0",
+		"\t1\t2\t\tEUR\t\t\t\t\t\t\t1\tSK7283300000009111111118\t\t0\t0",
+		"\t1\t1\t\tEUR\t\t\t\t\t\t\t0\t0\t0",
+		"\t1\t1\t\tEUR\t\t\t\t\t\t\t1\tSK7283300000009111111118\t\t0\t1",
+	} {
+		_, err = paybysquare.Parse(document(t, nil, fields))
+		require.Error(t, err, fields)
+	}
+
+	_, err = paybysquare.Parse(document(t, nil, "\t1\t1\t1,00\tEUR\t\t\t\t\t\t\t1\tSK7283300000009111111118\t\t0\t0"))
+	require.ErrorIs(t, err, paybysquare.ErrParseAmount)
+
+	_, err = paybysquare.Parse(document(t, nil, "\t1\t1\t\tEUR\t2026-10-31\t\t\t\t\t\t1\tSK7283300000009111111118\t\t0\t0"))
+	require.ErrorIs(t, err, paybysquare.ErrParseDate)
+}
+
+// document returns the code of the tab separated fields, with their checksum unless it is given
+func document(t *testing.T, checksum []byte, fields string) string {
+	t.Helper()
+
+	if checksum == nil {
+		checksum = binary.LittleEndian.AppendUint32(nil, crc32.ChecksumIEEE([]byte(fields)))
+	}
+
+	data := append(checksum, fields...)
+
+	compressed, err := lzma.Compress(data, lzma.DefaultProperties())
+	require.NoError(t, err)
+
+	b := binary.LittleEndian.AppendUint16([]byte{0, 0}, uint16(len(data)))
+
+	return base32.HexEncoding.WithPadding(base32.NoPadding).EncodeToString(append(b, compressed...))
+}
@@ -0,0 +1,471 @@
+// Package paybysquare encodes and decodes PAY by square, the QR payment format of the Slovak Banking Association.
+// The fields of the payment are separated by tabs and prefixed with their CRC32, then compressed with LZMA and
+// encoded in base32hex, behind a header with the type and version of the document.
+//
+// See: https://www.sbaonline.sk/wp-content/uploads/2020/03/pay-by-square-specifications-1_1_0.pdf
+package paybysquare
+
+import (
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jovandeginste/payme/internal/lzma"
+	"github.com/jovandeginste/payme/payment"
+	"github.com/jovandeginste/payme/qrcode"
+)
+
+const (
+	// version is the version of the specification of the documents that are encoded: 1.1.0, which adds the
+	// name and address of the beneficiary
+	version = 1
+	// dateLayout is the format of the dates
+	dateLayout = "20060102"
+
+	// CurrencyEUR is the euro; this is the default
+	CurrencyEUR = "EUR"
+
+	// maxName is the largest name of the beneficiary, in characters
+	maxName = 70
+	// maxNote is the largest payment note, in characters
+	maxNote = 140
+	// maxReference is the largest originator's reference information, in characters
+	maxReference = 35
+	// maxConstantSymbol is the largest constant symbol, in digits
+	maxConstantSymbol = 4
+	// maxSymbol is the largest variable or specific symbol, in digits
+	maxSymbol = 10
+)
+
+// PaymentType is the kind of payment of a document
+type PaymentType int
+
+const (
+	// PaymentOrder is a single payment
+	PaymentOrder PaymentType = 1
+	// StandingOrderPayment is a standing order: a payment that repeats
+	StandingOrderPayment PaymentType = 2
+	// DirectDebit is a direct debit, which this package does not support
+	DirectDebit PaymentType = 4
+)
+
+// Periodicity is how often a standing order repeats
+type Periodicity string
+
+// Periodicities of a standing order
+const (
+	Daily        Periodicity = "d"
+	Weekly       Periodicity = "w"
+	Biweekly     Periodicity = "b"
+	Monthly      Periodicity = "m"
+	Bimonthly    Periodicity = "B"
+	Quarterly    Periodicity = "q"
+	Semiannually Periodicity = "s"
+	Annually     Periodicity = "a"
+)
+
+var periodicities = map[string]Periodicity{
+	"daily":        Daily,
+	"weekly":       Weekly,
+	"biweekly":     Biweekly,
+	"monthly":      Monthly,
+	"bimonthly":    Bimonthly,
+	"quarterly":    Quarterly,
+	"semiannually": Semiannually,
+	"annually":     Annually,
+}
+
+// ParsePeriodicity returns the periodicity of its name (eg. monthly) or its code (eg. m)
+func ParsePeriodicity(s string) (Periodicity, error) {
+	if p, ok := periodicities[strings.ToLower(s)]; ok {
+		return p, nil
+	}
+
+	for _, p := range periodicities {
+		if string(p) == s {
+			return p, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %q", ErrValidationPeriodicity, s)
+}
+
+// IsWeekly returns whether the day of the standing order is a day of the week rather than of the month
+func (p Periodicity) IsWeekly() bool {
+	return p == Weekly || p == Biweekly
+}
+
+// StandingOrder is the schedule of a payment that repeats; the due date of the payment is the first payment
+type StandingOrder struct {
+	// Periodicity is how often the payment repeats
+	Periodicity Periodicity
+	// Day is the day of the payment: the day of the week (1 is Monday) for weekly periodicities, and the day of
+	// the month otherwise; leave it 0 to use the day of the due date
+	Day int
+	// Months are the months in which the payment is made; leave it empty for every month of the periodicity
+	Months []time.Month
+	// LastDate is the date of the last payment; leave it zero to repeat until it is cancelled
+	LastDate time.Time
+}
+
+var (
+	currencyValidator = regexp.MustCompile(`^[A-Z]{3}$`)
+	encoding          = base32.HexEncoding.WithPadding(base32.NoPadding)
+
+	// ErrValidationAccount is returned when the account is not a valid IBAN
+	ErrValidationAccount = errors.New("field 'IBANBeneficiary' should be a valid IBAN")
+	// ErrValidationBIC is returned when the BIC is not a valid BIC
+	ErrValidationBIC = errors.New("field 'BICBeneficiary' should be a valid BIC (ISO 9362)")
+	// ErrValidationAmount is returned when the amount is not 0 (open amount), or 0.01 or more and 999999999.99 or less
+	ErrValidationAmount = errors.New("field 'EuroAmount' must be 0 (open amount), or 0.01 or more and 999999999.99 or less")
+	// ErrValidationCurrency is returned when the currency is not a 3-letter ISO 4217 code
+	ErrValidationCurrency = errors.New("field 'Currency' should be a 3-letter currency code (ISO 4217), eg. EUR")
+	// ErrValidationNameRequired is returned when the name of the beneficiary is empty
+	ErrValidationNameRequired = errors.New("field 'NameBeneficiary' is required")
+	// ErrValidationNameTooLong is returned when the name of the beneficiary exceeds 70 characters
+	ErrValidationNameTooLong = errors.New("field 'NameBeneficiary' should not exceed 70 characters")
+	// ErrValidationNoteTooLong is returned when the unstructured remittance exceeds 140 characters
+	ErrValidationNoteTooLong = errors.New("unstructured 'Remittance' should not exceed 140 characters")
+	// ErrValidationReferenceTooLong is returned when the structured remittance exceeds 35 characters
+	ErrValidationReferenceTooLong = errors.New("structured 'Remittance' should not exceed 35 characters")
+	// ErrValidationReference is returned when the structured remittance starts with RF but is not a valid
+	// ISO 11649 reference
+	ErrValidationReference = errors.New("structured 'Remittance' is not a valid RF creditor reference")
+	// ErrValidationCharacters is returned when a text field contains control characters, such as tabs
+	ErrValidationCharacters = errors.New("field should only contain printable characters")
+	// ErrValidationSymbol is returned when a variable or specific symbol is not 1 to 10 digits, or a constant
+	// symbol is not 1 to 4 digits
+	ErrValidationSymbol = errors.New("field should be 1 to 10 digits, or 1 to 4 digits for the constant symbol")
+	// ErrValidationPeriodicity is returned when the periodicity of a standing order is not one of its codes
+	ErrValidationPeriodicity = errors.New("periodicity should be daily (d), weekly (w), biweekly (b), monthly (m), bimonthly (B), quarterly (q), semiannually (s) or annually (a)")
+	// ErrValidationDay is returned when the day of a standing order is not a day of the week or of the month
+	ErrValidationDay = errors.New("day of a standing order should be 1..7 (Monday..Sunday) when weekly, and 1..31 otherwise")
+	// ErrValidationMonth is returned when a month of a standing order is not a month
+	ErrValidationMonth = errors.New("months of a standing order should be 1..12")
+	// ErrValidationLastDate is returned when the last date of a standing order is before its due date
+	ErrValidationLastDate = errors.New("last date of a standing order should not be before its due date")
+
+	// ErrParseEncoding is returned when the code is not base32hex
+	ErrParseEncoding = errors.New("code should be base32hex: digits and the letters A to V")
+	// ErrParseHeader is returned when the code is not a PAY by square document of a supported version
+	ErrParseHeader = errors.New("code should be a PAY by square document of version 1.0.0 or 1.1.0")
+	// ErrParseCompression is returned when the compressed data of the code is corrupt
+	ErrParseCompression = errors.New("compressed data of the code is corrupt")
+	// ErrParseCRC32 is returned when the checksum does not match the data
+	ErrParseCRC32 = errors.New("checksum does not match the data")
+	// ErrParseFields is returned when the data has too few fields, or a field is not a number where it should be
+	ErrParseFields = errors.New("data does not have the fields of a payment")
+	// ErrParsePaymentType is returned when the payment is not a payment order or a standing order
+	ErrParsePaymentType = errors.New("payment should be a payment order (1) or a standing order (2)")
+	// ErrParseAmount is returned when the amount is not a number with at most 2 decimals
+	ErrParseAmount = errors.New("amount should be a number with at most 2 decimals, eg. 25.30")
+	// ErrParseDate is returned when a date is not of the form YYYYMMDD
+	ErrParseDate = errors.New("date should be of the form YYYYMMDD")
+)
+
+// Payment is a payment in the PAY by square format: the account, name, amount and remittance of a
+// payment.Payment, with the Slovak symbols, a due date and a schedule for standing orders
+type Payment struct {
+	// Payment holds the IBAN and BIC, the name of the beneficiary, the amount, and the remittance: the
+	// originator's reference information when it is structured, and the payment note otherwise; its other fields
+	// are not part of the document
+	Payment *payment.Payment
+	// Currency is the 3-letter ISO 4217 code of the amount, eg. EUR
+	Currency string
+	// DueDate is the date the payment is due, or the first payment of a standing order; leave it zero to pay at
+	// once
+	DueDate time.Time
+	// VariableSymbol identifies the payment for the beneficiary, eg. the invoice number (up to 10 digits)
+	VariableSymbol string
+	// ConstantSymbol is the kind of payment (up to 4 digits)
+	ConstantSymbol string
+	// SpecificSymbol identifies the payer for the beneficiary, eg. the customer number (up to 10 digits)
+	SpecificSymbol string
+	// StandingOrder is the schedule of a standing order; leave it nil for a single payment
+	StandingOrder *StandingOrder
+}
+
+// New returns a new Payment in euro
+func New() *Payment {
+	return &Payment{Payment: payment.New(), Currency: CurrencyEUR}
+}
+
+// Type returns the type of the payment: a standing order when it has a schedule, and a payment order otherwise
+func (s *Payment) Type() PaymentType {
+	if s.StandingOrder != nil {
+		return StandingOrderPayment
+	}
+
+	return PaymentOrder
+}
+
+// fields returns the fields of the document: a single payment, with one bank account
+func (s *Payment) fields() []string {
+	p := s.Payment
+
+	account := p.IBANBeneficiary
+	if i, err := p.IBAN(); err == nil {
+		account = i.Code
+	}
+
+	var amount, dueDate, reference, note string
+
+	if !p.IsOpenAmount() {
+		amount = p.EuroAmount.String()
+	}
+
+	if !s.DueDate.IsZero() {
+		dueDate = s.DueDate.Format(dateLayout)
+	}
+
+	if p.RemittanceIsStructured {
+		reference = p.RemittanceStructured()
+	} else {
+		note = p.Remittance
+	}
+
+	fields := []string{
+		"",  // invoice ID
+		"1", // number of payments
+		strconv.Itoa(int(s.Type())),
+		amount,
+		s.Currency,
+		dueDate,
+		s.VariableSymbol,
+		s.ConstantSymbol,
+		s.SpecificSymbol,
+		reference,
+		note,
+		"1", // number of bank accounts
+		account,
+		p.BICBeneficiary,
+	}
+
+	fields = append(fields, s.StandingOrder.fields()...)
+
+	return append(fields,
+		"0", // no direct debit
+		p.NameBeneficiary,
+		"", // address lines of the beneficiary
+		"",
+	)
+}
+
+// fields returns the fields of the standing order extension
+func (o *StandingOrder) fields() []string {
+	if o == nil {
+		return []string{"0"}
+	}
+
+	var day, months, lastDate string
+
+	if o.Day != 0 {
+		day = strconv.Itoa(o.Day)
+	}
+
+	if len(o.Months) > 0 {
+		months = strconv.Itoa(monthsMask(o.Months))
+	}
+
+	if !o.LastDate.IsZero() {
+		lastDate = o.LastDate.Format(dateLayout)
+	}
+
+	return []string{"1", day, months, string(o.Periodicity), lastDate}
+}
+
+// monthsMask returns the months as a bit mask: January is 1, February is 2, March is 4, and so on
+func monthsMask(months []time.Month) int {
+	var mask int
+
+	for _, m := range months {
+		mask |= 1 << (m - 1)
+	}
+
+	return mask
+}
+
+// ToString returns the code of the payment, after validating it
+func (s *Payment) ToString() (string, error) {
+	if err := s.IsValid(); err != nil {
+		return "", err
+	}
+
+	serialized := []byte(strings.Join(s.fields(), "\t"))
+	data := binary.LittleEndian.AppendUint32(nil, crc32.ChecksumIEEE(serialized))
+	data = append(data, serialized...)
+
+	compressed, err := lzma.Compress(data, lzma.DefaultProperties())
+	if err != nil {
+		return "", err
+	}
+
+	// The header is the type (0 is PAY by square) and version, then the document type and a reserved nibble
+	b := []byte{version, 0}
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(data)))
+	b = append(b, compressed...)
+
+	return encoding.EncodeToString(b), nil
+}
+
+// QRCode returns the QR code of the payment, encoded with the options
+// The base32hex code fits in alphanumeric mode, which makes the QR code smaller.
+func (s *Payment) QRCode(opts qrcode.Options) (*qrcode.Code, error) {
+	str, err := s.ToString()
+	if err != nil {
+		return nil, err
+	}
+
+	return qrcode.Encode([]byte(str), opts)
+}
+
+// IsValid checks the payment against the PAY by square specification.
+// It returns nil if all is well, or payment.ValidationErrors with every problem it finds.
+func (s *Payment) IsValid() error {
+	p := s.Payment
+
+	var errs payment.ValidationErrors
+
+	if _, err := p.IBAN(); err != nil {
+		errs = append(errs, payment.NewValidationError("IBANBeneficiary", "IBAN", payment.RuleFormat, "IBAN",
+			fmt.Errorf("%w: %w", ErrValidationAccount, err)))
+	}
+
+	if p.BICBeneficiary != "" {
+		if err := payment.ValidateBIC(p.BICBeneficiary); err != nil {
+			errs = append(errs, payment.NewValidationError("BICBeneficiary", "BIC", payment.RuleFormat, "ISO 9362",
+				fmt.Errorf("%w: %w", ErrValidationBIC, err)))
+		}
+	}
+
+	if p.EuroAmount < 0 || p.EuroAmount > payment.MaxAmount {
+		errs = append(errs, payment.NewValidationError("EuroAmount", "Amount", payment.RuleRange, "0.01..999999999.99",
+			ErrValidationAmount))
+	}
+
+	if !currencyValidator.MatchString(s.Currency) {
+		errs = append(errs, payment.NewValidationError("Currency", "CurrencyCode", payment.RuleFormat, "ISO 4217",
+			fmt.Errorf("%w: %q", ErrValidationCurrency, s.Currency)))
+	}
+
+	if p.NameBeneficiary == "" {
+		errs = append(errs, payment.NewValidationError("NameBeneficiary", "BeneficiaryName", payment.RuleRequired, "",
+			ErrValidationNameRequired))
+	}
+
+	errs = append(errs, textRule("BeneficiaryName", maxName, ErrValidationNameTooLong).Validate("NameBeneficiary", p.NameBeneficiary)...)
+	errs = append(errs, s.validateRemittance()...)
+	errs = append(errs, s.validateSymbols()...)
+	errs = append(errs, s.validateStandingOrder()...)
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+// validateRemittance checks the originator's reference information or the payment note
+func (s *Payment) validateRemittance() payment.ValidationErrors {
+	p := s.Payment
+
+	if !p.RemittanceIsStructured {
+		return textRule("PaymentNote", maxNote, ErrValidationNoteTooLong).Validate("Remittance", p.Remittance)
+	}
+
+	ref := p.RemittanceStructured()
+	errs := textRule("OriginatorsReferenceInformation", maxReference, ErrValidationReferenceTooLong).Validate("Remittance", ref)
+
+	if strings.HasPrefix(ref, "RF") {
+		if err := payment.ValidateRFReference(ref); err != nil {
+			errs = append(errs, payment.NewValidationError("Remittance", "OriginatorsReferenceInformation", payment.RuleFormat, "ISO 11649",
+				fmt.Errorf("%w: %w", ErrValidationReference, err)))
+		}
+	}
+
+	return errs
+}
+
+// validateSymbols checks the variable, constant and specific symbols
+func (s *Payment) validateSymbols() payment.ValidationErrors {
+	var errs payment.ValidationErrors
+
+	for _, f := range []struct {
+		field string
+		value string
+		limit int
+	}{
+		{"VariableSymbol", s.VariableSymbol, maxSymbol},
+		{"ConstantSymbol", s.ConstantSymbol, maxConstantSymbol},
+		{"SpecificSymbol", s.SpecificSymbol, maxSymbol},
+	} {
+		if f.value == "" {
+			continue
+		}
+
+		if len(f.value) > f.limit || strings.ContainsFunc(f.value, func(r rune) bool { return r < '0' || r > '9' }) {
+			errs = append(errs, payment.NewValidationError(f.field, f.field, payment.RuleFormat, strconv.Itoa(f.limit),
+				fmt.Errorf("%w: %s: %q", ErrValidationSymbol, f.field, f.value)))
+		}
+	}
+
+	return errs
+}
+
+// validateStandingOrder checks the schedule of a standing order
+func (s *Payment) validateStandingOrder() payment.ValidationErrors {
+	o := s.StandingOrder
+	if o == nil {
+		return nil
+	}
+
+	var errs payment.ValidationErrors
+
+	if _, err := ParsePeriodicity(string(o.Periodicity)); err != nil {
+		errs = append(errs, payment.NewValidationError("StandingOrder.Periodicity", "Periodicity", payment.RuleValue, "d, w, b, m, B, q, s, a", err))
+	}
+
+	maxDay := 31
+	if o.Periodicity.IsWeekly() {
+		maxDay = 7
+	}
+
+	if o.Day < 0 || o.Day > maxDay {
+		errs = append(errs, payment.NewValidationError("StandingOrder.Day", "Day", payment.RuleRange, "1.."+strconv.Itoa(maxDay),
+			fmt.Errorf("%w: %d", ErrValidationDay, o.Day)))
+	}
+
+	for _, m := range o.Months {
+		if m < time.January || m > time.December {
+			errs = append(errs, payment.NewValidationError("StandingOrder.Months", "Month", payment.RuleRange, "1..12",
+				fmt.Errorf("%w: %d", ErrValidationMonth, m)))
+		}
+	}
+
+	if !o.LastDate.IsZero() && o.LastDate.Before(s.DueDate) {
+		errs = append(errs, payment.NewValidationError("StandingOrder.LastDate", "LastDate", payment.RuleRange, s.DueDate.Format(time.DateOnly),
+			ErrValidationLastDate))
+	}
+
+	return errs
+}
+
+// textRule returns the rule of a text field: at most limit printable characters; code is the name in the specification of the
+// field, and tooLong the error when it is longer
+func textRule(code string, limit int, tooLong error) payment.TextRule {
+	return payment.TextRule{
+		Code:          code,
+		MaxLength:     limit,
+		Charset:       "printable",
+		Allowed:       unicode.IsPrint,
+		ErrTooLong:    tooLong,
+		ErrCharacters: ErrValidationCharacters,
+	}
+}
@@ -0,0 +1,227 @@
+package paybysquare_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jovandeginste/payme/paybysquare"
+	"github.com/jovandeginste/payme/payment"
+	"github.com/jovandeginste/payme/qrcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const exampleIBAN = "SK3112000000198742637541"
+
+// examplePayment returns a payment with a due date and all symbols
+func examplePayment() *paybysquare.Payment {
+	s := paybysquare.New()
+	s.Payment.IBANBeneficiary = "SK31 1200 0000 1987 4263 7541"
+	s.Payment.BICBeneficiary = "TATRSKBX"
+	s.Payment.NameBeneficiary = "Ján Novák"
+	s.Payment.EuroAmount = 2530
+	s.Payment.Remittance = "Faktúra 2026-001"
+	s.VariableSymbol = "2026001"
+	s.ConstantSymbol = "0308"
+	s.SpecificSymbol = "42"
+	s.DueDate = time.Date(2026, time.October, 31, 0, 0, 0, 0, time.UTC)
+
+	return s
+}
+
+func TestToStringRoundTrip(t *testing.T) {
+	s, err := examplePayment().ToString()
+	require.NoError(t, err)
+	assert.Regexp(t, `^[0-9A-V]+$`, s)
+	assert.True(t, strings.HasPrefix(s, "0"), "the header starts with type 0 and version 1")
+
+	parsed, err := paybysquare.Parse(s)
+	require.NoError(t, err)
+	assert.Equal(t, paybysquare.PaymentOrder, parsed.Type())
+	assert.Equal(t, exampleIBAN, parsed.Payment.IBANBeneficiary)
+	assert.Equal(t, "TATRSKBX", parsed.Payment.BICBeneficiary)
+	assert.Equal(t, "Ján Novák", parsed.Payment.NameBeneficiary)
+	assert.Equal(t, payment.Amount(2530), parsed.Payment.EuroAmount)
+	assert.Equal(t, "EUR", parsed.Currency)
+	assert.Equal(t, "Faktúra 2026-001", parsed.Payment.Remittance)
+	assert.False(t, parsed.Payment.RemittanceIsStructured)
+	assert.Equal(t, "2026001", parsed.VariableSymbol)
+	assert.Equal(t, "0308", parsed.ConstantSymbol)
+	assert.Equal(t, "42", parsed.SpecificSymbol)
+	assert.Equal(t, "2026-10-31", parsed.DueDate.Format(time.DateOnly))
+	assert.Nil(t, parsed.StandingOrder)
+}
+
+func TestToStringStructured(t *testing.T) {
+	sp := examplePayment()
+	sp.Payment.RemittanceIsStructured = true
+	sp.Payment.Remittance = "RF18 5390 0754 7034"
+
+	s, err := sp.ToString()
+	require.NoError(t, err)
+
+	parsed, err := paybysquare.Parse(s)
+	require.NoError(t, err)
+	assert.True(t, parsed.Payment.RemittanceIsStructured)
+	assert.Equal(t, "RF18539007547034", parsed.Payment.Remittance)
+}
+
+func TestToStringOpenAmount(t *testing.T) {
+	sp := examplePayment()
+	sp.Payment.EuroAmount = 0
+	sp.Payment.BICBeneficiary = ""
+	sp.DueDate = time.Time{}
+
+	s, err := sp.ToString()
+	require.NoError(t, err)
+
+	parsed, err := paybysquare.Parse(s)
+	require.NoError(t, err)
+	assert.True(t, parsed.Payment.IsOpenAmount())
+	assert.Empty(t, parsed.Payment.BICBeneficiary)
+	assert.True(t, parsed.DueDate.IsZero())
+}
+
+func TestToStringStandingOrder(t *testing.T) {
+	sp := examplePayment()
+	sp.StandingOrder = &paybysquare.StandingOrder{
+		Periodicity: paybysquare.Monthly,
+		Day:         15,
+		Months:      []time.Month{time.January, time.April, time.December},
+		LastDate:    time.Date(2027, time.December, 31, 0, 0, 0, 0, time.UTC),
+	}
+
+	assert.Equal(t, paybysquare.StandingOrderPayment, sp.Type())
+
+	s, err := sp.ToString()
+	require.NoError(t, err)
+
+	parsed, err := paybysquare.Parse(s)
+	require.NoError(t, err)
+	assert.Equal(t, paybysquare.StandingOrderPayment, parsed.Type())
+	require.NotNil(t, parsed.StandingOrder)
+	assert.Equal(t, paybysquare.Monthly, parsed.StandingOrder.Periodicity)
+	assert.Equal(t, 15, parsed.StandingOrder.Day)
+	assert.Equal(t, []time.Month{time.January, time.April, time.December}, parsed.StandingOrder.Months)
+	assert.Equal(t, "2027-12-31", parsed.StandingOrder.LastDate.Format(time.DateOnly))
+	assert.Equal(t, "Ján Novák", parsed.Payment.NameBeneficiary, "the beneficiary follows the extensions")
+}
+
+func TestParsePeriodicity(t *testing.T) {
+	for s, expected := range map[string]paybysquare.Periodicity{
+		"monthly":   paybysquare.Monthly,
+		"Weekly":    paybysquare.Weekly,
+		"m":         paybysquare.Monthly,
+		"B":         paybysquare.Bimonthly,
+		"b":         paybysquare.Biweekly,
+		"bimonthly": paybysquare.Bimonthly,
+		"annually":  paybysquare.Annually,
+	} {
+		p, err := paybysquare.ParsePeriodicity(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, expected, p, s)
+	}
+
+	_, err := paybysquare.ParsePeriodicity("yearly")
+	require.ErrorIs(t, err, paybysquare.ErrValidationPeriodicity)
+
+	assert.True(t, paybysquare.Biweekly.IsWeekly())
+	assert.False(t, paybysquare.Bimonthly.IsWeekly())
+}
+
+func TestIsValid(t *testing.T) {
+	require.NoError(t, examplePayment().IsValid())
+
+	tests := map[string]struct {
+		change func(s *paybysquare.Payment)
+		err    error
+		code   string
+	}{
+		"IBAN":          {func(s *paybysquare.Payment) { s.Payment.IBANBeneficiary = "SK31 1200" }, paybysquare.ErrValidationAccount, "IBAN"},
+		"BIC":           {func(s *paybysquare.Payment) { s.Payment.BICBeneficiary = "TATR" }, paybysquare.ErrValidationBIC, "BIC"},
+		"amount":        {func(s *paybysquare.Payment) { s.Payment.EuroAmount = -1 }, paybysquare.ErrValidationAmount, "Amount"},
+		"currency":      {func(s *paybysquare.Payment) { s.Currency = "eur" }, paybysquare.ErrValidationCurrency, "CurrencyCode"},
+		"name required": {func(s *paybysquare.Payment) { s.Payment.NameBeneficiary = "" }, paybysquare.ErrValidationNameRequired, "BeneficiaryName"},
+		"name too long": {func(s *paybysquare.Payment) { s.Payment.NameBeneficiary = strings.Repeat("a", 71) }, paybysquare.ErrValidationNameTooLong, "BeneficiaryName"},
+		"note too long": {func(s *paybysquare.Payment) { s.Payment.Remittance = strings.Repeat("a", 141) }, paybysquare.ErrValidationNoteTooLong, "PaymentNote"},
+		"note with tab": {func(s *paybysquare.Payment) { s.Payment.Remittance = "a\tb" }, paybysquare.ErrValidationCharacters, "PaymentNote"},
+		"variable":      {func(s *paybysquare.Payment) { s.VariableSymbol = "12345678901" }, paybysquare.ErrValidationSymbol, "VariableSymbol"},
+		"constant":      {func(s *paybysquare.Payment) { s.ConstantSymbol = "03080" }, paybysquare.ErrValidationSymbol, "ConstantSymbol"},
+		"specific":      {func(s *paybysquare.Payment) { s.SpecificSymbol = "4a" }, paybysquare.ErrValidationSymbol, "SpecificSymbol"},
+		"reference long": {func(s *paybysquare.Payment) {
+			s.Payment.RemittanceIsStructured = true
+			s.Payment.Remittance = strings.Repeat("1", 36)
+		}, paybysquare.ErrValidationReferenceTooLong, "OriginatorsReferenceInformation"},
+		"reference RF": {func(s *paybysquare.Payment) {
+			s.Payment.RemittanceIsStructured = true
+			s.Payment.Remittance = "RF19 5390 0754 7034"
+		}, paybysquare.ErrValidationReference, "OriginatorsReferenceInformation"},
+		"periodicity": {func(s *paybysquare.Payment) { s.StandingOrder = &paybysquare.StandingOrder{Periodicity: "x"} }, paybysquare.ErrValidationPeriodicity, "Periodicity"},
+		"weekday": {func(s *paybysquare.Payment) {
+			s.StandingOrder = &paybysquare.StandingOrder{Periodicity: paybysquare.Weekly, Day: 8}
+		}, paybysquare.ErrValidationDay, "Day"},
+		"day": {func(s *paybysquare.Payment) {
+			s.StandingOrder = &paybysquare.StandingOrder{Periodicity: paybysquare.Monthly, Day: 32}
+		}, paybysquare.ErrValidationDay, "Day"},
+		"month": {func(s *paybysquare.Payment) {
+			s.StandingOrder = &paybysquare.StandingOrder{Periodicity: paybysquare.Monthly, Months: []time.Month{13}}
+		}, paybysquare.ErrValidationMonth, "Month"},
+		"last date": {func(s *paybysquare.Payment) {
+			s.StandingOrder = &paybysquare.StandingOrder{Periodicity: paybysquare.Monthly, LastDate: s.DueDate.AddDate(0, 0, -1)}
+		}, paybysquare.ErrValidationLastDate, "LastDate"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			sp := examplePayment()
+			tt.change(sp)
+
+			err := sp.IsValid()
+			require.ErrorIs(t, err, tt.err)
+
+			var errs payment.ValidationErrors
+			require.ErrorAs(t, err, &errs)
+			assert.Equal(t, tt.code, errs[0].Code)
+
+			_, err = sp.ToString()
+			require.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestQRCodeRoundTrip(t *testing.T) {
+	s := examplePayment()
+
+	result, err := qrcode.ToPNG(s, qrcode.DefaultOptions(), 300)
+	require.NoError(t, err)
+
+	decoded, err := payment.DecodeQRImage(bytes.NewReader(result))
+	require.NoError(t, err)
+
+	parsed, err := paybysquare.Parse(string(decoded))
+	require.NoError(t, err)
+	assert.Equal(t, s.VariableSymbol, parsed.VariableSymbol)
+	assert.Equal(t, s.Payment.EuroAmount, parsed.Payment.EuroAmount)
+}
+
+func TestQRCode(t *testing.T) {
+	s := examplePayment()
+
+	opts := qrcode.DefaultOptions()
+
+	code, err := s.QRCode(opts)
+	require.NoError(t, err)
+
+	opts.Mode = qrcode.ModeByte
+
+	byteCode, err := s.QRCode(opts)
+	require.NoError(t, err)
+	assert.Less(t, code.Version, byteCode.Version, "a base32hex code fits in alphanumeric mode")
+
+	_, err = paybysquare.New().QRCode(qrcode.DefaultOptions())
+
+	var errs payment.ValidationErrors
+	require.ErrorAs(t, err, &errs)
+}
@@ -0,0 +1,127 @@
+package payment
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Amount is an amount of money in cents, so it is stored and summed exactly
+type Amount int64
+
+const (
+	// MinAmount is the smallest amount of a credit transfer: 0.01
+	MinAmount Amount = 1
+	// MaxAmount is the largest amount of a credit transfer: 999999999.99
+	MaxAmount Amount = 99999999999
+)
+
+// Locale selects the decimal and thousands separators accepted by ParseAmount
+type Locale string
+
+const (
+	// LocaleNone accepts a decimal point and no thousands separators (1234.56)
+	LocaleNone Locale = ""
+	// LocalePoint accepts a decimal point and commas as thousands separators (1,234.56), eg. in English
+	LocalePoint Locale = "point"
+	// LocaleComma accepts a decimal comma and points or spaces as thousands separators (1.234,56),
+	// eg. in German, Dutch or French
+	LocaleComma Locale = "comma"
+)
+
+var (
+	amountParsers = map[Locale]*regexp.Regexp{
+		LocaleNone:  regexp.MustCompile(`^(\d+)(?:\.(\d*))?$`),
+		LocalePoint: regexp.MustCompile(`^(\d+|\d{1,3}(?:,\d{3})+)(?:\.(\d*))?$`),
+		LocaleComma: regexp.MustCompile(`^(\d+|\d{1,3}(?:\.\d{3})+|\d{1,3}(?: \d{3})+)(?:,(\d*))?$`),
+	}
+
+	// ErrAmountLocale is returned when the locale is unknown
+	ErrAmountLocale = errors.New("locale should be point or comma, or empty")
+	// ErrAmountFormat is returned when the amount is not a number in the format of the locale
+	ErrAmountFormat = errors.New("amount is not a number in the format of the locale")
+	// ErrAmountDecimals is returned when the amount has more than 2 decimals
+	ErrAmountDecimals = errors.New("amount should not have more than 2 decimals")
+)
+
+// ParseAmount parses an amount in euros, with at most 2 decimals, in the format of the locale
+// Negative amounts and amounts in exponent notation are rejected.
+func ParseAmount(s string, locale Locale) (Amount, error) {
+	parser, ok := amountParsers[locale]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrAmountLocale, locale)
+	}
+
+	m := parser.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, fmt.Errorf("%w: %q", ErrAmountFormat, s)
+	}
+
+	if len(m[2]) > 2 {
+		return 0, fmt.Errorf("%w: %q", ErrAmountDecimals, s)
+	}
+
+	euros := strings.NewReplacer(",", "", ".", "", " ", "").Replace(m[1])
+	cents := m[2] + strings.Repeat("0", 2-len(m[2]))
+
+	a, err := strconv.ParseInt(euros+cents, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrAmountFormat, s)
+	}
+
+	return Amount(a), nil
+}
+
+// MustParseAmount is like ParseAmount, without a locale, but panics if the amount can not be parsed
+func MustParseAmount(s string) Amount {
+	a, err := ParseAmount(s, LocaleNone)
+	if err != nil {
+		panic(err)
+	}
+
+	return a
+}
+
+// String returns the amount with a decimal point and 2 decimals, eg. 12.30
+func (a Amount) String() string {
+	sign := ""
+	if a < 0 {
+		sign, a = "-", -a
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, a/100, a%100)
+}
+
+// MarshalText implements encoding.TextMarshaler; it returns the amount as a decimal, eg. 12.30
+func (a Amount) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler; it parses an amount with a decimal point, eg. 12.30
+func (a *Amount) UnmarshalText(text []byte) error {
+	v, err := ParseAmount(string(text), LocaleNone)
+	if err != nil {
+		return err
+	}
+
+	*a = v
+
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler; it accepts the amount as a string ("12.30") or a number (12.3)
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		s = string(data)
+	}
+
+	return a.UnmarshalText([]byte(s))
+}
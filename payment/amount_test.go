@@ -0,0 +1,95 @@
+package payment_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		s        string
+		locale   payment.Locale
+		expected payment.Amount
+	}{
+		{"12", payment.LocaleNone, 1200},
+		{"12.3", payment.LocaleNone, 1230},
+		{"12.30", payment.LocaleNone, 1230},
+		{"0.01", payment.LocaleNone, 1},
+		{"12.", payment.LocaleNone, 1200},
+		{"999999999.99", payment.LocaleNone, payment.MaxAmount},
+		{"1234.56", payment.LocalePoint, 123456},
+		{"1,234.56", payment.LocalePoint, 123456},
+		{"1,234,567", payment.LocalePoint, 123456700},
+		{"12,30", payment.LocaleComma, 1230},
+		{"1.234,56", payment.LocaleComma, 123456},
+		{"1 234,56", payment.LocaleComma, 123456},
+		{"1234,5", payment.LocaleComma, 123450},
+		{" 12,30 ", payment.LocaleComma, 1230},
+	}
+
+	for _, tt := range tests {
+		a, err := payment.ParseAmount(tt.s, tt.locale)
+		require.NoError(t, err, tt.s)
+		assert.Equal(t, tt.expected, a, tt.s)
+	}
+}
+
+func TestParseAmountErrors(t *testing.T) {
+	tests := []struct {
+		s        string
+		locale   payment.Locale
+		expected error
+	}{
+		{"0.005", payment.LocaleNone, payment.ErrAmountDecimals},
+		{"12,345", payment.LocaleComma, payment.ErrAmountDecimals},
+		{"1,234.567", payment.LocalePoint, payment.ErrAmountDecimals},
+		{"12,30", payment.LocaleNone, payment.ErrAmountFormat},
+		{"12,30", payment.LocalePoint, payment.ErrAmountFormat},
+		{"1.234,56", payment.LocalePoint, payment.ErrAmountFormat},
+		{"1,234.56", payment.LocaleComma, payment.ErrAmountFormat},
+		{"12,34,56", payment.LocalePoint, payment.ErrAmountFormat},
+		{"-1", payment.LocaleNone, payment.ErrAmountFormat},
+		{"1e3", payment.LocaleNone, payment.ErrAmountFormat},
+		{"", payment.LocaleNone, payment.ErrAmountFormat},
+		{".5", payment.LocaleNone, payment.ErrAmountFormat},
+		{"99999999999999999999", payment.LocaleNone, payment.ErrAmountFormat},
+		{"12", "fr", payment.ErrAmountLocale},
+	}
+
+	for _, tt := range tests {
+		_, err := payment.ParseAmount(tt.s, tt.locale)
+		require.ErrorIs(t, err, tt.expected, tt.s)
+	}
+}
+
+func TestAmountString(t *testing.T) {
+	assert.Equal(t, "0.00", payment.Amount(0).String())
+	assert.Equal(t, "0.05", payment.Amount(5).String())
+	assert.Equal(t, "12.30", payment.Amount(1230).String())
+	assert.Equal(t, "-12.30", payment.Amount(-1230).String())
+	assert.Equal(t, "999999999.99", payment.MaxAmount.String())
+
+	// Split amounts add up exactly
+	assert.Equal(t, "0.30", (payment.MustParseAmount("0.10") + payment.MustParseAmount("0.20")).String())
+	assert.Panics(t, func() { payment.MustParseAmount("0.001") })
+}
+
+func TestAmountJSON(t *testing.T) {
+	b, err := json.Marshal(payment.Amount(1230))
+	require.NoError(t, err)
+	assert.JSONEq(t, `"12.30"`, string(b))
+
+	for _, s := range []string{`"12.30"`, `12.3`, `"12.3"`} {
+		var a payment.Amount
+		require.NoError(t, json.Unmarshal([]byte(s), &a), s)
+		assert.Equal(t, payment.Amount(1230), a, s)
+	}
+
+	var a payment.Amount
+	require.ErrorIs(t, json.Unmarshal([]byte(`12.345`), &a), payment.ErrAmountDecimals)
+	require.ErrorIs(t, json.Unmarshal([]byte(`"-1"`), &a), payment.ErrAmountFormat)
+}
@@ -0,0 +1,175 @@
+package payment
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/almerlucke/go-iban/iban"
+)
+
+var (
+	//go:embed banks.csv
+	banksCSV []byte
+
+	countryValidator  = regexp.MustCompile(`^[A-Z]{2}$`)
+	bankCodeValidator = regexp.MustCompile(`^[A-Z0-9]+$`)
+
+	// ErrBankDirectory is returned when a bank directory can not be read
+	ErrBankDirectory = errors.New("bank directory is not valid")
+	// ErrBankUnknown is returned when the bank of an IBAN is not in the bank directory
+	ErrBankUnknown = errors.New("bank is not in the bank directory")
+
+	defaultBankDirectory = sync.OnceValue(func() *BankDirectory {
+		d, err := ReadBankDirectory(bytes.NewReader(banksCSV))
+		if err != nil {
+			panic(err)
+		}
+
+		return d
+	})
+)
+
+// Bank is an entry of a bank directory: the BIC of a bank code, or a range of bank codes, of a country
+type Bank struct {
+	// Country is the country code of the IBAN, eg. BE
+	Country string
+	// FirstCode is the bank code, or the first of the range of bank codes
+	FirstCode string
+	// LastCode is the last of the range of bank codes; it is FirstCode for a single bank code
+	LastCode string
+	// BIC is the BIC of the bank
+	BIC string
+	// Name is the name of the bank
+	Name string
+}
+
+// matches returns whether the BBAN starts with a bank code of the bank
+func (b *Bank) matches(bban string) bool {
+	if len(bban) < len(b.FirstCode) {
+		return false
+	}
+
+	code := bban[:len(b.FirstCode)]
+
+	return code >= b.FirstCode && code <= b.LastCode
+}
+
+// BankDirectory holds the BIC of banks by their bank code, to derive the BIC from an IBAN
+type BankDirectory struct {
+	banks []Bank
+}
+
+// DefaultBankDirectory returns the bank directory that is embedded in payme, with the largest banks of
+// Belgium, the Netherlands, Germany, France, Austria and Luxembourg
+func DefaultBankDirectory() *BankDirectory {
+	return defaultBankDirectory()
+}
+
+// ReadBankDirectory reads a bank directory in CSV format, with the columns country, bank code, BIC and name;
+// the bank code may be a range, eg. 001-049. Lines starting with # and a header line are skipped.
+func ReadBankDirectory(r io.Reader) (*BankDirectory, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = 4
+	cr.TrimLeadingSpace = true
+
+	d := &BankDirectory{}
+
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return d, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrBankDirectory, err)
+		}
+
+		if len(d.banks) == 0 && record[0] == "country" {
+			continue
+		}
+
+		line, _ := cr.FieldPos(0)
+
+		b, err := parseBank(record)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrBankDirectory, line, err)
+		}
+
+		d.banks = append(d.banks, b)
+	}
+}
+
+// parseBank returns the bank of a record of a bank directory
+func parseBank(record []string) (Bank, error) {
+	b := Bank{Country: record[0], BIC: record[2], Name: record[3]}
+
+	if !countryValidator.MatchString(b.Country) {
+		return b, fmt.Errorf("country should be 2 letters: %q", b.Country)
+	}
+
+	first, last, ok := strings.Cut(record[1], "-")
+	if !ok {
+		last = first
+	}
+
+	if !bankCodeValidator.MatchString(first) || !bankCodeValidator.MatchString(last) || len(first) != len(last) || first > last {
+		return b, fmt.Errorf("bank code should be a code, or a range of codes of the same length: %q", record[1])
+	}
+
+	b.FirstCode, b.LastCode = first, last
+
+	if err := ValidateBIC(b.BIC); err != nil {
+		return b, err
+	}
+
+	if country := BICCountry(b.BIC); country != b.Country {
+		return b, fmt.Errorf("BIC %s is not of country %s", b.BIC, b.Country)
+	}
+
+	return b, nil
+}
+
+// Extend returns a bank directory with the banks of both directories, where the banks of other take precedence
+func (d *BankDirectory) Extend(other *BankDirectory) *BankDirectory {
+	banks := make([]Bank, 0, len(other.banks)+len(d.banks))
+	banks = append(banks, other.banks...)
+	banks = append(banks, d.banks...)
+
+	return &BankDirectory{banks: banks}
+}
+
+// Bank returns the bank of the IBAN, by the bank code at the start of the BBAN
+func (d *BankDirectory) Bank(s string) (*Bank, error) {
+	i, err := iban.NewIBAN(commonSeparatorChars.ReplaceAllString(s, ""))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range d.banks {
+		if b.Country == i.CountryCode && b.matches(i.BBAN) {
+			return &b, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrBankUnknown, i.PrintCode)
+}
+
+// SetBICFromIBAN sets the BIC of the beneficiary to the BIC of the bank of the IBAN in the directory
+func (p *Payment) SetBICFromIBAN(d *BankDirectory) error {
+	b, err := d.Bank(p.IBANBeneficiary)
+	if err != nil {
+		return err
+	}
+
+	p.BICBeneficiary = b.BIC
+
+	return nil
+}
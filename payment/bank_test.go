@@ -0,0 +1,86 @@
+package payment_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultBankDirectory(t *testing.T) {
+	d := payment.DefaultBankDirectory()
+
+	for iban, bic := range map[string]string{
+		"BE71 0961 2345 6769":         "GKCCBEBB",
+		"NL91ABNA0417164300":          "ABNANL2AXXX",
+		"DE89370400440532013000":      "COBADEFFXXX",
+		"FR1420041010050500013M02606": "PSSTFRPP",
+		"AT611904300234573201":        "",
+		"LU280019400644750000":        "BCEELULLXXX",
+	} {
+		b, err := d.Bank(iban)
+		if bic == "" {
+			require.ErrorIs(t, err, payment.ErrBankUnknown, iban)
+			continue
+		}
+
+		require.NoError(t, err, iban)
+		assert.Equal(t, bic, b.BIC, iban)
+		assert.NotEmpty(t, b.Name, iban)
+	}
+
+	_, err := d.Bank("BE00539007547034")
+	require.Error(t, err)
+}
+
+func TestBankDirectoryRange(t *testing.T) {
+	d, err := payment.ReadBankDirectory(strings.NewReader("country,bank code,bic,name\nBE,001-049,GEBABEBB,BNP Paribas Fortis\n"))
+	require.NoError(t, err)
+
+	for _, iban := range []string{"BE62510007547061", "BE68539007547034"} {
+		_, err := d.Bank(iban)
+		require.ErrorIs(t, err, payment.ErrBankUnknown, iban)
+	}
+
+	b, err := d.Bank("BE21001234567803")
+	require.NoError(t, err)
+	assert.Equal(t, "001", b.FirstCode)
+	assert.Equal(t, "049", b.LastCode)
+}
+
+func TestBankDirectoryExtend(t *testing.T) {
+	custom, err := payment.ReadBankDirectory(strings.NewReader("# Test\nNL,ABNA,ABNANL2AXXX,Test Bank\n"))
+	require.NoError(t, err)
+
+	b, err := payment.DefaultBankDirectory().Extend(custom).Bank("NL91ABNA0417164300")
+	require.NoError(t, err)
+	assert.Equal(t, "Test Bank", b.Name)
+
+	b, err = payment.DefaultBankDirectory().Bank("NL91ABNA0417164300")
+	require.NoError(t, err)
+	assert.Equal(t, "ABN AMRO", b.Name)
+}
+
+func TestReadBankDirectoryErrors(t *testing.T) {
+	for _, s := range []string{
+		"BE,001,GEBABEBB",
+		"Belgium,001,GEBABEBB,Bank",
+		"BE,049-001,GEBABEBB,Bank",
+		"BE,01-049,GEBABEBB,Bank",
+		"BE,001,XYZ,Bank",
+		"BE,001,COBADEFFXXX,Bank",
+	} {
+		_, err := payment.ReadBankDirectory(strings.NewReader(s))
+		require.ErrorIs(t, err, payment.ErrBankDirectory, s)
+	}
+}
+
+func TestSetBICFromIBAN(t *testing.T) {
+	p := payment.New()
+	p.IBANBeneficiary = "DE89 3704 0044 0532 0130 00"
+
+	require.NoError(t, p.SetBICFromIBAN(payment.DefaultBankDirectory()))
+	assert.Equal(t, "COBADEFFXXX", p.BICBeneficiary)
+}
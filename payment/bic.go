@@ -0,0 +1,100 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	// bicValidator matches an ISO 9362 BIC: 4 letters for the bank, 2 for the country, 2 letters or digits for
+	// the location, and optionally 3 letters or digits for the branch
+	bicValidator = regexp.MustCompile(`^[A-Z]{4}([A-Z]{2})[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+
+	// eeaCountries are the countries of the European Economic Area; the BIC is required for payments to
+	// beneficiaries in the other SEPA countries
+	eeaCountries = []string{
+		"AT", "BE", "BG", "CY", "CZ", "DE", "DK", "EE", "ES", "FI", "FR", "GR", "HR", "HU", "IE", "IS", "IT",
+		"LI", "LT", "LU", "LV", "MT", "NL", "NO", "PL", "PT", "RO", "SE", "SI", "SK",
+	}
+
+	// bicTerritories are the territories with a country code of their own, whose banks have IBANs of the
+	// country they belong to, eg. FR for a bank in Réunion (RE) and GB for one in Jersey (JE)
+	bicTerritories = map[string][]string{
+		"FI": {"AX"},
+		"FR": {"BL", "GF", "GP", "MF", "MQ", "NC", "PF", "PM", "RE", "WF", "YT"},
+		"GB": {"GG", "IM", "JE"},
+	}
+
+	// ErrBICFormat is returned when a BIC is not 8 or 11 letters and digits, as ISO 9362 defines it
+	ErrBICFormat = errors.New("BIC should be 8 or 11 characters: bank (4 letters), country (2 letters), location (2) and optionally branch (3)")
+
+	// ErrValidationBICFormat is returned when BICBeneficiary is not a valid BIC
+	ErrValidationBICFormat = errors.New("field 'BICBeneficiary' should be a valid BIC (ISO 9362)")
+	// ErrValidationBICCountry is returned when the country of BICBeneficiary is not the country of IBANBeneficiary,
+	// nor one of its territories
+	ErrValidationBICCountry = errors.New("field 'BICBeneficiary' should be of the same country as the IBAN, or one of its territories")
+)
+
+// ValidateBIC returns an error when the BIC does not have the ISO 9362 format, eg. GEBABEBB or GEBABEBBXXX
+func ValidateBIC(bic string) error {
+	if !bicValidator.MatchString(bic) {
+		return fmt.Errorf("%w: %q", ErrBICFormat, bic)
+	}
+
+	return nil
+}
+
+// BICCountry returns the country code of the BIC, or an empty string if the BIC is not valid
+func BICCountry(bic string) string {
+	m := bicValidator.FindStringSubmatch(bic)
+	if m == nil {
+		return ""
+	}
+
+	return m[1]
+}
+
+// RequiresBIC returns whether the QR code must hold the BIC: for version 1, and for beneficiaries
+// outside the European Economic Area
+func (p *Payment) RequiresBIC() bool {
+	if p.Version == 1 {
+		return true
+	}
+
+	i, err := p.IBAN()
+	if err != nil {
+		return false
+	}
+
+	return !slices.Contains(eeaCountries, i.CountryCode)
+}
+
+// validateBIC checks the format of the BIC, and that it is of the country of the IBAN or one of its territories
+func (p *Payment) validateBIC() error {
+	if p.BICBeneficiary == "" {
+		return nil
+	}
+
+	if err := ValidateBIC(p.BICBeneficiary); err != nil {
+		return ValidationErrors{{Field: "BICBeneficiary", Code: "AT-23", Rule: RuleFormat, Limit: "ISO 9362",
+			Err: fmt.Errorf("%w: %w", ErrValidationBICFormat, err)}}
+	}
+
+	i, err := p.IBAN()
+	if err != nil {
+		// The IBAN is reported by validateIBAN
+		return nil
+	}
+
+	countries := append([]string{i.CountryCode}, bicTerritories[i.CountryCode]...)
+
+	if country := BICCountry(p.BICBeneficiary); !slices.Contains(countries, country) {
+		return ValidationErrors{{Field: "BICBeneficiary", Code: "AT-23", Rule: RuleValue, Limit: strings.Join(countries, ", "),
+			Err: fmt.Errorf("%w: %s for an IBAN of %s", ErrValidationBICCountry, country, i.CountryCode)}}
+	}
+
+	return nil
+}
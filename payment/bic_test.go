@@ -0,0 +1,67 @@
+package payment_test
+
+import (
+	"testing"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateBIC(t *testing.T) {
+	for _, bic := range []string{"GEBABEBB", "GEBABEBBXXX", "BHBLDEHHXXX", "ABNANL2A", "NTSBDEB1XXX"} {
+		require.NoError(t, payment.ValidateBIC(bic), bic)
+	}
+
+	for _, bic := range []string{"", "XYZ", "GEBABEB", "GEBABEBBXX", "GEBABEBBXXXX", "gebabebb", "1EBABEBB", "GEBA1EBB"} {
+		require.ErrorIs(t, payment.ValidateBIC(bic), payment.ErrBICFormat, bic)
+	}
+}
+
+func TestBICCountry(t *testing.T) {
+	assert.Equal(t, "BE", payment.BICCountry("GEBABEBB"))
+	assert.Equal(t, "DE", payment.BICCountry("COBADEFFXXX"))
+	assert.Empty(t, payment.BICCountry("XYZ"))
+}
+
+func TestRequiresBIC(t *testing.T) {
+	p := payment.New()
+	p.IBANBeneficiary = "NL91ABNA0417164300"
+	assert.False(t, p.RequiresBIC())
+
+	p.Version = 1
+	assert.True(t, p.RequiresBIC())
+
+	p.Version = 2
+	p.IBANBeneficiary = "CH9300762011623852957"
+	assert.True(t, p.RequiresBIC(), "Switzerland is not in the EEA")
+}
+
+func TestBICValidation(t *testing.T) {
+	p := payment.New()
+	p.NameBeneficiary = "Jane Doe"
+	p.IBANBeneficiary = "BE68539007547034"
+	p.EuroAmount = 100
+	p.Remittance = "Invoice 1234"
+
+	p.BICBeneficiary = "GEBABEBB"
+	require.NoError(t, p.IsValid())
+
+	p.BICBeneficiary = "XYZ"
+	require.ErrorIs(t, p.IsValid(), payment.ErrValidationBICFormat)
+	require.ErrorIs(t, p.IsValid(), payment.ErrBICFormat)
+
+	p.BICBeneficiary = "COBADEFFXXX"
+	require.ErrorIs(t, p.IsValid(), payment.ErrValidationBICCountry)
+
+	// Banks in overseas territories and Crown Dependencies have IBANs of France and the United Kingdom
+	p.IBANBeneficiary = "FR1420041010050500013M02606"
+	p.BICBeneficiary = "BNPARERXXXX"
+	require.NoError(t, p.IsValid())
+
+	p.BICBeneficiary = "RBOSJESH"
+	require.ErrorIs(t, p.IsValid(), payment.ErrValidationBICCountry)
+
+	p.IBANBeneficiary = "GB29NWBK60161331926819"
+	require.NoError(t, p.IsValid())
+}
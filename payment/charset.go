@@ -0,0 +1,248 @@
+package payment
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/unicode/norm"
+)
+
+// CharacterSetUTF8 is the character set that can represent every character
+const CharacterSetUTF8 = 1
+
+var (
+	// characterSets are the single-byte encodings of character sets 2..8
+	characterSets = map[int]*charmap.Charmap{
+		2: charmap.ISO8859_1,
+		3: charmap.ISO8859_2,
+		4: charmap.ISO8859_4,
+		5: charmap.ISO8859_5,
+		6: charmap.ISO8859_7,
+		7: charmap.ISO8859_10,
+		8: charmap.ISO8859_15,
+	}
+
+	characterSetNames = map[int]string{
+		1: "UTF-8",
+		2: "ISO 8859-1",
+		3: "ISO 8859-2",
+		4: "ISO 8859-4",
+		5: "ISO 8859-5",
+		6: "ISO 8859-7",
+		7: "ISO 8859-10",
+		8: "ISO 8859-15",
+	}
+
+	// transliterations replace characters that do not decompose into a base letter and accents
+	transliterations = map[rune]string{
+		'ß': "ss", 'Æ': "AE", 'æ': "ae", 'Œ': "OE", 'œ': "oe", 'Ø': "O", 'ø': "o",
+		'Ł': "L", 'ł': "l", 'Đ': "D", 'đ': "d", 'Þ': "Th", 'þ': "th", 'Ð': "D", 'ð': "d",
+		'‘': "'", '’': "'", '‚': "'", '“': `"`, '”': `"`, '„': `"`, '–': "-", '—': "-", '€': "EUR",
+	}
+
+	// ErrValidationCharacterSetUnsupported is returned when a field contains characters that can not be encoded in CharacterSet
+	ErrValidationCharacterSetUnsupported = errors.New("characters can not be encoded in the character set")
+)
+
+// CharacterSetName returns the name of the character set, eg. ISO 8859-1
+func (p *Payment) CharacterSetName() string {
+	return characterSetNames[p.CharacterSet]
+}
+
+// ToBytes returns the content of the QR code, encoded in the character set of the payment
+// This is what is stored in the QR code; ToString returns the same content as a Go (UTF-8) string.
+func (p *Payment) ToBytes() ([]byte, error) {
+	s, err := p.ToString()
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeCharacterSet(s, p.CharacterSet, 0)
+}
+
+// DetectCharacterSet returns the first ISO 8859 character set (2..8) that can represent
+// every field of the payment, or UTF-8 (1) if none can
+func (p *Payment) DetectCharacterSet() int {
+	for cs := 2; cs <= 8; cs++ {
+		if len(p.unsupportedCharacters(cs)) == 0 {
+			return cs
+		}
+	}
+
+	return CharacterSetUTF8
+}
+
+// Transliterate replaces the characters that can not be encoded in the character set of the payment:
+// accents are removed where possible (é becomes e), some letters are spelled out (ß becomes ss),
+// and anything else is replaced by a question mark
+func (p *Payment) Transliterate() {
+	cm := characterSets[p.CharacterSet]
+	if cm == nil {
+		return
+	}
+
+	for _, f := range p.textFields() {
+		*f.value = transliterate(*f.value, cm)
+	}
+}
+
+// textField is a field that may contain characters outside of ASCII
+type textField struct {
+	name  string
+	code  string
+	value *string
+}
+
+func (p *Payment) textFields() []textField {
+	return []textField{
+		{"NameBeneficiary", "AT-21", &p.NameBeneficiary},
+		{"Purpose", "AT-44", &p.Purpose},
+		{"Remittance", "AT-05", &p.Remittance},
+		{"B2OInformation", "", &p.B2OInformation},
+	}
+}
+
+// unsupportedCharacters returns, per field name, the characters that can not be encoded in the character set
+func (p *Payment) unsupportedCharacters(cs int) map[string]string {
+	cm := characterSets[cs]
+	if cm == nil {
+		return nil
+	}
+
+	unsupported := map[string]string{}
+
+	for _, f := range p.textFields() {
+		var b strings.Builder
+
+		for _, r := range *f.value {
+			if _, ok := cm.EncodeRune(r); !ok && !strings.ContainsRune(b.String(), r) {
+				b.WriteRune(r)
+			}
+		}
+
+		if b.Len() > 0 {
+			unsupported[f.name] = b.String()
+		}
+	}
+
+	return unsupported
+}
+
+func (p *Payment) validateCharacterSet() error {
+	var errs ValidationErrors
+
+	unsupported := p.unsupportedCharacters(p.CharacterSet)
+
+	for _, f := range p.textFields() {
+		if chars, ok := unsupported[f.name]; ok {
+			errs.add(f.name, f.code, RuleCharacters, p.CharacterSetName(),
+				fmt.Errorf("field '%s': %w %s: %q", f.name, ErrValidationCharacterSetUnsupported, p.CharacterSetName(), chars))
+		}
+	}
+
+	return errs.err()
+}
+
+// encodeCharacterSet encodes the string in the character set; characters that the character set can not
+// encode are replaced by the fallback, or are an error when the fallback is 0
+func encodeCharacterSet(s string, cs int, fallback byte) ([]byte, error) {
+	cm := characterSets[cs]
+	if cm == nil {
+		return []byte(s), nil
+	}
+
+	b := make([]byte, 0, len(s))
+
+	for _, r := range s {
+		c, ok := cm.EncodeRune(r)
+		if !ok {
+			if fallback == 0 {
+				return nil, fmt.Errorf("%w %s: %q", ErrValidationCharacterSetUnsupported, characterSetNames[cs], r)
+			}
+
+			c = fallback
+		}
+
+		b = append(b, c)
+	}
+
+	return b, nil
+}
+
+// decodeCharacterSet decodes the bytes from the character set
+func decodeCharacterSet(b []byte, cs int) string {
+	cm := characterSets[cs]
+	if cm == nil {
+		return string(b)
+	}
+
+	var s strings.Builder
+
+	for _, c := range b {
+		s.WriteRune(cm.DecodeByte(c))
+	}
+
+	return s.String()
+}
+
+// payloadCharacterSet returns the character set declared on the third line of the payload, or 0 if there is none
+func payloadCharacterSet(b []byte) int {
+	lines := bytes.SplitN(b, []byte("\n"), 4)
+	if len(lines) < 3 {
+		return 0
+	}
+
+	cs, _ := strconv.Atoi(string(bytes.TrimSuffix(lines[2], []byte("\r"))))
+
+	return cs
+}
+
+// transliterate replaces the characters in s that can not be encoded in the character set
+func transliterate(s string, cm *charmap.Charmap) string {
+	var b strings.Builder
+
+	for _, r := range s {
+		if _, ok := cm.EncodeRune(r); ok {
+			b.WriteRune(r)
+			continue
+		}
+
+		if t, ok := transliterations[r]; ok {
+			b.WriteString(t)
+			continue
+		}
+
+		b.WriteString(stripAccents(r, cm))
+	}
+
+	return b.String()
+}
+
+// stripAccents returns the character without its accents, or a question mark
+// if that can not be encoded in the character set either
+func stripAccents(r rune, cm *charmap.Charmap) string {
+	var b strings.Builder
+
+	for _, d := range norm.NFD.String(string(r)) {
+		if unicode.Is(unicode.Mn, d) {
+			continue
+		}
+
+		if _, ok := cm.EncodeRune(d); !ok {
+			return "?"
+		}
+
+		b.WriteRune(d)
+	}
+
+	if b.Len() == 0 {
+		return "?"
+	}
+
+	return b.String()
+}
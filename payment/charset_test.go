@@ -0,0 +1,123 @@
+package payment_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToBytes(t *testing.T) {
+	p := payment.New()
+	p.NameBeneficiary = "Franz Mustermänn"
+	p.IBANBeneficiary = "DE71110220330123456789"
+	p.EuroAmount = 1230
+	p.Remittance = "Grüße"
+
+	b, err := p.ToBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "ISO 8859-1", p.CharacterSetName())
+	assert.True(t, bytes.Contains(b, []byte("Musterm\xe4nn\n")))
+	assert.True(t, bytes.HasSuffix(b, []byte("\nGr\xfc\xdfe")))
+
+	p.CharacterSet = payment.CharacterSetUTF8
+
+	b, err = p.ToBytes()
+	require.NoError(t, err)
+	assert.True(t, bytes.Contains(b, []byte("Mustermänn\n")))
+}
+
+func TestCharacterSetValidation(t *testing.T) {
+	p := payment.New()
+	p.NameBeneficiary = "Лев Николаевич Толстой"
+	p.IBANBeneficiary = "DE71110220330123456789"
+	p.EuroAmount = 1230
+	p.Remittance = "Łódź"
+
+	err := p.IsValid()
+	require.ErrorIs(t, err, payment.ErrValidationCharacterSetUnsupported)
+
+	var errs payment.ValidationErrors
+
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, errs, 2)
+	assert.Equal(t, "NameBeneficiary", errs[0].Field)
+	assert.Equal(t, "Remittance", errs[1].Field)
+	assert.Equal(t, "ISO 8859-1", errs[1].Limit)
+	assert.Equal(t, `AT-05: field 'Remittance': characters can not be encoded in the character set ISO 8859-1: "Łź"`, errs[1].Error())
+
+	_, err = p.ToBytes()
+	require.Error(t, err)
+
+	p.CharacterSet = payment.CharacterSetUTF8
+	require.NoError(t, p.IsValid())
+}
+
+func TestDetectCharacterSet(t *testing.T) {
+	for name, expected := range map[string]int{
+		"Franz Mustermann":       2,
+		"Franz Mustermänn":       2,
+		"Łódź Spółka":            3,
+		"Lev Nikolaevič Tolstoj": 3,
+		"Лев Николаевич Толстой": 5,
+		"Ελληνικά":               6,
+		"Łódź Лев":               1,
+		"老子":                     1,
+	} {
+		p := payment.New()
+		p.NameBeneficiary = name
+		p.Remittance = "Invoice 1234"
+
+		assert.Equal(t, expected, p.DetectCharacterSet(), name)
+	}
+}
+
+func TestTransliterate(t *testing.T) {
+	p := payment.New()
+	p.NameBeneficiary = "Łódź Straße Œuvre"
+	p.Remittance = "Лев – “quoted”"
+
+	p.Transliterate()
+
+	assert.Equal(t, "Lódz Straße OEuvre", p.NameBeneficiary)
+	assert.Equal(t, `??? - "quoted"`, p.Remittance)
+
+	p = payment.New()
+	p.CharacterSet = 3
+	p.NameBeneficiary = "Łódź Straße Œuvre"
+
+	p.Transliterate()
+
+	assert.Equal(t, "Łódź Straße OEuvre", p.NameBeneficiary)
+
+	p = payment.New()
+	p.CharacterSet = payment.CharacterSetUTF8
+	p.NameBeneficiary = "老子"
+
+	p.Transliterate()
+
+	assert.Equal(t, "老子", p.NameBeneficiary)
+}
+
+func TestParseBytes(t *testing.T) {
+	p := payment.New()
+	p.CharacterSet = 3
+	p.NameBeneficiary = "Łódź Spółka"
+	p.IBANBeneficiary = "PL61109010140000071219812874"
+	p.EuroAmount = 1230
+	p.Remittance = "Faktura 1234"
+
+	b, err := p.ToBytes()
+	require.NoError(t, err)
+
+	decoded, err := payment.ParseBytes(b)
+	require.NoError(t, err)
+	assert.Equal(t, "Łódź Spółka", decoded.NameBeneficiary)
+
+	// UTF-8 payloads are left as they are
+	decoded, err = payment.ParseBytes([]byte("BCD\n002\n1\nSCT\n\nFranz Mustermänn\nDE71110220330123456789\nEUR1\n\n\nTest"))
+	require.NoError(t, err)
+	assert.Equal(t, "Franz Mustermänn", decoded.NameBeneficiary)
+}
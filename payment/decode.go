@@ -0,0 +1,72 @@
+package payment
+
+import (
+	"errors"
+	"image"
+	_ "image/jpeg" // register the JPEG decoder for FromQRImage
+	"io"
+	"unicode/utf8"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+)
+
+// ErrNoQRCode is returned when no QR code could be found in an image
+var ErrNoQRCode = errors.New("no QR code found in image")
+
+// FromQRImage finds and decodes the QR code in a PNG or JPEG image, and parses its content into a Payment.
+// This is the inverse of ToQRPNG; use IsValid to check the content of the result.
+func FromQRImage(r io.Reader) (*Payment, error) {
+	data, err := DecodeQRImage(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseBytes(data)
+}
+
+// DecodeQRImage finds the QR code in a PNG or JPEG image, and returns the raw content
+func DecodeQRImage(r io.Reader) ([]byte, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	bmp, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return nil, err
+	}
+
+	hints := map[gozxing.DecodeHintType]any{
+		gozxing.DecodeHintType_TRY_HARDER: true,
+	}
+
+	result, err := qrcode.NewQRCodeReader().Decode(bmp, hints)
+	if err != nil {
+		return nil, errors.Join(ErrNoQRCode, err)
+	}
+
+	// Prefer the raw bytes of a symbol that is a single byte segment, so the content is not mangled
+	// by guessing its character set
+	if segments, ok := result.GetResultMetadata()[gozxing.ResultMetadataType_BYTE_SEGMENTS].([][]byte); ok && len(segments) == 1 && onlySegment(segments[0], result.GetText()) {
+		return segments[0], nil
+	}
+
+	// A mixed mode symbol is only available as text, so re-encode it in the declared character set
+	text := result.GetText()
+	if b, err := encodeCharacterSet(text, payloadCharacterSet([]byte(text)), 0); err == nil {
+		return b, nil
+	}
+
+	return []byte(text), nil
+}
+
+// onlySegment returns whether the decoded text holds nothing but the byte segment, which the
+// decoder has converted from UTF-8 or a single byte character set
+func onlySegment(segment []byte, text string) bool {
+	if utf8.Valid(segment) {
+		return string(segment) == text
+	}
+
+	return utf8.RuneCountInString(text) == len(segment)
+}
@@ -0,0 +1,143 @@
+package payment_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"testing"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"rsc.io/qr/coding"
+)
+
+func TestFromQRImage(t *testing.T) {
+	f, err := os.Open("tests/test1.png")
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	p, err := payment.FromQRImage(f)
+	require.NoError(t, err)
+	require.NoError(t, p.IsValid())
+
+	assert.Equal(t, ExampleName, p.NameBeneficiary)
+	assert.Equal(t, ExampleRemittance, p.Remittance)
+	assert.Equal(t, "EUR12.30", p.EuroAmountString())
+}
+
+func TestFromQRImageRoundTrip(t *testing.T) {
+	p := payment.NewStructured()
+
+	p.NameBeneficiary = "Franz Mustermänn"
+	p.IBANBeneficiary = "DE71110220330123456789"
+	p.EuroAmount = 9999
+	p.Remittance = "RF18539007547034"
+
+	b, err := p.ToQRPNG(QRSize)
+	require.NoError(t, err)
+
+	// Re-encode as JPEG, to check lossy input as well
+	img, err := png.Decode(bytes.NewReader(b))
+	require.NoError(t, err)
+
+	var j bytes.Buffer
+	require.NoError(t, jpeg.Encode(&j, img, nil))
+
+	result, err := payment.FromQRImage(&j)
+	require.NoError(t, err)
+
+	expected, err := p.ToString()
+	require.NoError(t, err)
+
+	actual, err := result.ToString()
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+func TestFromQRImageOpenAmount(t *testing.T) {
+	p := payment.New()
+
+	p.NameBeneficiary = "Stichting Het Goede Doel"
+	p.IBANBeneficiary = "NL91ABNA0417164300"
+	p.Remittance = "Donation"
+
+	b, err := p.ToQRPNG(QRSize)
+	require.NoError(t, err)
+
+	result, err := payment.FromQRImage(bytes.NewReader(b))
+	require.NoError(t, err)
+	require.NoError(t, result.IsValid())
+
+	assert.True(t, result.IsOpenAmount())
+	assert.Equal(t, "Donation", result.Remittance)
+}
+
+// mixedModePNG returns a QR code whose data is split over an alphanumeric and a byte segment
+func mixedModePNG(t *testing.T, alpha string, data []byte) []byte {
+	t.Helper()
+
+	plan, err := coding.NewPlan(10, coding.M, 0)
+	require.NoError(t, err)
+
+	code, err := plan.Encode(coding.Alpha(alpha), coding.String(data))
+	require.NoError(t, err)
+
+	const scale, quiet = 8, 4
+
+	img := image.NewGray(image.Rect(0, 0, (code.Size+2*quiet)*scale, (code.Size+2*quiet)*scale))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+
+	for y := range code.Size {
+		for x := range code.Size {
+			if !code.Black(x, y) {
+				continue
+			}
+
+			for i := range scale * scale {
+				img.SetGray((x+quiet)*scale+i%scale, (y+quiet)*scale+i/scale, color.Gray{})
+			}
+		}
+	}
+
+	var b bytes.Buffer
+	require.NoError(t, png.Encode(&b, img))
+
+	return b.Bytes()
+}
+
+func TestDecodeQRImageMixedMode(t *testing.T) {
+	p := payment.New()
+
+	p.CharacterSet = 2
+	p.NameBeneficiary = "Franz Mustermänn"
+	p.IBANBeneficiary = "DE71110220330123456789"
+	p.EuroAmount = 1230
+	p.Remittance = "Invoice 1"
+
+	b, err := p.ToBytes()
+	require.NoError(t, err)
+
+	data, err := payment.DecodeQRImage(bytes.NewReader(mixedModePNG(t, "BCD", b[3:])))
+	require.NoError(t, err)
+	assert.Equal(t, b, data, "the alphanumeric segment is kept, and the text is re-encoded in ISO 8859-2")
+}
+
+func TestFromQRImageErrors(t *testing.T) {
+	_, err := payment.FromQRImage(bytes.NewReader([]byte("not an image")))
+	require.Error(t, err)
+
+	f, err := os.Open("tests/test1.qr")
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	_, err = payment.FromQRImage(f)
+	require.Error(t, err)
+}
@@ -0,0 +1,101 @@
+package payment
+
+import (
+	_ "embed" // for the JSON Schema
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// FileFormat is the format of a document with the fields of a payment
+type FileFormat string
+
+const (
+	// FileFormatJSON is a JSON object
+	FileFormatJSON FileFormat = "json"
+	// FileFormatYAML is a YAML mapping; as YAML is a superset of JSON, it reads JSON objects as well
+	FileFormatYAML FileFormat = "yaml"
+	// FileFormatTOML is a TOML document
+	FileFormatTOML FileFormat = "toml"
+)
+
+// JSONSchema is the JSON Schema of a payment document, with the fields and their limits
+//
+//go:embed schema.json
+var JSONSchema []byte
+
+var (
+	// ErrFileFormat is returned when the format of a payment document is not json, yaml or toml
+	ErrFileFormat = errors.New("payment file format should be json, yaml or toml")
+	// ErrFileFormatExtension is returned when the format of a payment document can not be derived from its name
+	ErrFileFormatExtension = errors.New("payment file name should end in .json, .yaml, .yml or .toml")
+)
+
+// FileFormatFor returns the format of the payment document, based on the extension of its name
+func FileFormatFor(path string) (FileFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FileFormatJSON, nil
+	case ".yaml", ".yml":
+		return FileFormatYAML, nil
+	case ".toml":
+		return FileFormatTOML, nil
+	}
+
+	return "", fmt.Errorf("%w: %q", ErrFileFormatExtension, path)
+}
+
+// UnmarshalFile sets the fields of the payment from a JSON, YAML or TOML document, with the field names of
+// JSONSchema; fields that are missing keep their value, and unknown fields are an error.
+// It returns the names of the fields in the document, sorted.
+func (p *Payment) UnmarshalFile(data []byte, format FileFormat) ([]string, error) {
+	fields := map[string]any{}
+
+	var err error
+
+	switch format {
+	case FileFormatJSON:
+		err = json.Unmarshal(data, &fields)
+	case FileFormatYAML:
+		err = yaml.Unmarshal(data, &fields)
+	case FileFormatTOML:
+		err = toml.Unmarshal(data, &fields)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrFileFormat, format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Every format is read through JSON, so the fields are checked and converted the same way
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.UnmarshalJSON(b); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	return keys, nil
+}
+
+// MarshalYAML implements yaml.Marshaler; it returns the fields of the payment as a mapping,
+// instead of the content of the QR code
+func (p *Payment) MarshalYAML() (any, error) {
+	return (*paymentFields)(p), nil
+}
@@ -0,0 +1,168 @@
+package payment_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestFileFormatFor(t *testing.T) {
+	tests := map[string]payment.FileFormat{
+		"payment.json":      payment.FileFormatJSON,
+		"payment.yaml":      payment.FileFormatYAML,
+		"dir/payment.YML":   payment.FileFormatYAML,
+		"payment.toml":      payment.FileFormatTOML,
+		"/tmp/a.b/pay.toml": payment.FileFormatTOML,
+	}
+
+	for path, expected := range tests {
+		f, err := payment.FileFormatFor(path)
+		require.NoError(t, err, path)
+		assert.Equal(t, expected, f, path)
+	}
+
+	_, err := payment.FileFormatFor("payment.txt")
+	require.ErrorIs(t, err, payment.ErrFileFormatExtension)
+}
+
+func TestUnmarshalFile(t *testing.T) {
+	docs := map[payment.FileFormat]string{
+		payment.FileFormatJSON: `{"name": "Franz Mustermänn", "iban": "DE71110220330123456789", "amount": 12.3,
+			"remittance": "RF18539007547034", "structured": true, "b2o_information": "Thank you"}`,
+		payment.FileFormatYAML: `
+name: Franz Mustermänn
+iban: DE71110220330123456789
+amount: 12.30
+remittance: RF18539007547034
+structured: true
+b2o_information: Thank you
+`,
+		payment.FileFormatTOML: `
+name = "Franz Mustermänn"
+iban = "DE71110220330123456789"
+amount = "12.30"
+remittance = "RF18539007547034"
+structured = true
+b2o_information = "Thank you"
+`,
+	}
+
+	for format, doc := range docs {
+		p := payment.New()
+
+		keys, err := p.UnmarshalFile([]byte(doc), format)
+		require.NoError(t, err, format)
+
+		assert.Equal(t, []string{"amount", "b2o_information", "iban", "name", "remittance", "structured"}, keys, format)
+		assert.Equal(t, "Franz Mustermänn", p.NameBeneficiary, format)
+		assert.Equal(t, payment.Amount(1230), p.EuroAmount, format)
+		assert.True(t, p.RemittanceIsStructured, format)
+		assert.Equal(t, "Thank you", p.B2OInformation, format)
+		assert.Equal(t, 2, p.Version, format)
+		require.NoError(t, p.IsValid(), format)
+	}
+}
+
+func TestUnmarshalFileErrors(t *testing.T) {
+	p := payment.New()
+
+	_, err := p.UnmarshalFile([]byte(`name: Jane`), "xml")
+	require.ErrorIs(t, err, payment.ErrFileFormat)
+
+	_, err = p.UnmarshalFile([]byte(`nmae: Jane`), payment.FileFormatYAML)
+	require.Error(t, err)
+
+	_, err = p.UnmarshalFile([]byte(`amount = 12.345`), payment.FileFormatTOML)
+	require.ErrorIs(t, err, payment.ErrAmountDecimals)
+
+	_, err = p.UnmarshalFile([]byte(`{"name": `), payment.FileFormatJSON)
+	require.Error(t, err)
+}
+
+func TestPaymentYAML(t *testing.T) {
+	p := payment.New()
+	p.NameBeneficiary = "Jane Doe"
+	p.IBANBeneficiary = "BE68539007547034"
+	p.EuroAmount = 500
+	p.Remittance = "Invoice 1234"
+
+	b, err := yaml.Marshal(p)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "amount: \"5.00\"\n")
+
+	r := payment.New()
+
+	_, err = r.UnmarshalFile(b, payment.FileFormatYAML)
+	require.NoError(t, err)
+	assert.Equal(t, p, r)
+}
+
+// TestJSONSchema checks that the schema describes every serialised field of Payment, and nothing else
+func TestJSONSchema(t *testing.T) {
+	var schema struct {
+		Properties map[string]any `json:"properties"`
+	}
+
+	require.NoError(t, json.Unmarshal(payment.JSONSchema, &schema))
+
+	var fields []string
+
+	typ := reflect.TypeFor[payment.Payment]()
+	for i := range typ.NumField() {
+		name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		if name != "-" {
+			fields = append(fields, name)
+		}
+	}
+
+	assert.Len(t, schema.Properties, len(fields))
+
+	for _, name := range fields {
+		assert.Contains(t, schema.Properties, name)
+	}
+}
+
+// TestJSONSchemaPatterns checks that the patterns of the text fields, which are ASCII-only for every regex dialect,
+// accept and refuse the same characters as IsValid
+func TestJSONSchemaPatterns(t *testing.T) {
+	var schema struct {
+		Properties map[string]struct {
+			Pattern string `json:"pattern"`
+		} `json:"properties"`
+	}
+
+	require.NoError(t, json.Unmarshal(payment.JSONSchema, &schema))
+
+	for _, name := range []string{"name", "remittance", "b2o_information"} {
+		pattern := schema.Properties[name].Pattern
+		require.NotEmpty(t, pattern, name)
+		assert.NotContains(t, pattern, `\p`, name)
+
+		re := regexp.MustCompile(pattern)
+
+		for _, s := range []string{"Franz Mustermänn", `Jean-Luc (O'Neil) @ "1/2" + 3?`, "RF18539007547034"} {
+			assert.True(t, re.MatchString(s), "%s: %q", name, s)
+		}
+
+		for _, s := range []string{"a_b", "a;b", `a\b`, "a#b", "a\tb"} {
+			assert.False(t, re.MatchString(s), "%s: %q", name, s)
+
+			p := payment.New()
+			p.NameBeneficiary = s
+
+			var errs payment.ValidationErrors
+			require.ErrorAs(t, p.IsValid(), &errs)
+			assert.True(t, slices.ContainsFunc(errs, func(e *payment.ValidationError) bool {
+				return e.Field == "NameBeneficiary" && e.Rule == payment.RuleCharacters
+			}), s)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package payment
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// paymentFields has the fields of Payment, without its MarshalText and UnmarshalText methods,
+// so it is (un)marshalled as an object instead of as the content of the QR code
+type paymentFields Payment
+
+// MarshalJSON implements json.Marshaler; it returns the fields of the payment as an object
+func (p *Payment) MarshalJSON() ([]byte, error) {
+	return json.Marshal((*paymentFields)(p))
+}
+
+// UnmarshalJSON implements json.Unmarshaler; it sets the fields of the payment from an object
+// Fields that are missing from the object keep their value, so unmarshal into New() to get the defaults;
+// unknown fields are an error, so typos do not go unnoticed.
+func (p *Payment) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	return dec.Decode((*paymentFields)(p))
+}
@@ -0,0 +1,52 @@
+package payment_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentJSON(t *testing.T) {
+	p := payment.New()
+	p.NameBeneficiary = "François D'Alsace S.A."
+	p.IBANBeneficiary = "BE68539007547034"
+	p.EuroAmount = 1230
+	p.Remittance = "RF18539007547034"
+	p.RemittanceIsStructured = true
+
+	b, err := json.Marshal(p)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"service_tag": "BCD",
+		"version": 2,
+		"character_set": 2,
+		"identification_code": "SCT",
+		"name": "François D'Alsace S.A.",
+		"iban": "BE68539007547034",
+		"amount": "12.30",
+		"remittance": "RF18539007547034",
+		"structured": true
+	}`, string(b))
+
+	r := payment.New()
+	require.NoError(t, json.Unmarshal(b, r))
+	assert.Equal(t, p, r)
+}
+
+func TestPaymentJSONDefaults(t *testing.T) {
+	p := payment.New()
+	require.NoError(t, json.Unmarshal([]byte(`{"name": "Jane Doe", "iban": "BE68539007547034", "amount": 5}`), p))
+
+	assert.Equal(t, "BCD", p.ServiceTag)
+	assert.Equal(t, 2, p.Version)
+	assert.Equal(t, "Jane Doe", p.NameBeneficiary)
+	assert.Equal(t, payment.Amount(500), p.EuroAmount)
+}
+
+func TestPaymentJSONUnknownField(t *testing.T) {
+	p := payment.New()
+	require.Error(t, json.Unmarshal([]byte(`{"name": "Jane Doe", "ibn": "BE68539007547034"}`), p))
+}
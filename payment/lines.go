@@ -0,0 +1,72 @@
+package payment
+
+import "unicode/utf8"
+
+// MaxPayloadBytes is the largest payload of an EPC QR code, in bytes
+const MaxPayloadBytes = 331
+
+// Line is one line of the payload, with the attribute it holds
+type Line struct {
+	// Number is the (1-based) line number in the payload
+	Number int
+	// Field is the name of the Payment field that is stored on this line
+	Field string
+	// Code is the EPC attribute code, eg. AT-20; it is empty for the header lines
+	Code string
+	// Meaning describes the attribute
+	Meaning string
+	// Value is the content of the line
+	Value string
+	// Bytes is the length of the value, encoded in the character set of the payment; characters that the
+	// character set can not encode count as one byte
+	Bytes int
+	// Limit is the maximum length of the value, in characters
+	Limit int
+}
+
+// lineAttributes are the attributes of the lines of the payload, in order
+var lineAttributes = []Line{
+	{Field: "ServiceTag", Meaning: "Service tag", Limit: 3},
+	{Field: "Version", Meaning: "Version", Limit: 3},
+	{Field: "CharacterSet", Meaning: "Character set", Limit: 1},
+	{Field: "IdentificationCode", Meaning: "Identification: SEPA Credit Transfer", Limit: 3},
+	{Field: "BICBeneficiary", Code: "AT-23", Meaning: "BIC of the beneficiary bank", Limit: 11},
+	{Field: "NameBeneficiary", Code: "AT-21", Meaning: "Name of the beneficiary", Limit: 70},
+	{Field: "IBANBeneficiary", Code: "AT-20", Meaning: "Account number (IBAN) of the beneficiary", Limit: 34},
+	{Field: "EuroAmount", Code: "AT-04", Meaning: "Amount of the credit transfer in euro", Limit: 15},
+	{Field: "Purpose", Code: "AT-44", Meaning: "Purpose of the credit transfer", Limit: 4},
+	{Field: "Remittance", Code: "AT-05", Meaning: "Remittance information (structured)", Limit: 35},
+	{Field: "Remittance", Code: "AT-05", Meaning: "Remittance information (unstructured)", Limit: 140},
+	{Field: "B2OInformation", Meaning: "Beneficiary to originator information", Limit: 70},
+}
+
+// Characters returns the length of the value, in characters
+func (l Line) Characters() int {
+	return utf8.RuneCountInString(l.Value)
+}
+
+// Remaining returns how many characters can be added to the value; it is negative when the value is too long
+func (l Line) Remaining() int {
+	return l.Limit - l.Characters()
+}
+
+// Lines returns the lines of the payload with their attributes, without validating the payment
+func (p *Payment) Lines() []Line {
+	lines := make([]Line, len(lineAttributes))
+
+	for i, value := range p.fields() {
+		l := lineAttributes[i]
+		l.Number = i + 1
+		l.Value = value
+		l.Bytes = len(value)
+
+		// The single-byte character sets store every character in one byte
+		if characterSets[p.CharacterSet] != nil {
+			l.Bytes = l.Characters()
+		}
+
+		lines[i] = l
+	}
+
+	return lines
+}
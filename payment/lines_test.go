@@ -0,0 +1,62 @@
+package payment_test
+
+import (
+	"testing"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLines(t *testing.T) {
+	p := payment.New()
+	p.NameBeneficiary = "Franz Mustermänn"
+	p.IBANBeneficiary = "DE71110220330123456789"
+	p.EuroAmount = 1230
+	p.Remittance = "Invoice 1234"
+
+	lines := p.Lines()
+	require.Len(t, lines, 12)
+
+	s, err := p.ToString()
+	require.NoError(t, err)
+
+	total := len(lines) - 1
+	for i, l := range lines {
+		assert.Equal(t, i+1, l.Number)
+		total += l.Bytes
+	}
+
+	b, err := p.ToBytes()
+	require.NoError(t, err)
+	assert.Len(t, b, total-1, "the empty last line is left out")
+	assert.NotEqual(t, len(s), len(b), "UTF-8 takes 2 bytes for ä, ISO 8859-1 only 1")
+
+	name := lines[5]
+	assert.Equal(t, "NameBeneficiary", name.Field)
+	assert.Equal(t, "AT-21", name.Code)
+	assert.Equal(t, 16, name.Characters())
+	assert.Equal(t, 16, name.Bytes)
+	assert.Equal(t, 54, name.Remaining())
+
+	assert.Equal(t, "EUR12.30", lines[7].Value)
+	assert.Empty(t, lines[9].Value)
+	assert.Equal(t, "Invoice 1234", lines[10].Value)
+}
+
+func TestLinesUTF8(t *testing.T) {
+	p := payment.New()
+	p.CharacterSet = payment.CharacterSetUTF8
+	p.NameBeneficiary = "Franz Mustermänn"
+
+	name := p.Lines()[5]
+	assert.Equal(t, 16, name.Characters())
+	assert.Equal(t, 17, name.Bytes)
+}
+
+func TestLinesInvalid(t *testing.T) {
+	p := payment.New()
+	p.NameBeneficiary = "This name is much too long to fit on the line, as it has more than seventy characters"
+
+	assert.Negative(t, p.Lines()[5].Remaining())
+}
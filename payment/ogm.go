@@ -0,0 +1,97 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ogmBaseValidator = regexp.MustCompile(`^\d{1,10}$`)
+	// ogmValidator matches the Belgian structured communication, with or without the +++ or *** notation
+	ogmValidator = regexp.MustCompile(`^(\+{3}|\*{3})?(\d{3})/?(\d{4})/?(\d{5})(\+{3}|\*{3})?$`)
+
+	// ErrOGMReferenceBase is returned when the base of an OGM is not 1 to 10 digits
+	ErrOGMReferenceBase = errors.New("OGM base should be 1 to 10 digits")
+	// ErrOGMReferenceFormat is returned when an OGM does not have the format +++123/4567/89012+++
+	ErrOGMReferenceFormat = errors.New("OGM should be 12 digits, eg. +++123/4567/89012+++")
+	// ErrOGMReferenceChecksum is returned when the check digits of an OGM are wrong
+	ErrOGMReferenceChecksum = errors.New("OGM has invalid check digits")
+)
+
+// NewOGMReference returns a Belgian structured communication (OGM/VCS) of 12 digits for the base, eg. an
+// invoice number of up to 10 digits; shorter bases are padded with leading zeros.
+// Use FormatOGMReference to print it as +++123/4567/89012+++.
+func NewOGMReference(base string) (string, error) {
+	base = strings.Join(strings.Fields(base), "")
+
+	if !ogmBaseValidator.MatchString(base) {
+		return "", ErrOGMReferenceBase
+	}
+
+	base = strings.Repeat("0", 10-len(base)) + base
+
+	return base + ogmCheck(base), nil
+}
+
+// ValidateOGMReference returns an error when the reference is not a valid Belgian structured communication
+// The +++123/4567/89012+++ and ***123/4567/89012*** notations are accepted, as well as the 12 digits only.
+func ValidateOGMReference(ref string) error {
+	digits, ok := normalizeOGM(ref)
+	if !ok {
+		return ErrOGMReferenceFormat
+	}
+
+	if ogmCheck(digits[:10]) != digits[10:] {
+		return ErrOGMReferenceChecksum
+	}
+
+	return nil
+}
+
+// IsOGMReference returns whether the reference is a valid Belgian structured communication
+func IsOGMReference(ref string) bool {
+	return ValidateOGMReference(ref) == nil
+}
+
+// FormatOGMReference returns the reference in the +++123/4567/89012+++ notation;
+// references that are not 12 digits are returned unchanged
+func FormatOGMReference(ref string) string {
+	digits, ok := normalizeOGM(ref)
+	if !ok {
+		return ref
+	}
+
+	return fmt.Sprintf("+++%s/%s/%s+++", digits[:3], digits[3:7], digits[7:])
+}
+
+// isOGMCandidate returns whether the reference looks like it is meant to be an OGM
+func isOGMCandidate(ref string) bool {
+	_, ok := normalizeOGM(ref)
+	return ok
+}
+
+// normalizeOGM returns the 12 digits of the reference, without the notation, slashes and spaces
+func normalizeOGM(ref string) (string, bool) {
+	m := ogmValidator.FindStringSubmatch(strings.Join(strings.Fields(ref), ""))
+	if m == nil || (m[1] == "") != (m[5] == "") || (m[1] != "" && m[1][0] != m[5][0]) {
+		return "", false
+	}
+
+	return m[2] + m[3] + m[4], true
+}
+
+// ogmCheck returns the check digits for the 10 digits of the base: the remainder of
+// the division by 97, where a remainder of 0 becomes 97
+func ogmCheck(base string) string {
+	n, _ := strconv.ParseUint(base, 10, 64)
+
+	check := n % 97
+	if check == 0 {
+		check = 97
+	}
+
+	return fmt.Sprintf("%02d", check)
+}
@@ -0,0 +1,86 @@
+package payment_test
+
+import (
+	"testing"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewOGMReference(t *testing.T) {
+	for base, expected := range map[string]string{
+		"1234567890":  "123456789002",
+		"0909337554":  "090933755493",
+		"909337554":   "090933755493",
+		"42":          "000000004242",
+		"97":          "000000009797",
+		"9700000000":  "970000000097",
+		"12345 67890": "123456789002",
+	} {
+		ref, err := payment.NewOGMReference(base)
+		require.NoError(t, err, base)
+		assert.Equal(t, expected, ref, base)
+		assert.True(t, payment.IsOGMReference(ref), ref)
+	}
+
+	for _, base := range []string{"", "12345678901", "INV42", "-1"} {
+		_, err := payment.NewOGMReference(base)
+		require.ErrorIs(t, err, payment.ErrOGMReferenceBase, base)
+	}
+}
+
+func TestValidateOGMReference(t *testing.T) {
+	for _, ref := range []string{
+		"+++090/9337/55493+++",
+		"***090/9337/55493***",
+		"090/9337/55493",
+		"090933755493",
+		"+++ 090 / 9337 / 55493 +++",
+		"+++000/0000/09797+++",
+	} {
+		require.NoError(t, payment.ValidateOGMReference(ref), ref)
+	}
+
+	for ref, expected := range map[string]error{
+		"":                     payment.ErrOGMReferenceFormat,
+		"+++090/9337/5549+++":  payment.ErrOGMReferenceFormat,
+		"+++090/9337/55493***": payment.ErrOGMReferenceFormat,
+		"+++090/9337/55493":    payment.ErrOGMReferenceFormat,
+		"RF18539007547034":     payment.ErrOGMReferenceFormat,
+		"+++090/9337/55494+++": payment.ErrOGMReferenceChecksum,
+		"000000000000":         payment.ErrOGMReferenceChecksum,
+	} {
+		require.ErrorIs(t, payment.ValidateOGMReference(ref), expected, ref)
+		assert.False(t, payment.IsOGMReference(ref), ref)
+	}
+}
+
+func TestFormatOGMReference(t *testing.T) {
+	assert.Equal(t, "+++090/9337/55493+++", payment.FormatOGMReference("090933755493"))
+	assert.Equal(t, "+++090/9337/55493+++", payment.FormatOGMReference("***090/9337/55493***"))
+	assert.Equal(t, "invalid", payment.FormatOGMReference("invalid"))
+}
+
+func TestStructuredRemittanceNormalized(t *testing.T) {
+	p := payment.NewStructured()
+	p.NameBeneficiary = "François D'Alsace S.A."
+	p.IBANBeneficiary = "BE68539007547034"
+	p.EuroAmount = 1000
+	p.Remittance = "+++090/9337/55493+++"
+
+	require.NoError(t, p.IsValid())
+	assert.Equal(t, "090933755493", p.RemittanceStructured())
+
+	p.Remittance = "***090/9337/55494***"
+	require.ErrorIs(t, p.IsValid(), payment.ErrValidationRemittanceStructuredOGM)
+	require.ErrorIs(t, p.IsValid(), payment.ErrOGMReferenceChecksum)
+
+	p.Remittance = "rf18 5390 0754 7034"
+	require.NoError(t, p.IsValid())
+	assert.Equal(t, "RF18539007547034", p.RemittanceStructured())
+
+	p.RemittanceIsStructured = false
+	p.Remittance = "+++090/9337/55494+++"
+	assert.Equal(t, "+++090/9337/55494+++", p.RemittanceText())
+}
@@ -1,23 +1,37 @@
 package payment
 
 import (
-	"bytes"
-	"image/png"
 	"strings"
 
-	"github.com/boombuler/barcode"
-	"github.com/boombuler/barcode/qr"
-	"github.com/mdp/qrterminal/v3"
+	"github.com/jovandeginste/payme/qrcode"
 )
 
 // ToString returns the content of the QR code as string
-// Use this to then generate the QR code in the form you need
+// Use this to then generate the QR code in the form you need; use ToBytes for the content
+// encoded in the character set of the payment, as it is stored in the QR code
 func (p *Payment) ToString() (string, error) {
 	if err := p.IsValid(); err != nil {
 		return "", err
 	}
 
-	fields := []string{
+	return strings.Join(p.payloadFields(), "\n"), nil
+}
+
+// payloadFields returns the values of the lines of the payload up to the last one that is not empty;
+// the trailing empty lines are left out, as the EPC guidelines require
+func (p *Payment) payloadFields() []string {
+	fields := p.fields()
+
+	for len(fields) > minLines && fields[len(fields)-1] == "" {
+		fields = fields[:len(fields)-1]
+	}
+
+	return fields
+}
+
+// fields returns the values of the lines of the payload, without validating them
+func (p *Payment) fields() []string {
+	return []string{
 		p.ServiceTag,
 		p.VersionString(),
 		p.CharacterSetString(),
@@ -31,49 +45,37 @@ func (p *Payment) ToString() (string, error) {
 		p.RemittanceText(),
 		p.B2OInformation,
 	}
-
-	return strings.Join(fields, "\n"), nil
 }
 
-// ToQRBytes returns an ASCII representation of the QR code
-// You can print this to the console, save to a file, etc.
-func (p *Payment) ToQRBytes() ([]byte, error) {
-	var result bytes.Buffer
-
-	t, err := p.ToString()
+// QRCode returns the QR code, encoded with the options (error correction level, quiet zone, ...)
+// Use this to render the QR code in the form you need; it makes Payment a qrcode.Encoder
+func (p *Payment) QRCode(opts qrcode.Options) (*qrcode.Code, error) {
+	b, err := p.ToBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	qrterminal.GenerateHalfBlock(t, qrterminal.M, &result)
-
-	return result.Bytes(), nil
+	return qrcode.Encode(b, opts)
 }
 
-// ToQRPNG returns an PNG representation of the QR code
-// You should save this to a file, or pass it to an image processing library
-func (p *Payment) ToQRPNG(qrSize int) ([]byte, error) {
-	t, err := p.ToString()
+// ToQRBytes returns an ASCII representation of the QR code, with the default options
+// You can print this to the console, save to a file, etc.
+func (p *Payment) ToQRBytes() ([]byte, error) {
+	qrCode, err := p.QRCode(qrcode.DefaultOptions())
 	if err != nil {
 		return nil, err
 	}
 
-	// Create the barcode
-	qrCode, err := qr.Encode(t, qr.M, qr.Auto)
-	if err != nil {
-		return nil, err
-	}
+	return qrCode.Terminal(), nil
+}
 
-	// Scale the barcode to qrSize x qrSize pixels
-	qrCode, err = barcode.Scale(qrCode, qrSize, qrSize)
+// ToQRPNG returns an PNG representation of the QR code, with the default options
+// You should save this to a file, or pass it to an image processing library
+func (p *Payment) ToQRPNG(qrSize int) ([]byte, error) {
+	qrCode, err := p.QRCode(qrcode.DefaultOptions())
 	if err != nil {
 		return nil, err
 	}
 
-	var b bytes.Buffer
-
-	// encode the barcode as png
-	err = png.Encode(&b, qrCode)
-
-	return b.Bytes(), err
+	return qrCode.PNG(qrSize)
 }
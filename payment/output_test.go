@@ -1,11 +1,13 @@
 package payment_test
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"testing"
 
 	"github.com/jovandeginste/payme/payment"
+	"github.com/jovandeginste/payme/qrcode"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -35,7 +37,7 @@ func ExamplePayment() {
 
 	p.NameBeneficiary = ExampleName
 	p.IBANBeneficiary = ExampleIBAN
-	p.EuroAmount = 12.3
+	p.EuroAmount = 1230
 	p.Remittance = ExampleRemittance
 
 	o, err := p.ToQRBytes()
@@ -54,7 +56,7 @@ func TestUnstructuredPaymentQR(t *testing.T) {
 
 	p.NameBeneficiary = ExampleName
 	p.IBANBeneficiary = ExampleIBAN
-	p.EuroAmount = 12.3
+	p.EuroAmount = 1230
 	p.Remittance = ExampleRemittance
 
 	err := p.IsValid()
@@ -85,7 +87,7 @@ func TestStructuredPaymentQR(t *testing.T) {
 	p.BICBeneficiary = "BHBLDEHHXXX"
 	p.NameBeneficiary = "Franz Mustermänn"
 	p.IBANBeneficiary = "DE71110220330123456789"
-	p.EuroAmount = 12.3
+	p.EuroAmount = 1230
 	p.Purpose = "GDDS"
 	p.Remittance = "RF18539007547034"
 
@@ -108,3 +110,39 @@ func TestStructuredPaymentQR(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, expected, result)
 }
+
+func TestQROptions(t *testing.T) {
+	p := payment.New()
+
+	p.NameBeneficiary = ExampleName
+	p.IBANBeneficiary = ExampleIBAN
+	p.EuroAmount = 1230
+	p.Remittance = ExampleRemittance
+
+	c, err := p.QRCode(qrcode.DefaultOptions())
+	require.NoError(t, err)
+	assert.Equal(t, qrcode.LevelM, c.Level)
+	assert.Equal(t, qrcode.DefaultQuietZone, c.QuietZone)
+
+	opts := qrcode.DefaultOptions()
+	opts.Level = qrcode.LevelH
+	opts.MinVersion = 15
+	opts.QuietZone = 2
+
+	c, err = p.QRCode(opts)
+	require.NoError(t, err)
+	assert.Equal(t, qrcode.LevelH, c.Level)
+	assert.Equal(t, 15, c.Version)
+
+	result, err := qrcode.ToPNG(p, opts, QRSize)
+	require.NoError(t, err)
+
+	decoded, err := payment.FromQRImage(bytes.NewReader(result))
+	require.NoError(t, err)
+	assert.Equal(t, ExampleRemittance, decoded.Remittance)
+
+	opts.Level = "X"
+
+	_, err = qrcode.ToBytes(p, opts)
+	require.ErrorIs(t, err, qrcode.ErrLevel)
+}
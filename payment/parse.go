@@ -0,0 +1,185 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// minLines is the number of lines up to and including the IBAN, which are mandatory
+	minLines = 7
+	// maxLines is the number of lines of a complete payload
+	maxLines = 12
+)
+
+var (
+	versionParser      = regexp.MustCompile(`^\d{3}$`)
+	characterSetParser = regexp.MustCompile(`^\d$`)
+	euroAmountParser   = regexp.MustCompile(`^EUR\d{1,9}(\.\d{1,2})?$`)
+
+	// ErrParseLineCount is returned when the payload does not have between 7 and 12 lines
+	ErrParseLineCount = fmt.Errorf("payload should have %d to %d lines", minLines, maxLines)
+	// ErrParseEuroAmount is returned when the amount line is not of the form EUR12.34
+	ErrParseEuroAmount = errors.New("amount should be formatted as EUR followed by the amount, eg. EUR12.34")
+	// ErrParseRemittance is returned when both the structured and unstructured remittance lines are set
+	ErrParseRemittance = errors.New("only one of structured and unstructured remittance can be set")
+)
+
+// ParseError is returned when a line of a payload could not be parsed
+type ParseError struct {
+	// Line is the (1-based) line number in the payload
+	Line int
+	// Field is the name of the Payment field that is stored on this line
+	Field string
+	// Err is the underlying error
+	Err error
+}
+
+// Error implements the error interface
+func (e *ParseError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+	}
+
+	return fmt.Sprintf("line %d (%s): %s", e.Line, e.Field, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Parse reads the content of a QR code (as returned by ToString) into a Payment.
+// Lines may be separated by LF or CRLF, and trailing empty lines may be omitted; one line ending after the
+// last line is ignored. Parse only checks the syntax of the payload; use IsValid to check the content.
+func Parse(s string) (*Payment, error) {
+	// The \r of a trailing CRLF is trimmed with those of the other lines
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSuffix(l, "\r")
+	}
+
+	if len(lines) < minLines || len(lines) > maxLines {
+		return nil, &ParseError{Line: len(lines), Err: ErrParseLineCount}
+	}
+
+	// Pad the optional trailing lines
+	lines = append(lines, make([]string, maxLines-len(lines))...)
+
+	p := New()
+
+	p.ServiceTag = lines[0]
+	if p.ServiceTag != "BCD" {
+		return nil, &ParseError{Line: 1, Field: "ServiceTag", Err: ErrValidationServiceTag}
+	}
+
+	if err := p.parseHeader(lines[1], lines[2]); err != nil {
+		return nil, err
+	}
+
+	p.IdentificationCode = lines[3]
+	if p.IdentificationCode != "SCT" {
+		return nil, &ParseError{Line: 4, Field: "IdentificationCode", Err: ErrValidationIdentificationCode}
+	}
+
+	p.BICBeneficiary = lines[4]
+	p.NameBeneficiary = lines[5]
+	p.IBANBeneficiary = lines[6]
+
+	if err := p.parseEuroAmount(lines[7]); err != nil {
+		return nil, err
+	}
+
+	p.Purpose = lines[8]
+
+	if err := p.parseRemittance(lines[9], lines[10]); err != nil {
+		return nil, err
+	}
+
+	p.B2OInformation = lines[11]
+
+	return p, nil
+}
+
+// ParseBytes reads the raw content of a QR code (as returned by ToBytes) into a Payment,
+// decoding it from the character set declared in its header
+func ParseBytes(b []byte) (*Payment, error) {
+	return Parse(decodeCharacterSet(b, payloadCharacterSet(b)))
+}
+
+func (p *Payment) parseHeader(version, characterSet string) error {
+	if !versionParser.MatchString(version) {
+		return &ParseError{Line: 2, Field: "Version", Err: ErrValidationVersion}
+	}
+
+	p.Version, _ = strconv.Atoi(version)
+	if p.Version != 1 && p.Version != 2 {
+		return &ParseError{Line: 2, Field: "Version", Err: ErrValidationVersion}
+	}
+
+	if !characterSetParser.MatchString(characterSet) {
+		return &ParseError{Line: 3, Field: "CharacterSet", Err: ErrValidationCharacterSet}
+	}
+
+	p.CharacterSet, _ = strconv.Atoi(characterSet)
+	if p.CharacterSet < 1 || p.CharacterSet > 8 {
+		return &ParseError{Line: 3, Field: "CharacterSet", Err: ErrValidationCharacterSet}
+	}
+
+	return nil
+}
+
+func (p *Payment) parseEuroAmount(s string) error {
+	if s == "" {
+		return nil
+	}
+
+	if !euroAmountParser.MatchString(s) {
+		return &ParseError{Line: 8, Field: "EuroAmount", Err: ErrParseEuroAmount}
+	}
+
+	a, err := ParseAmount(strings.TrimPrefix(s, "EUR"), LocaleNone)
+	if err != nil {
+		return &ParseError{Line: 8, Field: "EuroAmount", Err: err}
+	}
+
+	p.EuroAmount = a
+
+	return nil
+}
+
+func (p *Payment) parseRemittance(structured, unstructured string) error {
+	if structured != "" && unstructured != "" {
+		return &ParseError{Line: 11, Field: "Remittance", Err: ErrParseRemittance}
+	}
+
+	p.RemittanceIsStructured = structured != ""
+	p.Remittance = structured + unstructured
+
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler; it returns the content of the QR code
+func (p *Payment) MarshalText() ([]byte, error) {
+	s, err := p.ToString()
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(s), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler; it parses the content of a QR code
+func (p *Payment) UnmarshalText(text []byte) error {
+	r, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+
+	*p = *r
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package payment_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	examplePayloadUnstructured = `BCD
+002
+2
+SCT
+
+François D'Alsace S.A.
+FR14 2004 1010 0505 0001 3M02 606
+EUR12.30
+
+
+Client:Marie Louise La Lune`
+	examplePayloadStructured = `BCD
+001
+1
+SCT
+BHBLDEHHXXX
+Franz Mustermänn
+DE71 1102 2033 0123 4567 89
+EUR12.30
+GDDS
+RF18539007547034`
+	examplePayloadOpenAmount = `BCD
+002
+2
+SCT
+
+Stichting Het Goede Doel
+NL91 ABNA 0417 1643 00
+
+
+
+Donation`
+	examplePayloadB2O = `BCD
+002
+2
+SCT
+
+Franz Mustermänn
+DE71 1102 2033 0123 4567 89
+EUR12.30
+
+RF18539007547034
+
+Thank you for your order`
+)
+
+func TestParseRoundTrip(t *testing.T) {
+	for _, s := range []string{examplePayloadUnstructured, examplePayloadStructured, examplePayloadOpenAmount, examplePayloadB2O} {
+		p, err := payment.Parse(s)
+		require.NoError(t, err)
+		require.NoError(t, p.IsValid())
+
+		result, err := p.ToString()
+		require.NoError(t, err)
+		assert.Equal(t, s, result)
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	p, err := payment.Parse(examplePayloadStructured)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, p.Version)
+	assert.Equal(t, 1, p.CharacterSet)
+	assert.Equal(t, "BHBLDEHHXXX", p.BICBeneficiary)
+	assert.Equal(t, "Franz Mustermänn", p.NameBeneficiary)
+	assert.Equal(t, "DE71110220330123456789", strings.ReplaceAll(p.IBANBeneficiary, " ", ""))
+	assert.Equal(t, payment.Amount(1230), p.EuroAmount)
+	assert.Equal(t, "GDDS", p.Purpose)
+	assert.Equal(t, "RF18539007547034", p.Remittance)
+	assert.True(t, p.RemittanceIsStructured)
+}
+
+func TestParseOpenAmount(t *testing.T) {
+	p, err := payment.Parse(examplePayloadOpenAmount)
+	require.NoError(t, err)
+
+	assert.True(t, p.IsOpenAmount())
+	assert.Zero(t, p.EuroAmount)
+	assert.Empty(t, p.EuroAmountString())
+}
+
+func TestParseCRLFAndTrailingLines(t *testing.T) {
+	s := strings.Join([]string{
+		"BCD", "002", "2", "SCT", "", ExampleName, ExampleIBAN, "EUR1", "", "", ExampleRemittance,
+	}, "\r\n")
+
+	p, err := payment.Parse(s)
+	require.NoError(t, err)
+
+	assert.Equal(t, ExampleName, p.NameBeneficiary)
+	assert.Equal(t, ExampleRemittance, p.Remittance)
+	assert.False(t, p.RemittanceIsStructured)
+	assert.Empty(t, p.B2OInformation)
+	require.NoError(t, p.IsValid())
+}
+
+func TestParseTrailingNewline(t *testing.T) {
+	lines := []string{"BCD", "002", "2", "SCT", "", ExampleName, ExampleIBAN, "EUR1", "", "", ExampleRemittance, "Thanks"}
+
+	for _, sep := range []string{"\n", "\r\n"} {
+		p, err := payment.Parse(strings.Join(lines, sep) + sep)
+		require.NoError(t, err, "a complete payload of 12 lines may end with a line ending")
+		assert.Equal(t, "Thanks", p.B2OInformation)
+	}
+
+	_, err := payment.Parse(strings.Join(lines, "\n") + "\n\n")
+	require.ErrorIs(t, err, payment.ErrParseLineCount, "only one line ending is ignored")
+}
+
+func TestParseErrors(t *testing.T) {
+	valid := strings.Split(examplePayloadUnstructured, "\n")
+
+	for _, tc := range []struct {
+		line  int
+		value string
+		err   error
+	}{
+		{1, "ABC", payment.ErrValidationServiceTag},
+		{2, "003", payment.ErrValidationVersion},
+		{2, "2", payment.ErrValidationVersion},
+		{3, "9", payment.ErrValidationCharacterSet},
+		{3, "02", payment.ErrValidationCharacterSet},
+		{4, "SCC", payment.ErrValidationIdentificationCode},
+		{8, "12.30", payment.ErrParseEuroAmount},
+		{8, "EUR12.345", payment.ErrParseEuroAmount},
+		{10, "RF18539007547034", payment.ErrParseRemittance},
+	} {
+		lines := append([]string{}, valid...)
+		lines[tc.line-1] = tc.value
+
+		_, err := payment.Parse(strings.Join(lines, "\n"))
+		require.ErrorIs(t, err, tc.err, "Line %d: %q", tc.line, tc.value)
+
+		var pe *payment.ParseError
+		require.True(t, errors.As(err, &pe))
+		assert.NotEmpty(t, pe.Field)
+	}
+
+	_, err := payment.Parse("BCD\n002\n1")
+	require.ErrorIs(t, err, payment.ErrParseLineCount)
+
+	_, err = payment.Parse(examplePayloadUnstructured + "\n\n\n")
+	require.ErrorIs(t, err, payment.ErrParseLineCount)
+}
+
+func TestTextMarshaler(t *testing.T) {
+	p := payment.New()
+
+	require.NoError(t, p.UnmarshalText([]byte(examplePayloadUnstructured)))
+	assert.Equal(t, ExampleName, p.NameBeneficiary)
+
+	b, err := p.MarshalText()
+	require.NoError(t, err)
+	assert.Equal(t, examplePayloadUnstructured, string(b))
+
+	require.Error(t, p.UnmarshalText([]byte("invalid")))
+	assert.Equal(t, ExampleName, p.NameBeneficiary)
+}
@@ -18,41 +18,42 @@ var commonSeparatorChars = regexp.MustCompile("[ _-]")
 // Payment encapsulates all fields needed to generate the QR code
 type Payment struct {
 	// ServiceTag should always be BCD
-	ServiceTag string
+	ServiceTag string `json:"service_tag" yaml:"service_tag" toml:"service_tag"`
 	// Version should be v1 or v2
-	Version int
+	Version int `json:"version" yaml:"version" toml:"version"`
 	/*
 		1: UTF-8 5: ISO 8859-5
 		2: ISO 8859-1 6: ISO 8859-7
 		3: ISO 8859-2 7: ISO 8859-10
 		4: ISO 8859-4 8: ISO 8859-15
 	*/
-	CharacterSet int
+	CharacterSet int `json:"character_set" yaml:"character_set" toml:"character_set"`
 	// IdentificationCode should always be SCT (SEPA Credit Transfer)
-	IdentificationCode string
+	IdentificationCode string `json:"identification_code" yaml:"identification_code" toml:"identification_code"`
 	// AT-23 BIC of the Beneficiary Bank [optional in Version 2]
 	// The BIC will continue to be mandatory for SEPA payment transactions involving non-EEA countries.
-	BICBeneficiary string
+	BICBeneficiary string `json:"bic,omitempty" yaml:"bic,omitempty" toml:"bic,omitempty"`
 	// AT-21 Name of the Beneficiary
-	NameBeneficiary string
+	NameBeneficiary string `json:"name" yaml:"name" toml:"name"`
 	// AT-20 Account number of the Beneficiary
 	// Only IBAN is allowed.
-	IBANBeneficiary string
-	// AT-04 Amount of the Credit Transfer in Euro [optional]
-	// Amount must be 0.01 or more and 999999999.99 or less
-	EuroAmount float64
+	IBANBeneficiary string `json:"iban" yaml:"iban" toml:"iban"`
+	// AT-04 Amount of the Credit Transfer in Euro cents [optional]
+	// Amount must be 0.01 or more and 999999999.99 or less; leave it 0 for an open amount,
+	// which the payer fills in (eg. for donations)
+	EuroAmount Amount `json:"amount" yaml:"amount" toml:"amount"`
 	// AT-44 Purpose of the Credit Transfer [optional]
-	Purpose string
+	Purpose string `json:"purpose,omitempty" yaml:"purpose,omitempty" toml:"purpose,omitempty"`
 	// AT-05 Remittance Information (Structured) [optional]
 	// Creditor Reference (ISO 11649 RFCreditor Reference may be used
 	// *or*
 	// AT-05 Remittance Information (Unstructured) [optional]
-	Remittance string
+	Remittance string `json:"remittance" yaml:"remittance" toml:"remittance"`
 	// Beneficiary to originator information [optional]
-	B2OInformation string
+	B2OInformation string `json:"b2o_information,omitempty" yaml:"b2o_information,omitempty" toml:"b2o_information,omitempty"`
 
 	// Defines whether the Remittance Information is Structured or Unstructured
-	RemittanceIsStructured bool
+	RemittanceIsStructured bool `json:"structured" yaml:"structured" toml:"structured"`
 }
 
 // NewStructured returns a default Payment with the Structured flag enabled
@@ -109,7 +110,16 @@ func (p *Payment) CharacterSetString() string {
 // EuroAmountString returns the set amount in financial format (eg. EUR12.34)
 // or an empty string if the amount is 0
 func (p *Payment) EuroAmountString() string {
-	return fmt.Sprintf("EUR%.2f", p.EuroAmount)
+	if p.IsOpenAmount() {
+		return ""
+	}
+
+	return "EUR" + p.EuroAmount.String()
+}
+
+// IsOpenAmount returns whether the amount is left for the payer to fill in
+func (p *Payment) IsOpenAmount() bool {
+	return p.EuroAmount == 0
 }
 
 // RemittanceStructured returns the value for the structured remittance line
@@ -123,19 +133,22 @@ func (p *Payment) RemittanceText() string {
 }
 
 // RemittanceString returns the value for the remittance field, independing on being structured
+// Structured references are normalized: spaces are removed from RF references, and Belgian
+// structured communications (+++123/4567/89012+++) are reduced to their 12 digits.
 func (p *Payment) RemittanceString(structured bool) string {
 	if p.RemittanceIsStructured != structured {
 		return ""
 	}
 
+	if structured {
+		return normalizeStructuredReference(p.Remittance)
+	}
+
 	return p.Remittance
 }
 
-// BICBeneficiaryString returns the BIC of the beneficiary, depending on the version of the QR code
+// BICBeneficiaryString returns the BIC of the beneficiary; it is mandatory for version 1
+// and optional for version 2 of the QR code, so it is left out when empty
 func (p *Payment) BICBeneficiaryString() string {
-	if p.Version != 1 {
-		return ""
-	}
-
 	return p.BICBeneficiary
 }
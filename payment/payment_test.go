@@ -23,7 +23,7 @@ func TestUnstructuredPayment(t *testing.T) {
 
 	p.NameBeneficiary = ExampleName
 	p.IBANBeneficiary = ExampleIBAN
-	p.EuroAmount = 12.3
+	p.EuroAmount = 1230
 	p.Remittance = ExampleRemittance
 
 	result, err := p.ToString()
@@ -39,8 +39,7 @@ FR14 2004 1010 0505 0001 3M02 606
 EUR12.30
 
 
-Client:Marie Louise La Lune
-`
+Client:Marie Louise La Lune`
 
 	assert.Equal(t, expected, result)
 }
@@ -53,7 +52,7 @@ func TestStructuredPayment(t *testing.T) {
 	p.BICBeneficiary = "BHBLDEHHXXX"
 	p.NameBeneficiary = "Franz Mustermänn"
 	p.IBANBeneficiary = "DE71110220330123456789"
-	p.EuroAmount = 12.3
+	p.EuroAmount = 1230
 	p.Purpose = "GDDS"
 	p.Remittance = "RF18539007547034"
 
@@ -69,20 +68,25 @@ Franz Mustermänn
 DE71 1102 2033 0123 4567 89
 EUR12.30
 GDDS
-RF18539007547034
-
-`
+RF18539007547034`
 
 	assert.Equal(t, expected, result)
 }
 
 func TestEuroAmountString(t *testing.T) {
 	p := payment.Payment{}
-	p.EuroAmount = 0.01
+	p.EuroAmount = 1
 	assert.Equal(t, "EUR0.01", p.EuroAmountString())
 
-	p.EuroAmount = 1000.0001
+	p.EuroAmount = 100000
 	assert.Equal(t, "EUR1000.00", p.EuroAmountString())
+
+	p.EuroAmount = payment.MaxAmount
+	assert.Equal(t, "EUR999999999.99", p.EuroAmountString())
+
+	p.EuroAmount = 0
+	assert.True(t, p.IsOpenAmount())
+	assert.Empty(t, p.EuroAmountString())
 }
 
 func TestIBANBeneficiaryString(t *testing.T) {
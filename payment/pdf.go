@@ -0,0 +1,147 @@
+package payment
+
+import (
+	"errors"
+
+	"github.com/jovandeginste/payme/internal/pdf"
+	"github.com/jovandeginste/payme/qrcode"
+)
+
+// PageSize is the size of the PDF page
+type PageSize string
+
+const (
+	// PageSizeA4 prints the payment slip at the bottom of an A4 page (portrait), below a cutting line
+	PageSizeA4 PageSize = "a4"
+	// PageSizeA6 prints the payment slip on an A6 page (landscape)
+	PageSizeA6 PageSize = "a6"
+)
+
+// ErrPageSize is returned when the page size is not supported
+var ErrPageSize = errors.New("page size should be a4 or a6")
+
+const (
+	// slipHeight is the height of the payment slip in mm, which fills an A6 page
+	slipHeight = 105
+	// slipMargin is the margin around the content of the slip
+	slipMargin = 8
+	// slipQRSize is the size of the QR code on the slip, including its quiet zone
+	slipQRSize = 50
+)
+
+// PDFOptions configures the PDF representation of the payment
+type PDFOptions struct {
+	// PageSize is the size of the page; the payment slip itself is always the size of an A6 page
+	PageSize PageSize
+	// QR configures how the QR code on the slip is encoded
+	QR qrcode.Options
+}
+
+// DefaultPDFOptions returns the options for an A4 page, with the default QR code options
+func DefaultPDFOptions() PDFOptions {
+	return PDFOptions{
+		PageSize: PageSizeA4,
+		QR:       qrcode.DefaultOptions(),
+	}
+}
+
+// ToQRPDF returns a printable PDF page with a payment slip: the QR code next to
+// the human-readable payment information
+func (p *Payment) ToQRPDF(opts PDFOptions) ([]byte, error) {
+	qrCode, err := p.QRCode(opts.QR)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := pdf.New()
+
+	var page *pdf.Page
+
+	switch opts.PageSize {
+	case PageSizeA4:
+		page = doc.AddPage(pdf.A4Width, pdf.A4Height)
+		page.Line(0, pdf.MM(slipHeight), page.Width, pdf.MM(slipHeight), 0.5, true)
+	case PageSizeA6:
+		page = doc.AddPage(pdf.A6Height, pdf.A6Width)
+	default:
+		return nil, ErrPageSize
+	}
+
+	top := pdf.MM(slipHeight - slipMargin)
+
+	drawQR(page, qrCode, pdf.MM(slipMargin), top-pdf.MM(slipQRSize), pdf.MM(slipQRSize))
+	p.drawSlipText(page, pdf.MM(2*slipMargin+slipQRSize), top, page.Width-pdf.MM(3*slipMargin+slipQRSize))
+
+	return doc.Bytes(), nil
+}
+
+// drawSlipText draws the labels and values of the payment in a column starting at the top left corner x, y
+func (p *Payment) drawSlipText(page *pdf.Page, x, y, width float64) {
+	const (
+		labelSize = 7
+		valueSize = 10
+		lineSkip  = 1.25
+	)
+
+	remittance, reference := "Remittance", p.Remittance
+	if p.RemittanceIsStructured {
+		remittance, reference = "Reference", p.referenceString()
+	}
+
+	for _, f := range [][2]string{
+		{"Beneficiary", p.NameBeneficiary},
+		{"IBAN", p.IBANBeneficiaryString()},
+		{"BIC", p.BICBeneficiaryString()},
+		{"Amount", p.amountString()},
+		{"Purpose", p.PurposeString()},
+		{remittance, reference},
+	} {
+		if f[1] == "" {
+			continue
+		}
+
+		y -= labelSize
+		page.Text(x, y, pdf.HelveticaBold, labelSize, f[0])
+
+		for _, l := range pdf.Wrap(pdf.Helvetica, valueSize, width, f[1]) {
+			y -= valueSize * lineSkip
+			page.Text(x, y, pdf.Helvetica, valueSize, l)
+		}
+
+		y -= valueSize
+	}
+}
+
+// amountString returns the amount for people to read; an open amount is left blank to fill in by hand
+func (p *Payment) amountString() string {
+	if p.IsOpenAmount() {
+		return "EUR ______________"
+	}
+
+	return "EUR " + p.EuroAmount.String()
+}
+
+// referenceString returns the structured reference in the notation people are used to read:
+// RF references in groups of 4 characters, Belgian structured communications as +++123/4567/89012+++
+func (p *Payment) referenceString() string {
+	ref := p.RemittanceStructured()
+
+	switch {
+	case IsOGMReference(ref):
+		return FormatOGMReference(ref)
+	case IsRFReference(ref):
+		return FormatRFReference(ref)
+	}
+
+	return ref
+}
+
+// drawQR draws the QR code as rectangles, one per run of dark modules, in a square
+// of the given size (including the quiet zone) with its bottom left corner at x, y
+func drawQR(page *pdf.Page, qrCode *qrcode.Code, x, y, size float64) {
+	module := size / float64(qrCode.Size()+2*qrCode.QuietZone)
+
+	for _, r := range qrCode.Runs() {
+		page.Rect(x+float64(r.Min.X)*module, y+size-float64(r.Max.Y)*module, float64(r.Dx())*module, module)
+	}
+}
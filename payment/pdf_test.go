@@ -0,0 +1,96 @@
+package payment_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var pdfRect = regexp.MustCompile(`([\d.]+) ([\d.]+) ([\d.]+) ([\d.]+) re f`)
+
+// rasterizePDF draws the rectangles of a PDF generated by ToQRPDF as a PNG image, 4 pixels per point
+func rasterizePDF(t *testing.T, doc []byte) []byte {
+	t.Helper()
+
+	const scale = 4
+
+	img := image.NewGray(image.Rect(0, 0, 200*scale, 300*scale))
+
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+
+	for _, r := range pdfRect.FindAllSubmatch(doc, -1) {
+		var v [4]float64
+
+		for i := range v {
+			f, err := strconv.ParseFloat(string(r[i+1]), 64)
+			require.NoError(t, err)
+
+			v[i] = f * scale
+		}
+
+		for px := int(v[0]); px < int(v[0]+v[2]); px++ {
+			for py := int(v[1]); py < int(v[1]+v[3]); py++ {
+				img.Set(px, img.Rect.Max.Y-py, color.Black)
+			}
+		}
+	}
+
+	var b bytes.Buffer
+	require.NoError(t, png.Encode(&b, img))
+
+	return b.Bytes()
+}
+
+func TestToQRPDF(t *testing.T) {
+	p := payment.New()
+
+	p.NameBeneficiary = "Franz Mustermänn"
+	p.IBANBeneficiary = ExampleIBAN
+	p.EuroAmount = 1230
+	p.Purpose = "GDDS"
+	p.Remittance = ExampleRemittance
+
+	for _, size := range []payment.PageSize{payment.PageSizeA4, payment.PageSizeA6} {
+		opts := payment.DefaultPDFOptions()
+		opts.PageSize = size
+
+		result, err := p.ToQRPDF(opts)
+		require.NoError(t, err)
+
+		assert.True(t, bytes.HasPrefix(result, []byte("%PDF-")))
+		assert.Contains(t, string(result), `(Franz Musterm\344nn)`)
+		assert.Contains(t, string(result), "(FR14 2004 1010 0505 0001 3M02 606)")
+		assert.Contains(t, string(result), "(EUR 12.30)")
+		assert.Contains(t, string(result), "(GDDS)")
+		assert.Contains(t, string(result), "(Client:Marie Louise La Lune)")
+
+		decoded, err := payment.FromQRImage(bytes.NewReader(rasterizePDF(t, result)))
+		require.NoError(t, err, "Page size: %s", size)
+		assert.Equal(t, ExampleRemittance, decoded.Remittance)
+	}
+}
+
+func TestToQRPDFErrors(t *testing.T) {
+	_, err := payment.New().ToQRPDF(payment.DefaultPDFOptions())
+	require.Error(t, err)
+
+	p := payment.New()
+
+	p.NameBeneficiary = ExampleName
+	p.IBANBeneficiary = ExampleIBAN
+	p.EuroAmount = 1230
+	p.Remittance = ExampleRemittance
+
+	_, err = p.ToQRPDF(payment.PDFOptions{PageSize: "letter"})
+	require.ErrorIs(t, err, payment.ErrPageSize)
+}
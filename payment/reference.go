@@ -0,0 +1,119 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// rfPrefix is the prefix of every ISO 11649 creditor reference
+const rfPrefix = "RF"
+
+var (
+	rfBaseValidator = regexp.MustCompile(`^[A-Z0-9]{1,21}$`)
+	rfValidator     = regexp.MustCompile(`^RF\d{2}[A-Z0-9]{1,21}$`)
+
+	// ErrRFReferenceBase is returned when the base of an RF reference is not 1 to 21 alpha-numerics
+	ErrRFReferenceBase = errors.New("RF reference base should be 1 to 21 alpha-numerics")
+	// ErrRFReferenceFormat is returned when an RF reference does not have the format RFnn followed by 1 to 21 alpha-numerics
+	ErrRFReferenceFormat = errors.New("RF reference should be RF, 2 check digits and 1 to 21 alpha-numerics")
+	// ErrRFReferenceChecksum is returned when the check digits of an RF reference are wrong
+	ErrRFReferenceChecksum = errors.New("RF reference has invalid check digits")
+)
+
+// NewRFReference returns an ISO 11649 creditor reference (RFnn...) for the base, eg. an invoice number
+// The base may contain up to 21 letters and digits; spaces are ignored and letters are converted to upper case.
+func NewRFReference(base string) (string, error) {
+	base = normalizeReference(base)
+
+	if !rfBaseValidator.MatchString(base) {
+		return "", ErrRFReferenceBase
+	}
+
+	check := 98 - mod97(base+rfPrefix+"00")
+
+	return fmt.Sprintf("%s%02d%s", rfPrefix, check, base), nil
+}
+
+// ValidateRFReference returns an error when the reference is not a valid ISO 11649 creditor reference
+// Spaces are ignored, so references in print format (groups of 4 characters) are accepted.
+func ValidateRFReference(ref string) error {
+	ref = normalizeReference(ref)
+
+	if !rfValidator.MatchString(ref) {
+		return ErrRFReferenceFormat
+	}
+
+	if mod97(ref[4:]+ref[:4]) != 1 {
+		return ErrRFReferenceChecksum
+	}
+
+	return nil
+}
+
+// IsRFReference returns whether the reference is a valid ISO 11649 creditor reference
+func IsRFReference(ref string) bool {
+	return ValidateRFReference(ref) == nil
+}
+
+// IsStructuredReference returns whether the reference is a valid ISO 11649 creditor reference
+// or a valid Belgian structured communication, which both belong in the structured remittance line
+func IsStructuredReference(ref string) bool {
+	return IsRFReference(ref) || IsOGMReference(ref)
+}
+
+// FormatRFReference returns the reference in print format: groups of 4 characters separated by a space
+func FormatRFReference(ref string) string {
+	ref = normalizeReference(ref)
+
+	var groups []string
+
+	for len(ref) > 4 {
+		groups = append(groups, ref[:4])
+		ref = ref[4:]
+	}
+
+	return strings.Join(append(groups, ref), " ")
+}
+
+// normalizeStructuredReference returns the reference as it is encoded in the structured remittance line:
+// RF references without spaces and in upper case, OGM references as 12 digits, anything else unchanged
+func normalizeStructuredReference(ref string) string {
+	if digits, ok := normalizeOGM(ref); ok {
+		return digits
+	}
+
+	if isRFCandidate(ref) {
+		return normalizeReference(ref)
+	}
+
+	return ref
+}
+
+// isRFCandidate returns whether the reference looks like it is meant to be an RF reference
+func isRFCandidate(ref string) bool {
+	return strings.HasPrefix(normalizeReference(ref), rfPrefix)
+}
+
+// normalizeReference removes all spaces and converts the reference to upper case
+func normalizeReference(ref string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(ref), ""))
+}
+
+// mod97 returns the remainder of the ISO 7064 MOD 97-10 division, after converting
+// letters to numbers (A = 10 ... Z = 35)
+func mod97(s string) int {
+	rem := 0
+
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			rem = (rem*10 + int(r-'0')) % 97
+		case r >= 'A' && r <= 'Z':
+			rem = (rem*100 + int(r-'A'+10)) % 97
+		}
+	}
+
+	return rem
+}
@@ -0,0 +1,54 @@
+package payment_test
+
+import (
+	"testing"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRFReference(t *testing.T) {
+	for base, expected := range map[string]string{
+		"539007547034":          "RF18539007547034",
+		"2348231":               "RF712348231",
+		"1":                     "RF741",
+		"abc 123":               "RF47ABC123",
+		"123456789012345678901": "RF40123456789012345678901",
+	} {
+		ref, err := payment.NewRFReference(base)
+		require.NoError(t, err, base)
+		assert.Equal(t, expected, ref, base)
+		assert.True(t, payment.IsRFReference(ref), ref)
+	}
+
+	for _, base := range []string{"", "   ", "INV-2024", "1234567890123456789012", "Ünïcode"} {
+		_, err := payment.NewRFReference(base)
+		require.ErrorIs(t, err, payment.ErrRFReferenceBase, base)
+	}
+}
+
+func TestValidateRFReference(t *testing.T) {
+	for _, ref := range []string{"RF18539007547034", "RF18 5390 0754 7034", "rf712348231", "RF78Q"} {
+		require.NoError(t, payment.ValidateRFReference(ref), ref)
+	}
+
+	for ref, expected := range map[string]error{
+		"":                           payment.ErrRFReferenceFormat,
+		"RF18":                       payment.ErrRFReferenceFormat,
+		"RFAB539007547034":           payment.ErrRFReferenceFormat,
+		"XX18539007547034":           payment.ErrRFReferenceFormat,
+		"RF181234567890123456789012": payment.ErrRFReferenceFormat,
+		"RF17539007547034":           payment.ErrRFReferenceChecksum,
+		"RF18539007547043":           payment.ErrRFReferenceChecksum,
+	} {
+		require.ErrorIs(t, payment.ValidateRFReference(ref), expected, ref)
+		assert.False(t, payment.IsRFReference(ref), ref)
+	}
+}
+
+func TestFormatRFReference(t *testing.T) {
+	assert.Equal(t, "RF18 5390 0754 7034", payment.FormatRFReference("RF18539007547034"))
+	assert.Equal(t, "RF71 2348 231", payment.FormatRFReference("rf712348231"))
+	assert.Equal(t, "RF74 1", payment.FormatRFReference("RF741"))
+}
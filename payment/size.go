@@ -0,0 +1,59 @@
+package payment
+
+import (
+	"strings"
+
+	"github.com/jovandeginste/payme/qrcode"
+)
+
+// Size is the size of the payload of a payment, and of the QR code that holds it
+type Size struct {
+	// Bytes is the length of the payload, encoded in the character set of the payment
+	Bytes int
+	// Remaining is how many bytes can be added before the payload exceeds MaxPayloadBytes;
+	// it is negative when the payload is too large
+	Remaining int
+	// Versions is the smallest QR code version that holds the payload, by error correction level;
+	// a level is missing when no version can hold the payload
+	Versions map[qrcode.Level]int
+}
+
+// Modules returns the number of modules on a side of the QR code at the error correction level,
+// or 0 if no version can hold the payload
+func (s Size) Modules(level qrcode.Level) int {
+	v, ok := s.Versions[level]
+	if !ok {
+		return 0
+	}
+
+	return qrcode.ModulesFor(v)
+}
+
+// Size returns the size of the payload and the QR code versions that hold it, without validating the payment
+// Characters that the character set can not encode count as one byte.
+func (p *Payment) Size() Size {
+	b := p.payload()
+
+	s := Size{
+		Bytes:     len(b),
+		Remaining: MaxPayloadBytes - len(b),
+		Versions:  make(map[qrcode.Level]int, len(qrcode.Levels)),
+	}
+
+	for _, level := range qrcode.Levels {
+		if v, err := qrcode.VersionFor(b, level); err == nil {
+			s.Versions[level] = v
+		}
+	}
+
+	return s
+}
+
+// payload returns the payload encoded in the character set of the payment, without validating it;
+// characters that the character set can not encode are replaced by '?'
+func (p *Payment) payload() []byte {
+	// The fallback can not fail
+	b, _ := encodeCharacterSet(strings.Join(p.payloadFields(), "\n"), p.CharacterSet, '?')
+
+	return b
+}
@@ -0,0 +1,51 @@
+package payment_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/jovandeginste/payme/qrcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSize(t *testing.T) {
+	p := payment.New()
+	p.NameBeneficiary = "Franz Mustermänn"
+	p.IBANBeneficiary = "DE71110220330123456789"
+	p.EuroAmount = 1230
+	p.Remittance = "Invoice 1234"
+
+	b, err := p.ToBytes()
+	require.NoError(t, err)
+
+	s := p.Size()
+	assert.Equal(t, len(b), s.Bytes)
+	assert.Equal(t, payment.MaxPayloadBytes-len(b), s.Remaining)
+	require.Len(t, s.Versions, len(qrcode.Levels))
+
+	code, err := p.QRCode(qrcode.DefaultOptions())
+	require.NoError(t, err)
+	assert.Equal(t, code.Version, s.Versions[code.Level])
+	assert.Equal(t, code.Size(), s.Modules(code.Level))
+
+	for i := 1; i < len(qrcode.Levels); i++ {
+		assert.GreaterOrEqual(t, s.Versions[qrcode.Levels[i]], s.Versions[qrcode.Levels[i-1]])
+	}
+}
+
+func TestSizeInvalid(t *testing.T) {
+	p := payment.New()
+	p.CharacterSet = payment.CharacterSetUTF8
+	p.NameBeneficiary = strings.Repeat("Ä", 70)
+	p.IBANBeneficiary = "DE71110220330123456789"
+	p.Remittance = strings.Repeat("ä", 140)
+
+	require.ErrorIs(t, p.IsValid(), payment.ErrValidationPayloadTooLong)
+
+	s := p.Size()
+	assert.Greater(t, s.Bytes, payment.MaxPayloadBytes)
+	assert.Negative(t, s.Remaining)
+	assert.NotZero(t, s.Modules(qrcode.LevelM), "the size is known even when the payment is not valid")
+}
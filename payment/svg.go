@@ -0,0 +1,14 @@
+package payment
+
+import "github.com/jovandeginste/payme/qrcode"
+
+// ToQRSVG returns an SVG representation of the QR code, with the default options
+// Every module is one unit in the viewBox, so the image can be scaled to any size
+func (p *Payment) ToQRSVG(opts qrcode.SVGOptions) ([]byte, error) {
+	qrCode, err := p.QRCode(qrcode.DefaultOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	return qrCode.SVG(opts), nil
+}
@@ -0,0 +1,99 @@
+package payment_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/jovandeginste/payme/qrcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	svgViewBox = regexp.MustCompile(`viewBox="0 0 (\d+) (\d+)"`)
+	svgRun     = regexp.MustCompile(`M(\d+) (\d+)h(\d+)v1h-(\d+)z`)
+)
+
+// rasterizeSVG draws the runs of an SVG generated by ToQRSVG as a PNG image
+func rasterizeSVG(t *testing.T, svg []byte, scale int) []byte {
+	t.Helper()
+
+	m := svgViewBox.FindSubmatch(svg)
+	require.NotNil(t, m)
+
+	w, _ := strconv.Atoi(string(m[1]))
+	img := image.NewGray(image.Rect(0, 0, w*scale, w*scale))
+
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+
+	for _, r := range svgRun.FindAllSubmatch(svg, -1) {
+		x, _ := strconv.Atoi(string(r[1]))
+		y, _ := strconv.Atoi(string(r[2]))
+		n, _ := strconv.Atoi(string(r[3]))
+
+		for px := x * scale; px < (x+n)*scale; px++ {
+			for py := y * scale; py < (y+1)*scale; py++ {
+				img.Set(px, py, color.Black)
+			}
+		}
+	}
+
+	var b bytes.Buffer
+	require.NoError(t, png.Encode(&b, img))
+
+	return b.Bytes()
+}
+
+func TestToQRSVG(t *testing.T) {
+	p := payment.New()
+
+	p.NameBeneficiary = ExampleName
+	p.IBANBeneficiary = ExampleIBAN
+	p.EuroAmount = 1230
+	p.Remittance = ExampleRemittance
+
+	result, err := p.ToQRSVG(qrcode.DefaultSVGOptions())
+	require.NoError(t, err)
+
+	assert.Contains(t, string(result), `viewBox="0 0 53 53"`)
+	assert.Contains(t, string(result), `<rect width="53" height="53" fill="#ffffff"/>`)
+	assert.NotContains(t, string(result), `width="300"`)
+
+	decoded, err := payment.FromQRImage(bytes.NewReader(rasterizeSVG(t, result, 4)))
+	require.NoError(t, err)
+	assert.Equal(t, ExampleRemittance, decoded.Remittance)
+}
+
+func TestToQRSVGOptions(t *testing.T) {
+	p := payment.New()
+
+	p.NameBeneficiary = ExampleName
+	p.IBANBeneficiary = ExampleIBAN
+	p.EuroAmount = 1230
+	p.Remittance = ExampleRemittance
+
+	opts := qrcode.DefaultOptions()
+	opts.QuietZone = 0
+
+	result, err := qrcode.ToSVG(p, opts, qrcode.SVGOptions{
+		Size:       "4cm",
+		Foreground: "navy",
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, string(result), `viewBox="0 0 45 45"`)
+	assert.Contains(t, string(result), `width="4cm" height="4cm"`)
+	assert.Contains(t, string(result), `<path fill="navy" d="M0 0h7v1h-7z`)
+	assert.NotContains(t, string(result), "<rect")
+
+	_, err = payment.New().ToQRSVG(qrcode.DefaultSVGOptions())
+	require.Error(t, err)
+}
@@ -2,7 +2,10 @@ package payment
 
 import (
 	"errors"
+	"fmt"
 	"regexp"
+	"strconv"
+	"unicode/utf8"
 )
 
 const (
@@ -23,7 +26,7 @@ var (
 	// ErrValidationBICBeneficiary is returned when BICBeneficiary is not set
 	ErrValidationBICBeneficiary = errors.New("field 'BICBeneficiary' is required when version is 1")
 	// ErrValidationEuroAmount is returned when EuroAmount is not a valid amount
-	ErrValidationEuroAmount = errors.New("field 'EuroAmount' must be 0.01 or more and 999999999.99 or less")
+	ErrValidationEuroAmount = errors.New("field 'EuroAmount' must be 0 (open amount), or 0.01 or more and 999999999.99 or less")
 	// ErrValidationPurpose is returned when Purpose is not within bounds
 	ErrValidationPurpose = errors.New("field 'Purpose' should not exceed 4 characters")
 
@@ -31,108 +34,182 @@ var (
 	ErrValidationRemittanceRequired = errors.New("field 'Remittance' is required")
 	// ErrValidationRemittanceStructuredTooLong is returned when Remittance is not within bounds for structured field
 	ErrValidationRemittanceStructuredTooLong = errors.New("structured 'Remittance' should not exceed 35 characters")
+	// ErrValidationRemittanceStructuredRFReference is returned when structured Remittance starts with RF but is not a valid ISO 11649 reference
+	ErrValidationRemittanceStructuredRFReference = errors.New("structured 'Remittance' is not a valid RF creditor reference")
+	// ErrValidationRemittanceStructuredOGM is returned when structured Remittance is a Belgian structured communication with invalid check digits
+	ErrValidationRemittanceStructuredOGM = errors.New("structured 'Remittance' is not a valid Belgian structured communication (OGM/VCS)")
 	// ErrValidationRemittanceUnstructuredTooLong is returned when Remittance is not within bounds for unstructured field
 	ErrValidationRemittanceUnstructuredTooLong = errors.New("unstructured 'Remittance' should not exceed 140 characters")
 	// ErrValidationRemittanceUnstructuredCharacters is returned when Remittance contains invalid characters
 	ErrValidationRemittanceUnstructuredCharacters = errors.New("unstructured 'Remittance' should only contain alpha-numerics, spaces and/or " + specialChars)
 
+	// ErrValidationIBANBeneficiary is returned when IBANBeneficiary is not a valid IBAN
+	ErrValidationIBANBeneficiary = errors.New("field 'IBANBeneficiary' should be a valid IBAN")
+
 	// ErrValidationNameBeneficiaryRequired is returned when NameBeneficiary is empty
 	ErrValidationNameBeneficiaryRequired = errors.New("field 'NameBeneficiary' is required")
 	// ErrValidationNameBeneficiaryTooLong is returned when NameBeneficiary is not within bounds
 	ErrValidationNameBeneficiaryTooLong = errors.New("field 'NameBeneficiary' should not exceed 70 characers")
 	// ErrValidationNameBeneficiaryCharacters is returned when NameBeneficiary contains invalid characters
 	ErrValidationNameBeneficiaryCharacters = errors.New("field 'NameBeneficiary' should not only contain alpha-numerics, spaces and/or " + specialChars)
+
+	// ErrValidationB2OInformationTooLong is returned when B2OInformation is not within bounds
+	ErrValidationB2OInformationTooLong = errors.New("field 'B2OInformation' should not exceed 70 characters")
+	// ErrValidationB2OInformationCharacters is returned when B2OInformation contains invalid characters
+	ErrValidationB2OInformationCharacters = errors.New("field 'B2OInformation' should only contain alpha-numerics, spaces and/or " + specialChars)
+
+	// ErrValidationPayloadTooLong is returned when the encoded payload exceeds MaxPayloadBytes
+	ErrValidationPayloadTooLong = errors.New("the payload should not exceed 331 bytes")
 )
 
 // IsValid checks if all fields in the payment are consistent and meet the requirements.
-// It returns the first error it encounters, or nil if all is well.
+// It returns nil if all is well, or ValidationErrors with every problem it finds.
 func (p *Payment) IsValid() error {
 	return p.validateFields()
 }
 
 func (p *Payment) validateFields() error {
-	if err := p.validateHeader(); err != nil {
-		return err
-	}
+	var errs ValidationErrors
+
+	errs.merge(p.validateHeader())
+	errs.merge(p.validateBIC())
+	errs.merge(p.validateBeneficiary())
 
-	if err := p.validateBeneficiary(); err != nil {
-		return err
+	if !p.IsOpenAmount() && (p.EuroAmount < MinAmount || p.EuroAmount > MaxAmount) {
+		errs.add("EuroAmount", "AT-04", RuleRange, "0.01..999999999.99", ErrValidationEuroAmount)
 	}
 
-	if p.EuroAmount < 0.01 || p.EuroAmount > 999999999.99 {
-		return ErrValidationEuroAmount
+	if utf8.RuneCountInString(p.PurposeString()) > 4 {
+		errs.add("Purpose", "AT-44", RuleMaxLength, "4", ErrValidationPurpose)
 	}
 
-	if len(p.PurposeString()) > 4 {
-		return ErrValidationPurpose
+	errs.merge(p.validateRemittance())
+	errs.merge(p.validateB2OInformation())
+	errs.merge(p.validateCharacterSet())
+
+	if n := len(p.payload()); n > MaxPayloadBytes {
+		errs.add("Payload", "", RuleMaxLength, strconv.Itoa(MaxPayloadBytes),
+			fmt.Errorf("%w: it is %d bytes in %s", ErrValidationPayloadTooLong, n, p.CharacterSetName()))
 	}
 
-	return p.validateRemittance()
+	return errs.err()
 }
 
 func (p *Payment) validateHeader() error {
+	var errs ValidationErrors
+
 	if p.ServiceTag != "BCD" {
-		return ErrValidationServiceTag
+		errs.add("ServiceTag", "", RuleValue, "BCD", ErrValidationServiceTag)
 	}
 
-	if p.CharacterSet < 1 || p.CharacterSet > 8 {
-		return ErrValidationCharacterSet
+	if p.Version != 1 && p.Version != 2 {
+		errs.add("Version", "", RuleValue, "1, 2", ErrValidationVersion)
 	}
 
-	if p.Version != 1 && p.Version != 2 {
-		return ErrValidationVersion
+	if p.CharacterSet < 1 || p.CharacterSet > 8 {
+		errs.add("CharacterSet", "", RuleRange, "1..8", ErrValidationCharacterSet)
 	}
 
 	if p.IdentificationCode != "SCT" {
-		return ErrValidationIdentificationCode
+		errs.add("IdentificationCode", "", RuleValue, "SCT", ErrValidationIdentificationCode)
 	}
 
 	if p.Version == 1 && p.BICBeneficiary == "" {
-		return ErrValidationBICBeneficiary
+		errs.add("BICBeneficiary", "AT-23", RuleRequired, "", ErrValidationBICBeneficiary)
 	}
 
-	return nil
+	return errs.err()
 }
 
 func (p *Payment) validateRemittance() error {
 	if p.Remittance == "" {
-		return ErrValidationRemittanceRequired
+		return ValidationErrors{{Field: "Remittance", Code: "AT-05", Rule: RuleRequired, Err: ErrValidationRemittanceRequired}}
 	}
 
-	if p.RemittanceIsStructured && len(p.Remittance) > 35 {
-		return ErrValidationRemittanceStructuredTooLong
+	if p.RemittanceIsStructured {
+		return p.validateStructuredRemittance()
 	}
 
-	if !p.RemittanceIsStructured {
-		if len(p.Remittance) > 140 {
-			return ErrValidationRemittanceUnstructuredTooLong
-		}
+	var errs ValidationErrors
+
+	if utf8.RuneCountInString(p.Remittance) > 140 {
+		errs.add("Remittance", "AT-05", RuleMaxLength, "140", ErrValidationRemittanceUnstructuredTooLong)
+	}
+
+	if !stringValidator.MatchString(p.Remittance) {
+		errs.add("Remittance", "AT-05", RuleCharacters, specialChars, ErrValidationRemittanceUnstructuredCharacters)
+	}
+
+	return errs.err()
+}
+
+func (p *Payment) validateStructuredRemittance() error {
+	var errs ValidationErrors
 
-		if !stringValidator.MatchString(p.Remittance) {
-			return ErrValidationRemittanceUnstructuredCharacters
+	ref := p.RemittanceStructured()
+
+	if utf8.RuneCountInString(ref) > 35 {
+		errs.add("Remittance", "AT-05", RuleMaxLength, "35", ErrValidationRemittanceStructuredTooLong)
+	}
+
+	switch {
+	case isOGMCandidate(ref):
+		if err := ValidateOGMReference(ref); err != nil {
+			errs.add("Remittance", "AT-05", RuleFormat, "OGM", fmt.Errorf("%w: %w", ErrValidationRemittanceStructuredOGM, err))
+		}
+	case isRFCandidate(ref):
+		if err := ValidateRFReference(ref); err != nil {
+			errs.add("Remittance", "AT-05", RuleFormat, "ISO 11649", fmt.Errorf("%w: %w", ErrValidationRemittanceStructuredRFReference, err))
 		}
 	}
 
-	return nil
+	return errs.err()
 }
 
-func (p *Payment) validateBeneficiary() error {
-	if p.NameBeneficiary == "" {
-		return ErrValidationNameBeneficiaryRequired
+func (p *Payment) validateB2OInformation() error {
+	if p.B2OInformation == "" {
+		return nil
 	}
 
-	if len(p.NameBeneficiary) > 70 {
-		return ErrValidationNameBeneficiaryTooLong
+	var errs ValidationErrors
+
+	if utf8.RuneCountInString(p.B2OInformation) > 70 {
+		errs.add("B2OInformation", "", RuleMaxLength, "70", ErrValidationB2OInformationTooLong)
 	}
 
-	if !stringValidator.MatchString(p.NameBeneficiary) {
-		return ErrValidationNameBeneficiaryCharacters
+	if !stringValidator.MatchString(p.B2OInformation) {
+		errs.add("B2OInformation", "", RuleCharacters, specialChars, ErrValidationB2OInformationCharacters)
 	}
 
-	return p.validateIBAN()
+	return errs.err()
+}
+
+func (p *Payment) validateBeneficiary() error {
+	var errs ValidationErrors
+
+	switch {
+	case p.NameBeneficiary == "":
+		errs.add("NameBeneficiary", "AT-21", RuleRequired, "", ErrValidationNameBeneficiaryRequired)
+	default:
+		if utf8.RuneCountInString(p.NameBeneficiary) > 70 {
+			errs.add("NameBeneficiary", "AT-21", RuleMaxLength, "70", ErrValidationNameBeneficiaryTooLong)
+		}
+
+		if !stringValidator.MatchString(p.NameBeneficiary) {
+			errs.add("NameBeneficiary", "AT-21", RuleCharacters, specialChars, ErrValidationNameBeneficiaryCharacters)
+		}
+	}
+
+	errs.merge(p.validateIBAN())
+
+	return errs.err()
 }
 
 func (p *Payment) validateIBAN() error {
-	_, err := p.IBAN()
-	return err
+	if _, err := p.IBAN(); err != nil {
+		return ValidationErrors{{Field: "IBANBeneficiary", Code: "AT-20", Rule: RuleFormat, Limit: "IBAN",
+			Err: fmt.Errorf("%w: %w", ErrValidationIBANBeneficiary, err)}}
+	}
+
+	return nil
 }
@@ -2,6 +2,7 @@ package payment
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -68,30 +69,31 @@ func TestValidateNew(t *testing.T) {
 func TestValidateHeader(t *testing.T) {
 	p := validPayment()
 	p.ServiceTag = "ABC"
-	require.ErrorIs(t, ErrValidationServiceTag, p.validateHeader())
-	require.ErrorIs(t, ErrValidationServiceTag, p.validateFields())
+	require.ErrorIs(t, p.validateHeader(), ErrValidationServiceTag)
+	require.ErrorIs(t, p.validateFields(), ErrValidationServiceTag)
 
 	p = validPayment()
 	p.CharacterSet = 0
-	require.ErrorIs(t, ErrValidationCharacterSet, p.validateHeader())
+	require.ErrorIs(t, p.validateHeader(), ErrValidationCharacterSet)
 
 	p = validPayment()
 	p.CharacterSet = 9
-	require.ErrorIs(t, ErrValidationCharacterSet, p.validateHeader())
+	require.ErrorIs(t, p.validateHeader(), ErrValidationCharacterSet)
 
 	p = validPayment()
 	p.Version = 0
-	require.ErrorIs(t, ErrValidationVersion, p.validateHeader())
+	require.ErrorIs(t, p.validateHeader(), ErrValidationVersion)
 
 	p = validPayment()
 	p.Version = 1
 	p.BICBeneficiary = "XYZ"
 	require.NoError(t, p.validateHeader())
+	require.ErrorIs(t, p.validateBIC(), ErrValidationBICFormat)
 
 	p = validPayment()
 	p.Version = 1
 	p.BICBeneficiary = ""
-	require.ErrorIs(t, ErrValidationBICBeneficiary, p.validateHeader())
+	require.ErrorIs(t, p.validateHeader(), ErrValidationBICBeneficiary)
 
 	p = validPayment()
 	p.Version = 2
@@ -99,32 +101,32 @@ func TestValidateHeader(t *testing.T) {
 
 	p = validPayment()
 	p.Version = 3
-	require.ErrorIs(t, ErrValidationVersion, p.validateHeader())
+	require.ErrorIs(t, p.validateHeader(), ErrValidationVersion)
 
 	p = validPayment()
 	p.IdentificationCode = "DEF"
-	require.ErrorIs(t, ErrValidationIdentificationCode, p.validateHeader())
+	require.ErrorIs(t, p.validateHeader(), ErrValidationIdentificationCode)
 }
 
 func TestValidateFields(t *testing.T) {
 	p := validPayment()
 
-	for _, a := range []float64{-1, 0, 0.001, 0.00999, 999999999.991, 1000000000} {
+	for _, a := range []Amount{-1, -100, MaxAmount + 1, 100000000000} {
 		p.EuroAmount = a
-		require.ErrorIs(t, ErrValidationEuroAmount, p.validateFields(), fmt.Sprintf("Amount: %f", a))
+		require.ErrorIs(t, p.validateFields(), ErrValidationEuroAmount, fmt.Sprintf("Amount: %s", a))
 	}
 
-	for _, a := range []float64{0.01, 0.1, 1, 2.05, 99, 123456.78, 999999999.99} {
+	for _, a := range []Amount{0, 1, 10, 100, 205, 9900, 12345678, MaxAmount} {
 		p.EuroAmount = a
-		require.NoError(t, p.validateFields(), fmt.Sprintf("Amount: %f", a))
+		require.NoError(t, p.validateFields(), fmt.Sprintf("Amount: %s", a))
 	}
 
 	p = validPayment()
-	p.EuroAmount = 1
+	p.EuroAmount = 100
 
 	for _, n := range []string{"ABCDEF", "AB CD EF"} {
 		p.Purpose = n
-		require.ErrorIs(t, ErrValidationPurpose, p.validateFields(), "Purpose: "+n)
+		require.ErrorIs(t, p.validateFields(), ErrValidationPurpose, "Purpose: "+n)
 	}
 
 	for _, n := range []string{"ABCD", "AB CD", "A B C D"} {
@@ -133,37 +135,86 @@ func TestValidateFields(t *testing.T) {
 	}
 }
 
+func TestValidatePayloadSize(t *testing.T) {
+	p := validPayment()
+	p.NameBeneficiary = strings.Repeat("Ä", 70)
+	p.Remittance = strings.Repeat("ä", 140)
+	p.CharacterSet = 2
+	require.NoError(t, p.validateFields(), "every character is one byte in ISO 8859-1")
+
+	p.CharacterSet = CharacterSetUTF8
+	err := p.validateFields()
+	require.ErrorIs(t, err, ErrValidationPayloadTooLong, "every character is two bytes in UTF-8")
+
+	var errs ValidationErrors
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "Payload", errs[0].Field)
+	assert.Equal(t, RuleMaxLength, errs[0].Rule)
+	assert.Equal(t, "331", errs[0].Limit)
+}
+
 func TestValidateRemittance(t *testing.T) {
 	p := validPayment()
 	p.Remittance = ""
-	p.EuroAmount = 1
+	p.EuroAmount = 100
 
-	require.ErrorIs(t, ErrValidationRemittanceRequired, p.validateRemittance())
-	require.ErrorIs(t, ErrValidationRemittanceRequired, p.validateFields())
+	require.ErrorIs(t, p.validateRemittance(), ErrValidationRemittanceRequired)
+	require.ErrorIs(t, p.validateFields(), ErrValidationRemittanceRequired)
 
 	p.RemittanceIsStructured = true
 	p.Remittance = str40chars
-	require.ErrorIs(t, ErrValidationRemittanceStructuredTooLong, p.validateRemittance())
+	require.ErrorIs(t, p.validateRemittance(), ErrValidationRemittanceStructuredTooLong)
+
+	p.Remittance = "RF18539007547034"
+	require.NoError(t, p.validateRemittance())
+
+	p.Remittance = "RF18 5390 0754 7034"
+	require.NoError(t, p.validateRemittance())
+
+	p.Remittance = "RF19539007547034"
+	require.ErrorIs(t, p.validateRemittance(), ErrValidationRemittanceStructuredRFReference)
+	require.ErrorIs(t, p.validateRemittance(), ErrRFReferenceChecksum)
+
+	p.Remittance = "RF18-5390"
+	require.ErrorIs(t, p.validateRemittance(), ErrRFReferenceFormat)
 
 	p.RemittanceIsStructured = false
 	p.Remittance = str40chars + str40chars + str40chars + str40chars // 160 characters
-	require.ErrorIs(t, ErrValidationRemittanceUnstructuredTooLong, p.validateRemittance())
+	require.ErrorIs(t, p.validateRemittance(), ErrValidationRemittanceUnstructuredTooLong)
 
 	p.RemittanceIsStructured = false
 	p.Remittance = "#!"
-	require.ErrorIs(t, ErrValidationRemittanceUnstructuredCharacters, p.validateRemittance())
+	require.ErrorIs(t, p.validateRemittance(), ErrValidationRemittanceUnstructuredCharacters)
+}
+
+func TestValidateB2OInformation(t *testing.T) {
+	p := validPayment()
+	require.NoError(t, p.validateB2OInformation())
+
+	p.B2OInformation = strings.Repeat("Grüße ", 11) + "Ende" // 70 characters
+	require.NoError(t, p.validateB2OInformation())
+
+	p.B2OInformation = str40chars + str40chars // 80 characters
+	require.ErrorIs(t, p.validateB2OInformation(), ErrValidationB2OInformationTooLong)
+
+	p.B2OInformation = "#!"
+	require.ErrorIs(t, p.validateB2OInformation(), ErrValidationB2OInformationCharacters)
 }
 
 func TestValidateBeneficiary(t *testing.T) {
 	p := validPayment()
 	p.NameBeneficiary = ""
-	require.ErrorIs(t, ErrValidationNameBeneficiaryRequired, p.validateBeneficiary())
+	require.ErrorIs(t, p.validateBeneficiary(), ErrValidationNameBeneficiaryRequired)
 
 	p.NameBeneficiary = str40chars + str40chars // 80 characters
-	require.ErrorIs(t, ErrValidationNameBeneficiaryTooLong, p.validateBeneficiary())
+	require.ErrorIs(t, p.validateBeneficiary(), ErrValidationNameBeneficiaryTooLong)
+
+	p.NameBeneficiary = strings.Repeat("Müller ", 10) // 70 characters, 80 bytes in UTF-8
+	require.NoError(t, p.validateBeneficiary())
 
 	p.NameBeneficiary = "#!"
-	require.ErrorIs(t, ErrValidationNameBeneficiaryCharacters, p.validateBeneficiary())
+	require.ErrorIs(t, p.validateBeneficiary(), ErrValidationNameBeneficiaryCharacters)
 }
 
 func TestValidateIBAN(t *testing.T) {
@@ -173,3 +224,21 @@ func TestValidateIBAN(t *testing.T) {
 	require.Error(t, p.validateIBAN())
 	require.Error(t, p.validateBeneficiary())
 }
+
+func TestEncodeCharacterSet(t *testing.T) {
+	b, err := encodeCharacterSet("Dvořák", 3, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{'D', 'v', 'o', 0xf8, 0xe1, 'k'}, b)
+
+	_, err = encodeCharacterSet("Dvořák €", 3, 0)
+	require.ErrorIs(t, err, ErrValidationCharacterSetUnsupported)
+
+	b, err = encodeCharacterSet("Dvořák €", 3, '?')
+	require.NoError(t, err)
+	assert.Equal(t, []byte{'D', 'v', 'o', 0xf8, 0xe1, 'k', ' ', '?'}, b)
+
+	p := New()
+	p.CharacterSet = 3
+	p.NameBeneficiary = "Dvořák €"
+	assert.Contains(t, string(p.payload()), "Dvo\xf8\xe1k ?", "the size of a payload is measured with the fallback")
+}
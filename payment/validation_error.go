@@ -0,0 +1,179 @@
+package payment
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Rule is the kind of requirement a field does not meet
+type Rule string
+
+const (
+	// RuleRequired means the field is empty
+	RuleRequired Rule = "required"
+	// RuleValue means the field should have a fixed value, or one of a list of values
+	RuleValue Rule = "value"
+	// RuleRange means the value of the field is too small or too large
+	RuleRange Rule = "range"
+	// RuleMaxLength means the field is too long
+	RuleMaxLength Rule = "max-length"
+	// RuleCharacters means the field contains characters that are not allowed
+	RuleCharacters Rule = "characters"
+	// RuleFormat means the field does not have the required format
+	RuleFormat Rule = "format"
+)
+
+// ValidationError describes one field of the payment that does not meet a requirement
+// It wraps one of the ErrValidation* errors, so it can be matched with errors.Is.
+type ValidationError struct {
+	// Field is the name of the field in Payment, eg. NameBeneficiary, or Payload for the payload as a whole
+	Field string
+	// Code is the EPC attribute code of the field, eg. AT-21; it is empty for the header fields
+	Code string
+	// Rule is the kind of requirement the field does not meet
+	Rule Rule
+	// Limit is the value the rule checks against, eg. the maximum length or the allowed special characters;
+	// it may be empty
+	Limit string
+	// Err is the underlying error
+	Err error
+}
+
+// NewValidationError returns the validation error of a field; code is the code of the field in the specification
+// of the format, and may be empty
+func NewValidationError(field, code string, rule Rule, limit string, err error) *ValidationError {
+	return &ValidationError{Field: field, Code: code, Rule: rule, Limit: limit, Err: err}
+}
+
+func (e *ValidationError) Error() string {
+	if e.Code == "" {
+		return e.Err.Error()
+	}
+
+	return e.Code + ": " + e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// MarshalJSON implements json.Marshaler; the field is given by its JSON name (eg. iban), and the error as its message
+func (e *ValidationError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Field   string `json:"field"`
+		Code    string `json:"code,omitempty"`
+		Rule    Rule   `json:"rule"`
+		Limit   string `json:"limit,omitempty"`
+		Message string `json:"message"`
+	}{
+		Field:   jsonFieldName(e.Field),
+		Code:    e.Code,
+		Rule:    e.Rule,
+		Limit:   e.Limit,
+		Message: e.Err.Error(),
+	})
+}
+
+// jsonFieldName returns the JSON name of the field of Payment, or the lower case name if it is not a field
+func jsonFieldName(field string) string {
+	f, ok := reflect.TypeFor[Payment]().FieldByName(field)
+	if !ok {
+		return strings.ToLower(field)
+	}
+
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+
+	return name
+}
+
+// ValidationErrors are all validation errors of a payment, in the order of the fields in the payload
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the individual errors, so every ErrValidation* error can be matched with errors.Is
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+
+	return errs
+}
+
+// add appends a validation error for the field
+func (e *ValidationErrors) add(field, code string, rule Rule, limit string, err error) {
+	*e = append(*e, NewValidationError(field, code, rule, limit, err))
+}
+
+// merge appends the validation errors in err, if any
+func (e *ValidationErrors) merge(err error) {
+	var errs ValidationErrors
+	if errors.As(err, &errs) {
+		*e = append(*e, errs...)
+	}
+}
+
+// err returns the validation errors as an error, or nil if there are none
+func (e ValidationErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+// TextRule is the length and the characters a text field of a payment format may have
+type TextRule struct {
+	// Code is the code of the field in the specification of the format; it may be empty
+	Code string
+	// Required means the field should not be empty
+	Required bool
+	// MaxLength is the maximum number of characters
+	MaxLength int
+	// Charset names the characters that are allowed, eg. printable; it is the limit of a RuleCharacters error
+	Charset string
+	// Allowed returns whether a character is allowed
+	Allowed func(r rune) bool
+	// ErrRequired, ErrTooLong and ErrCharacters are the errors of the format that the validation errors wrap
+	ErrRequired, ErrTooLong, ErrCharacters error
+}
+
+// Validate checks the value of the field against the rule
+// An empty value that is not required is valid; otherwise it reports both a value that is too long, and the
+// first character that is not allowed.
+func (t TextRule) Validate(field, value string) ValidationErrors {
+	if value == "" {
+		if t.Required {
+			return ValidationErrors{NewValidationError(field, t.Code, RuleRequired, "", fmt.Errorf("%w: %s", t.ErrRequired, field))}
+		}
+
+		return nil
+	}
+
+	var errs ValidationErrors
+
+	if n := utf8.RuneCountInString(value); n > t.MaxLength {
+		errs.add(field, t.Code, RuleMaxLength, strconv.Itoa(t.MaxLength),
+			fmt.Errorf("%w: %s has %d characters, the limit is %d", t.ErrTooLong, field, n, t.MaxLength))
+	}
+
+	if i := strings.IndexFunc(value, func(r rune) bool { return !t.Allowed(r) }); i >= 0 {
+		r, _ := utf8.DecodeRuneInString(value[i:])
+		errs.add(field, t.Code, RuleCharacters, t.Charset, fmt.Errorf("%w: %s: %q", t.ErrCharacters, field, r))
+	}
+
+	return errs
+}
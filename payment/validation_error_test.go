@@ -0,0 +1,155 @@
+package payment_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidationErrors(t *testing.T) {
+	p := payment.New()
+	p.CharacterSet = 9
+	p.NameBeneficiary = "No # symbol"
+	p.IBANBeneficiary = "ABC"
+	p.EuroAmount = -1
+	p.Purpose = "ABCDEF"
+
+	err := p.IsValid()
+	require.Error(t, err)
+
+	for _, e := range []error{
+		payment.ErrValidationCharacterSet,
+		payment.ErrValidationNameBeneficiaryCharacters,
+		payment.ErrValidationIBANBeneficiary,
+		payment.ErrValidationEuroAmount,
+		payment.ErrValidationPurpose,
+		payment.ErrValidationRemittanceRequired,
+	} {
+		require.ErrorIs(t, err, e)
+	}
+
+	var errs payment.ValidationErrors
+
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, errs, 6)
+
+	fields := make([]string, len(errs))
+	codes := make([]string, len(errs))
+
+	for i, e := range errs {
+		fields[i] = e.Field
+		codes[i] = e.Code
+	}
+
+	assert.Equal(t, []string{"CharacterSet", "NameBeneficiary", "IBANBeneficiary", "EuroAmount", "Purpose", "Remittance"}, fields)
+	assert.Equal(t, []string{"", "AT-21", "AT-20", "AT-04", "AT-44", "AT-05"}, codes)
+
+	assert.Equal(t, payment.RuleRange, errs[0].Rule)
+	assert.Equal(t, "1..8", errs[0].Limit)
+	assert.Equal(t, payment.RuleMaxLength, errs[4].Rule)
+	assert.Equal(t, "4", errs[4].Limit)
+	assert.Equal(t, payment.RuleRequired, errs[5].Rule)
+
+	assert.Contains(t, err.Error(), "AT-44: field 'Purpose' should not exceed 4 characters\n")
+}
+
+func TestValidationError(t *testing.T) {
+	p := payment.New()
+	p.NameBeneficiary = "Franz Mustermänn"
+	p.IBANBeneficiary = "DE71110220330123456789"
+	p.EuroAmount = 1230
+	p.Remittance = "RF19539007547034"
+	p.RemittanceIsStructured = true
+
+	err := p.IsValid()
+
+	var e *payment.ValidationError
+
+	require.ErrorAs(t, err, &e)
+	assert.Equal(t, "Remittance", e.Field)
+	assert.Equal(t, "AT-05", e.Code)
+	assert.Equal(t, payment.RuleFormat, e.Rule)
+	assert.True(t, errors.Is(e, payment.ErrValidationRemittanceStructuredRFReference))
+	assert.True(t, errors.Is(e, payment.ErrRFReferenceChecksum))
+	assert.Equal(t, "AT-05: structured 'Remittance' is not a valid RF creditor reference: RF reference has invalid check digits", e.Error())
+}
+
+func TestValidationErrorsJSON(t *testing.T) {
+	p := payment.New()
+	p.NameBeneficiary = "Jane Doe"
+	p.IBANBeneficiary = "BE68539007547034"
+	p.Purpose = "ABCDEF"
+	p.Remittance = "Invoice 1234"
+
+	var errs payment.ValidationErrors
+	require.ErrorAs(t, p.IsValid(), &errs)
+
+	b, err := json.Marshal(errs)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{
+		"field": "purpose",
+		"code": "AT-44",
+		"rule": "max-length",
+		"limit": "4",
+		"message": "field 'Purpose' should not exceed 4 characters"
+	}]`, string(b))
+}
+
+func TestValidationErrorsJSONPayload(t *testing.T) {
+	p := payment.New()
+	p.CharacterSet = payment.CharacterSetUTF8
+	p.NameBeneficiary = strings.Repeat("Ä", 70)
+	p.IBANBeneficiary = "BE68539007547034"
+	p.Remittance = strings.Repeat("ä", 140)
+
+	var errs payment.ValidationErrors
+	require.ErrorAs(t, p.IsValid(), &errs)
+
+	b, err := json.Marshal(errs)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{
+		"field": "payload",
+		"rule": "max-length",
+		"limit": "331",
+		"message": "the payload should not exceed 331 bytes: it is 459 bytes in UTF-8"
+	}]`, string(b))
+}
+
+func TestTextRule(t *testing.T) {
+	errRequired := errors.New("required")
+	errTooLong := errors.New("too long")
+	errCharacters := errors.New("characters")
+
+	rule := payment.TextRule{
+		Code:          "X-1",
+		Required:      true,
+		MaxLength:     5,
+		Charset:       "ASCII",
+		Allowed:       func(r rune) bool { return r < 0x80 },
+		ErrRequired:   errRequired,
+		ErrTooLong:    errTooLong,
+		ErrCharacters: errCharacters,
+	}
+
+	assert.Empty(t, rule.Validate("Name", "Jane"))
+
+	errs := rule.Validate("Name", "")
+	require.Len(t, errs, 1)
+	require.ErrorIs(t, errs, errRequired)
+	assert.Equal(t, payment.RuleRequired, errs[0].Rule)
+
+	errs = rule.Validate("Name", "Jane Doë")
+	require.Len(t, errs, 2)
+	assert.Equal(t, &payment.ValidationError{Field: "Name", Code: "X-1", Rule: payment.RuleMaxLength, Limit: "5", Err: errs[0].Err}, errs[0])
+	require.ErrorIs(t, errs[0], errTooLong)
+	assert.Equal(t, "X-1: characters: Name: 'ë'", errs[1].Error())
+	assert.Equal(t, "ASCII", errs[1].Limit)
+
+	rule.Required = false
+	assert.Empty(t, rule.Validate("Name", ""))
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/spf13/cobra"
+)
+
+// ErrProfileArgs is returned when the settings are not given as pairs of a key and a value
+var ErrProfileArgs = errors.New("expected a profile name, followed by pairs of a setting and a value")
+
+func profileCmd(q *qrParams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Manage the beneficiary profiles in the config file",
+		Long: `Manage the beneficiary profiles in the config file ($XDG_CONFIG_HOME/payme/config.yaml, or set with --config).
+
+A profile holds default values for the flags of payme, with the flag names as settings (eg. name, iban, purpose,
+character-set, ec-level, output). Select a profile with --profile or PAYME_PROFILE, or set a default one.
+Flags on the command line take precedence over environment variables (eg. PAYME_IBAN), which take precedence
+over the profile.`,
+		Args: cobra.NoArgs,
+	}
+
+	cmd.AddCommand(profileListCmd(q))
+	cmd.AddCommand(profileShowCmd(q))
+	cmd.AddCommand(profileSetCmd(q))
+
+	return cmd
+}
+
+func profileListCmd(q *qrParams) *cobra.Command {
+	return &cobra.Command{
+		Use:          "list",
+		Short:        "List the profiles; the selected one is marked with *",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, err := q.loadConfig(false)
+			if err != nil {
+				return err
+			}
+
+			selected := q.selectedProfile(c)
+
+			for _, name := range c.profiles() {
+				marker := " "
+				if name == selected {
+					marker = "*"
+				}
+
+				fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", marker, name)
+			}
+
+			return nil
+		},
+	}
+}
+
+func profileShowCmd(q *qrParams) *cobra.Command {
+	return &cobra.Command{
+		Use:          "show [profile]",
+		Short:        "Show the settings of a profile, by default the selected one",
+		Args:         cobra.MaximumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := q.loadConfig(false)
+			if err != nil {
+				return err
+			}
+
+			name := q.selectedProfile(c)
+			if len(args) == 1 {
+				name = args[0]
+			}
+
+			settings, err := c.profile(name)
+			if err != nil {
+				return err
+			}
+
+			keys := make([]string, 0, len(settings))
+			for k := range settings {
+				keys = append(keys, k)
+			}
+
+			slices.Sort(keys)
+
+			for _, k := range keys {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s: %s\n", k, settings[k])
+			}
+
+			return nil
+		},
+	}
+}
+
+func profileSetCmd(q *qrParams) *cobra.Command {
+	var setDefault bool
+
+	cmd := &cobra.Command{
+		Use:   "set <profile> [<setting> <value>]...",
+		Short: "Change settings of a profile, creating it if needed",
+		Example: `  payme profile set association name "Franz Mustermänn" iban DE71110220330123456789
+  payme profile set company ec-level Q output pdf --default`,
+		Args: func(_ *cobra.Command, args []string) error {
+			if len(args)%2 != 1 {
+				return ErrProfileArgs
+			}
+
+			return nil
+		},
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := q.loadConfig(true)
+			if err != nil {
+				return err
+			}
+
+			name := args[0]
+
+			for i := 1; i < len(args); i += 2 {
+				key, value := strings.ToLower(args[i]), args[i+1]
+
+				if err := validateSetting(key, value); err != nil {
+					return err
+				}
+
+				if err := c.set(name, key, value); err != nil {
+					return err
+				}
+			}
+
+			if setDefault {
+				c.setDefault(name)
+			}
+
+			return c.write()
+		},
+	}
+
+	cmd.Flags().BoolVar(&setDefault, "default", false, "Use this profile when none is selected with --profile")
+
+	return cmd
+}
+
+// validateSetting checks that the setting is a flag that accepts the value
+func validateSetting(key, value string) error {
+	cmdRoot, err := newCommand(&qrParams{Payment: payment.New()})
+	if err != nil {
+		return err
+	}
+
+	f := cmdRoot.Flags().Lookup(key)
+	if f == nil || !configurable(f) {
+		return fmt.Errorf("%w: %q", ErrProfileSetting, key)
+	}
+
+	if err := f.Value.Set(value); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runProfile(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	q := qrParams{
+		Payment: payment.New(),
+	}
+
+	cmdRoot, err := newCommand(&q)
+	require.NoError(t, err)
+
+	out := new(bytes.Buffer)
+
+	cmdRoot.SetOut(out)
+	cmdRoot.SetErr(new(bytes.Buffer))
+	cmdRoot.SetArgs(append([]string{"profile"}, args...))
+
+	err = cmdRoot.Execute()
+
+	return out.String(), err
+}
+
+func TestProfileList(t *testing.T) {
+	writeTestConfig(t, testConfig)
+
+	out, err := runProfile(t, "list")
+	require.NoError(t, err)
+	assert.Equal(t, "* association\n  company\n", out)
+
+	out, err = runProfile(t, "list", "--profile", "company")
+	require.NoError(t, err)
+	assert.Equal(t, "  association\n* company\n", out)
+}
+
+func TestProfileShow(t *testing.T) {
+	writeTestConfig(t, testConfig)
+
+	out, err := runProfile(t, "show")
+	require.NoError(t, err)
+	assert.Equal(t, "ec-level: Q\niban: DE71110220330123456789\nname: Franz Mustermänn\npurpose: CHAR\n", out)
+
+	out, err = runProfile(t, "show", "company")
+	require.NoError(t, err)
+	assert.Contains(t, out, "qr-version: 1\n")
+
+	_, err = runProfile(t, "show", "missing")
+	require.ErrorIs(t, err, ErrProfileNotFound)
+}
+
+func TestProfileSet(t *testing.T) {
+	path := writeTestConfig(t, testConfig)
+	require.NoError(t, os.Remove(path))
+
+	_, err := runProfile(t, "set", "personal", "name", "Jo Doe", "iban", "NL91ABNA0417164300", "--default")
+	require.NoError(t, err)
+
+	_, err = runProfile(t, "set", "personal", "ec-level", "H")
+	require.NoError(t, err)
+
+	out, err := runProfile(t, "show")
+	require.NoError(t, err)
+	assert.Equal(t, "ec-level: H\niban: NL91ABNA0417164300\nname: Jo Doe\n", out)
+
+	q, err := runGenerate(t)
+	require.NoError(t, err)
+	assert.Equal(t, "Jo Doe", q.Payment.NameBeneficiary)
+
+	path = filepath.Join(t.TempDir(), "new", "config.yaml")
+
+	_, err = runProfile(t, "--config", path, "set", "other", "name", "Other", "iban", "BE68539007547034")
+	require.NoError(t, err, "profile set creates the config file selected with --config")
+
+	out, err = runProfile(t, "--config", path, "show", "other")
+	require.NoError(t, err)
+	assert.Equal(t, "iban: BE68539007547034\nname: Other\n", out)
+
+	_, err = runProfile(t, "--config", filepath.Join(t.TempDir(), "missing.yaml"), "list")
+	require.ErrorIs(t, err, fs.ErrNotExist, "read-only commands need the config file to exist")
+
+	for _, args := range [][]string{
+		{"set"},
+		{"set", "personal", "name"},
+		{"set", "personal", "nmae", "Jo"},
+		{"set", "personal", "qr-version", "two"},
+		{"set", "personal", "help", "true"},
+		{"set", "per.sonal", "name", "Jo"},
+	} {
+		_, err := runProfile(t, args...)
+		require.Error(t, err, args)
+	}
+}
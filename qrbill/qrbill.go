@@ -0,0 +1,409 @@
+// Package qrbill encodes Swiss QR-bills: the SPC payload of the QR code with the Swiss cross, and the payment part
+// with receipt that is printed below an invoice.
+//
+// See: https://www.six-group.com/en/products-services/banking-services/payment-standardization/standards/qr-bill.html
+package qrbill
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/almerlucke/go-iban/iban"
+	"github.com/jovandeginste/payme/payment"
+)
+
+// Currency is the currency of a QR-bill
+type Currency string
+
+const (
+	// CurrencyCHF is the Swiss franc; this is the default
+	CurrencyCHF Currency = "CHF"
+	// CurrencyEUR is the euro
+	CurrencyEUR Currency = "EUR"
+)
+
+// ReferenceType is the type of the payment reference of a QR-bill
+type ReferenceType string
+
+const (
+	// ReferenceQRR is a QR reference of 27 digits; it is required with a QR-IBAN
+	ReferenceQRR ReferenceType = "QRR"
+	// ReferenceSCOR is an ISO 11649 creditor reference (RF); it can only be used with an IBAN
+	ReferenceSCOR ReferenceType = "SCOR"
+	// ReferenceNON is no reference; it can only be used with an IBAN
+	ReferenceNON ReferenceType = "NON"
+)
+
+const (
+	// qrType is the first line of the payload
+	qrType = "SPC"
+	// version is the version of the implementation guidelines, 2.0
+	version = "0200"
+	// coding is the character set of the payload: UTF-8, restricted to the Latin character set
+	coding = "1"
+	// addressStructured is the address type of a structured address
+	addressStructured = "S"
+	// trailer marks the end of the payment data
+	trailer = "EPD"
+
+	// MaxPayloadCharacters is the largest payload of a QR-bill, in characters
+	MaxPayloadCharacters = 997
+	// maxAdditionalInformation is the largest message and bill information together, in characters
+	maxAdditionalInformation = 140
+	// maxAlternativeSchemes is the largest number of alternative procedures
+	maxAlternativeSchemes = 2
+
+	// qrIIDMin and qrIIDMax are the range of the institution IDs of QR-IBANs
+	qrIIDMin = 30000
+	qrIIDMax = 31999
+)
+
+var (
+	countryValidator = regexp.MustCompile(`^[A-Z]{2}$`)
+
+	// ErrValidationAccount is returned when the account is not a valid IBAN of Switzerland or Liechtenstein
+	ErrValidationAccount = errors.New("field 'Account' should be a valid IBAN or QR-IBAN of Switzerland (CH) or Liechtenstein (LI)")
+	// ErrValidationAmount is returned when the amount is not 0 (open amount), or 0.01 or more and 999999999.99 or less
+	ErrValidationAmount = errors.New("field 'Amount' must be 0 (open amount), or 0.01 or more and 999999999.99 or less")
+	// ErrValidationCurrency is returned when the currency is not CHF or EUR
+	ErrValidationCurrency = errors.New("field 'Currency' should be CHF or EUR")
+	// ErrValidationReferenceType is returned when the reference type is not QRR, SCOR or NON, or does not fit the account
+	ErrValidationReferenceType = errors.New("field 'ReferenceType' should be QRR with a QR-IBAN, and SCOR or NON with an IBAN")
+	// ErrValidationReferenceQRR is returned when the reference is not a valid QR reference
+	ErrValidationReferenceQRR = errors.New("field 'Reference' is not a valid QR reference")
+	// ErrValidationReferenceSCOR is returned when the reference is not a valid ISO 11649 creditor reference
+	ErrValidationReferenceSCOR = errors.New("field 'Reference' is not a valid RF creditor reference")
+	// ErrValidationReferenceNON is returned when there is a reference with reference type NON
+	ErrValidationReferenceNON = errors.New("field 'Reference' should be empty for reference type NON")
+	// ErrValidationRequired is returned when a required field is empty
+	ErrValidationRequired = errors.New("field is required")
+	// ErrValidationTooLong is returned when a field exceeds its maximum length
+	ErrValidationTooLong = errors.New("field is too long")
+	// ErrValidationCharacters is returned when a field contains characters outside the Latin character set
+	ErrValidationCharacters = errors.New("field should only contain characters of the Latin character set")
+	// ErrValidationCountry is returned when the country of an address is not a 2-letter ISO 3166 code
+	ErrValidationCountry = errors.New("field should be a 2-letter country code (ISO 3166-1)")
+	// ErrValidationAdditionalInformation is returned when the message and bill information together are too long
+	ErrValidationAdditionalInformation = errors.New("fields 'Message' and 'BillInformation' should not exceed 140 characters together")
+	// ErrValidationAlternativeSchemes is returned when there are more than 2 alternative procedures
+	ErrValidationAlternativeSchemes = errors.New("field 'AlternativeSchemes' should have at most 2 procedures")
+	// ErrValidationPayloadTooLong is returned when the payload exceeds MaxPayloadCharacters
+	ErrValidationPayloadTooLong = errors.New("the payload should not exceed 997 characters")
+)
+
+// Address is a structured address of the creditor or the debtor
+type Address struct {
+	// Name is the name of the person or company (required, up to 70 characters)
+	Name string
+	// Street is the street (up to 70 characters)
+	Street string
+	// BuildingNumber is the number of the building in the street (up to 16 characters)
+	BuildingNumber string
+	// PostalCode is the postal code, without a country prefix (required, up to 16 characters)
+	PostalCode string
+	// Town is the town (required, up to 35 characters)
+	Town string
+	// Country is the 2-letter ISO 3166-1 country code, eg. CH (required)
+	Country string
+}
+
+// IsEmpty returns whether no field of the address is set
+func (a *Address) IsEmpty() bool {
+	return *a == Address{}
+}
+
+// lines returns the address lines of the payload: the address type and the fields
+func (a *Address) lines() []string {
+	if a.IsEmpty() {
+		return make([]string, 7)
+	}
+
+	return []string{addressStructured, a.Name, a.Street, a.BuildingNumber, a.PostalCode, a.Town, a.Country}
+}
+
+// Bill encapsulates the fields of a Swiss QR-bill
+type Bill struct {
+	// Account is the IBAN or QR-IBAN of the creditor, of Switzerland (CH) or Liechtenstein (LI)
+	Account string
+	// Creditor is the name and address of the creditor
+	Creditor Address
+	// Amount is the amount in cents; leave it 0 for an open amount, which the debtor fills in
+	Amount payment.Amount
+	// Currency is CHF or EUR
+	Currency Currency
+	// Debtor is the name and address of the debtor; leave it empty to have the debtor fill it in
+	Debtor Address
+	// ReferenceType is QRR, SCOR or NON; leave it empty to derive it from the reference
+	ReferenceType ReferenceType
+	// Reference is the QR reference (QRR) or the RF creditor reference (SCOR); it is empty for NON
+	Reference string
+	// Message is the unstructured message to the creditor
+	Message string
+	// BillInformation is the structured information of the creditor to automate the booking, eg. in the
+	// Swico S1 syntax; together with Message it can be up to 140 characters
+	BillInformation string
+	// AlternativeSchemes are up to 2 alternative procedures, of up to 100 characters each
+	AlternativeSchemes []string
+}
+
+// New returns a new Bill in Swiss francs
+func New() *Bill {
+	return &Bill{Currency: CurrencyCHF}
+}
+
+// IBAN returns the parsed, sanitized IBAN of the account
+func (b *Bill) IBAN() (*iban.IBAN, error) {
+	return iban.NewIBAN(strings.Join(strings.FieldsFunc(b.Account, isSeparator), ""))
+}
+
+// IsQRIBAN returns whether the account is a QR-IBAN: an IBAN with an institution ID from 30000 to 31999,
+// which can only be paid with a QR reference
+func (b *Bill) IsQRIBAN() bool {
+	i, err := b.IBAN()
+	if err != nil {
+		return false
+	}
+
+	if len(i.BBAN) < 5 {
+		return false
+	}
+
+	iid, err := strconv.Atoi(i.BBAN[:5])
+
+	return err == nil && iid >= qrIIDMin && iid <= qrIIDMax
+}
+
+// ReferenceTypeString returns the reference type; when it is not set, it is derived from the reference:
+// SCOR for a reference that starts with RF, QRR for any other reference, and NON without a reference
+func (b *Bill) ReferenceTypeString() ReferenceType {
+	switch {
+	case b.ReferenceType != "":
+		return b.ReferenceType
+	case b.Reference == "":
+		return ReferenceNON
+	case strings.HasPrefix(normalizeReference(b.Reference), "RF"):
+		return ReferenceSCOR
+	}
+
+	return ReferenceQRR
+}
+
+// AccountString returns the IBAN of the account in print format, in groups of 4 characters
+func (b *Bill) AccountString() string {
+	i, err := b.IBAN()
+	if err != nil {
+		return b.Account
+	}
+
+	return i.PrintCode
+}
+
+// ReferenceString returns the reference in print format: QR references in groups of 5 digits, RF creditor
+// references in groups of 4 characters
+func (b *Bill) ReferenceString() string {
+	if b.ReferenceTypeString() == ReferenceQRR {
+		return FormatQRReference(b.Reference)
+	}
+
+	return payment.FormatRFReference(b.Reference)
+}
+
+// fields returns the lines of the payload, without validating them
+func (b *Bill) fields() []string {
+	account := ""
+	if i, err := b.IBAN(); err == nil {
+		account = i.Code
+	}
+
+	amount := ""
+	if b.Amount != 0 {
+		amount = b.Amount.String()
+	}
+
+	fields := []string{qrType, version, coding, account}
+	fields = append(fields, b.Creditor.lines()...)
+	fields = append(fields, make([]string, 7)...) // the ultimate creditor, for future use
+	fields = append(fields, amount, string(b.Currency))
+	fields = append(fields, b.Debtor.lines()...)
+	fields = append(fields, string(b.ReferenceTypeString()), normalizeReference(b.Reference), b.Message, trailer)
+	fields = append(fields, b.BillInformation)
+	fields = append(fields, b.AlternativeSchemes...)
+
+	// The optional lines after the trailer are left out when they are empty
+	for fields[len(fields)-1] == "" {
+		fields = fields[:len(fields)-1]
+	}
+
+	return fields
+}
+
+// ToString returns the content of the QR code, after validating the bill
+func (b *Bill) ToString() (string, error) {
+	if err := b.IsValid(); err != nil {
+		return "", err
+	}
+
+	return strings.Join(b.fields(), "\n"), nil
+}
+
+// IsValid checks the bill against the Swiss implementation guidelines.
+// It returns nil if all is well, or payment.ValidationErrors with every problem it finds.
+func (b *Bill) IsValid() error {
+	var errs payment.ValidationErrors
+
+	errs = append(errs, b.validateAccount()...)
+	errs = append(errs, validateAddress("Creditor", &b.Creditor, true)...)
+
+	if b.Amount < 0 || b.Amount > payment.MaxAmount {
+		errs = append(errs, payment.NewValidationError("Amount", "", payment.RuleRange, "0.01..999999999.99", ErrValidationAmount))
+	}
+
+	if b.Currency != CurrencyCHF && b.Currency != CurrencyEUR {
+		errs = append(errs, payment.NewValidationError("Currency", "", payment.RuleValue, "CHF, EUR", ErrValidationCurrency))
+	}
+
+	errs = append(errs, validateAddress("Debtor", &b.Debtor, false)...)
+	errs = append(errs, b.validateReference()...)
+	errs = append(errs, b.validateAdditionalInformation()...)
+
+	if len(errs) == 0 {
+		if n := utf8.RuneCountInString(strings.Join(b.fields(), "\n")); n > MaxPayloadCharacters {
+			errs = append(errs, payment.NewValidationError("Payload", "", payment.RuleMaxLength, strconv.Itoa(MaxPayloadCharacters),
+				fmt.Errorf("%w: it is %d characters", ErrValidationPayloadTooLong, n)))
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+func (b *Bill) validateAccount() payment.ValidationErrors {
+	i, err := b.IBAN()
+	if err != nil {
+		return payment.ValidationErrors{payment.NewValidationError("Account", "", payment.RuleFormat, "IBAN",
+			fmt.Errorf("%w: %w", ErrValidationAccount, err))}
+	}
+
+	if i.CountryCode != "CH" && i.CountryCode != "LI" {
+		return payment.ValidationErrors{payment.NewValidationError("Account", "", payment.RuleValue, "CH, LI",
+			fmt.Errorf("%w: %s", ErrValidationAccount, i.CountryCode))}
+	}
+
+	return nil
+}
+
+func (b *Bill) validateReference() payment.ValidationErrors {
+	t := b.ReferenceTypeString()
+
+	switch {
+	case !slices.Contains([]ReferenceType{ReferenceQRR, ReferenceSCOR, ReferenceNON}, t),
+		b.IsQRIBAN() != (t == ReferenceQRR):
+		return payment.ValidationErrors{payment.NewValidationError("ReferenceType", "", payment.RuleValue, "QRR, SCOR, NON",
+			fmt.Errorf("%w: %s", ErrValidationReferenceType, t))}
+	case t == ReferenceQRR:
+		if err := ValidateQRReference(b.Reference); err != nil {
+			return payment.ValidationErrors{payment.NewValidationError("Reference", "", payment.RuleFormat, "QRR",
+				fmt.Errorf("%w: %w", ErrValidationReferenceQRR, err))}
+		}
+	case t == ReferenceSCOR:
+		if err := payment.ValidateRFReference(b.Reference); err != nil {
+			return payment.ValidationErrors{payment.NewValidationError("Reference", "", payment.RuleFormat, "ISO 11649",
+				fmt.Errorf("%w: %w", ErrValidationReferenceSCOR, err))}
+		}
+	case b.Reference != "":
+		return payment.ValidationErrors{payment.NewValidationError("Reference", "", payment.RuleValue, "", ErrValidationReferenceNON)}
+	}
+
+	return nil
+}
+
+func (b *Bill) validateAdditionalInformation() payment.ValidationErrors {
+	var errs payment.ValidationErrors
+
+	errs = append(errs, textRule(maxAdditionalInformation, false).Validate("Message", b.Message)...)
+	errs = append(errs, textRule(maxAdditionalInformation, false).Validate("BillInformation", b.BillInformation)...)
+
+	if utf8.RuneCountInString(b.Message)+utf8.RuneCountInString(b.BillInformation) > maxAdditionalInformation {
+		errs = append(errs, payment.NewValidationError("Message", "", payment.RuleMaxLength, strconv.Itoa(maxAdditionalInformation),
+			ErrValidationAdditionalInformation))
+	}
+
+	if len(b.AlternativeSchemes) > maxAlternativeSchemes {
+		errs = append(errs, payment.NewValidationError("AlternativeSchemes", "", payment.RuleMaxLength, strconv.Itoa(maxAlternativeSchemes),
+			ErrValidationAlternativeSchemes))
+	}
+
+	for i, scheme := range b.AlternativeSchemes {
+		errs = append(errs, textRule(100, false).Validate(fmt.Sprintf("AlternativeSchemes[%d]", i), scheme)...)
+	}
+
+	return errs
+}
+
+// validateAddress checks the fields of the address; an empty address is valid when it is optional
+func validateAddress(prefix string, a *Address, required bool) payment.ValidationErrors {
+	if a.IsEmpty() && !required {
+		return nil
+	}
+
+	var errs payment.ValidationErrors
+
+	for _, f := range []struct {
+		name     string
+		value    string
+		limit    int
+		required bool
+	}{
+		{"Name", a.Name, 70, true},
+		{"Street", a.Street, 70, false},
+		{"BuildingNumber", a.BuildingNumber, 16, false},
+		{"PostalCode", a.PostalCode, 16, true},
+		{"Town", a.Town, 35, true},
+	} {
+		errs = append(errs, textRule(f.limit, f.required).Validate(prefix+"."+f.name, f.value)...)
+	}
+
+	if !countryValidator.MatchString(a.Country) {
+		errs = append(errs, payment.NewValidationError(prefix+".Country", "", payment.RuleFormat, "ISO 3166-1",
+			fmt.Errorf("%w: %s: %q", ErrValidationCountry, prefix+".Country", a.Country)))
+	}
+
+	return errs
+}
+
+// textRule returns the rule of a text field of the bill: at most limit characters of the Latin character set
+func textRule(limit int, required bool) payment.TextRule {
+	return payment.TextRule{
+		Required:      required,
+		MaxLength:     limit,
+		Charset:       "Latin",
+		Allowed:       isLatin,
+		ErrRequired:   ErrValidationRequired,
+		ErrTooLong:    ErrValidationTooLong,
+		ErrCharacters: ErrValidationCharacters,
+	}
+}
+
+// isLatin returns whether the character is in the Latin character set of the QR-bill: Basic Latin, Latin-1
+// Supplement and Latin Extended-A, and a few more
+func isLatin(r rune) bool {
+	switch {
+	case r >= 0x20 && r <= 0x7e, r >= 0xa0 && r <= 0x17f:
+		return true
+	}
+
+	return strings.ContainsRune("ȘșȚț€", r)
+}
+
+// isSeparator returns whether the character separates the groups of an IBAN
+func isSeparator(r rune) bool {
+	return r == ' ' || r == '-' || r == '_'
+}
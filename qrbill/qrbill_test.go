@@ -0,0 +1,143 @@
+package qrbill_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/jovandeginste/payme/qrbill"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exampleBill returns the example of the implementation guidelines: a QR-IBAN with a QR reference
+func exampleBill() *qrbill.Bill {
+	b := qrbill.New()
+	b.Account = "CH44 3199 9123 0008 8901 2"
+	b.Creditor = qrbill.Address{
+		Name: "Robert Schneider AG", Street: "Rue du Lac", BuildingNumber: "1268",
+		PostalCode: "2501", Town: "Biel", Country: "CH",
+	}
+	b.Amount = 194975
+	b.Debtor = qrbill.Address{
+		Name: "Pia-Maria Rutschmann-Schnyder", Street: "Grosse Marktgasse", BuildingNumber: "28",
+		PostalCode: "9400", Town: "Rorschach", Country: "CH",
+	}
+	b.Reference = "21 00000 00003 13947 14300 09017"
+	b.Message = "Order of 15 June 2020"
+	b.BillInformation = "//S1/10/10201409/11/200701/20/140.000-53/30/102673831/31/200615/32/7.7/33/7.7:2.00/40/0:30"
+
+	return b
+}
+
+func TestToString(t *testing.T) {
+	s, err := exampleBill().ToString()
+	require.NoError(t, err)
+
+	lines := strings.Split(s, "\n")
+	require.Len(t, lines, 32)
+
+	assert.Equal(t, []string{"SPC", "0200", "1", "CH4431999123000889012", "S", "Robert Schneider AG", "Rue du Lac", "1268", "2501", "Biel", "CH"}, lines[:11])
+	assert.Equal(t, make([]string, 7), lines[11:18], "the ultimate creditor is empty")
+	assert.Equal(t, []string{"1949.75", "CHF", "S", "Pia-Maria Rutschmann-Schnyder"}, lines[18:22])
+	assert.Equal(t, []string{"QRR", "210000000003139471430009017", "Order of 15 June 2020", "EPD"}, lines[27:31])
+	assert.True(t, strings.HasPrefix(lines[31], "//S1/10/"))
+}
+
+func TestToStringMinimal(t *testing.T) {
+	b := qrbill.New()
+	b.Account = "CH5800791123000889012"
+	b.Creditor = qrbill.Address{Name: "Robert Schneider AG", PostalCode: "2501", Town: "Biel", Country: "CH"}
+	b.Currency = qrbill.CurrencyEUR
+
+	s, err := b.ToString()
+	require.NoError(t, err)
+
+	lines := strings.Split(s, "\n")
+	require.Len(t, lines, 31, "the optional lines after the trailer are left out")
+	assert.Empty(t, lines[18], "open amount")
+	assert.Equal(t, "EUR", lines[19])
+	assert.Equal(t, make([]string, 7), lines[20:27], "no debtor")
+	assert.Equal(t, []string{"NON", "", "", "EPD"}, lines[27:])
+
+	b.AlternativeSchemes = []string{"eBill/B/41010560425610173"}
+
+	s, err = b.ToString()
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(s, "EPD\n\neBill/B/41010560425610173"), s)
+}
+
+func TestReferenceType(t *testing.T) {
+	b := exampleBill()
+	assert.True(t, b.IsQRIBAN())
+	assert.Equal(t, qrbill.ReferenceQRR, b.ReferenceTypeString())
+	assert.Equal(t, "CH44 3199 9123 0008 8901 2", b.AccountString())
+	assert.Equal(t, "21 00000 00003 13947 14300 09017", b.ReferenceString())
+
+	b.Account = "CH5800791123000889012"
+	b.Reference = "RF18539007547034"
+	assert.False(t, b.IsQRIBAN())
+	assert.Equal(t, qrbill.ReferenceSCOR, b.ReferenceTypeString())
+	assert.Equal(t, "RF18 5390 0754 7034", b.ReferenceString())
+	require.NoError(t, b.IsValid())
+
+	b.Reference = ""
+	assert.Equal(t, qrbill.ReferenceNON, b.ReferenceTypeString())
+	require.NoError(t, b.IsValid())
+}
+
+func TestIsValid(t *testing.T) {
+	require.NoError(t, exampleBill().IsValid())
+
+	for name, tc := range map[string]struct {
+		change   func(b *qrbill.Bill)
+		field    string
+		expected error
+	}{
+		"foreign IBAN":          {func(b *qrbill.Bill) { b.Account = "DE71110220330123456789" }, "Account", qrbill.ErrValidationAccount},
+		"invalid IBAN":          {func(b *qrbill.Bill) { b.Account = "CH4431999123000889013" }, "Account", qrbill.ErrValidationAccount},
+		"QR-IBAN without QRR":   {func(b *qrbill.Bill) { b.Reference = "RF18539007547034" }, "ReferenceType", qrbill.ErrValidationReferenceType},
+		"QRR with IBAN":         {func(b *qrbill.Bill) { b.Account = "CH5800791123000889012" }, "ReferenceType", qrbill.ErrValidationReferenceType},
+		"QR reference checksum": {func(b *qrbill.Bill) { b.Reference = "210000000003139471430009018" }, "Reference", qrbill.ErrValidationReferenceQRR},
+		"reference with NON": {func(b *qrbill.Bill) {
+			b.Account, b.ReferenceType = "CH5800791123000889012", qrbill.ReferenceNON
+		}, "Reference", qrbill.ErrValidationReferenceNON},
+		"RF checksum": {func(b *qrbill.Bill) {
+			b.Account, b.Reference = "CH5800791123000889012", "RF19539007547034"
+		}, "Reference", qrbill.ErrValidationReferenceSCOR},
+		"currency":          {func(b *qrbill.Bill) { b.Currency = "USD" }, "Currency", qrbill.ErrValidationCurrency},
+		"amount":            {func(b *qrbill.Bill) { b.Amount = -1 }, "Amount", qrbill.ErrValidationAmount},
+		"creditor name":     {func(b *qrbill.Bill) { b.Creditor.Name = "" }, "Creditor.Name", qrbill.ErrValidationRequired},
+		"creditor country":  {func(b *qrbill.Bill) { b.Creditor.Country = "Switzerland" }, "Creditor.Country", qrbill.ErrValidationCountry},
+		"debtor town":       {func(b *qrbill.Bill) { b.Debtor.Town = strings.Repeat("x", 36) }, "Debtor.Town", qrbill.ErrValidationTooLong},
+		"debtor characters": {func(b *qrbill.Bill) { b.Debtor.Name = "Пиа Рутшман" }, "Debtor.Name", qrbill.ErrValidationCharacters},
+		"additional information": {func(b *qrbill.Bill) {
+			b.Message = strings.Repeat("x", 60)
+		}, "Message", qrbill.ErrValidationAdditionalInformation},
+		"alternative schemes": {func(b *qrbill.Bill) {
+			b.AlternativeSchemes = []string{"a", "b", "c"}
+		}, "AlternativeSchemes", qrbill.ErrValidationAlternativeSchemes},
+	} {
+		b := exampleBill()
+		tc.change(b)
+
+		err := b.IsValid()
+		require.ErrorIs(t, err, tc.expected, name)
+
+		var errs payment.ValidationErrors
+		require.ErrorAs(t, err, &errs, name)
+		assert.Equal(t, tc.field, errs[0].Field, name)
+	}
+}
+
+func TestIsValidEmptyDebtor(t *testing.T) {
+	b := exampleBill()
+	b.Debtor = qrbill.Address{}
+	require.NoError(t, b.IsValid())
+
+	b.Debtor.Town = "Rorschach"
+
+	var errs payment.ValidationErrors
+	require.ErrorAs(t, b.IsValid(), &errs)
+	assert.Len(t, errs, 3, "name, postal code and country")
+}
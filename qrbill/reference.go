@@ -0,0 +1,88 @@
+package qrbill
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var (
+	qrReferenceBaseValidator = regexp.MustCompile(`^\d{1,26}$`)
+	qrReferenceValidator     = regexp.MustCompile(`^\d{27}$`)
+
+	// ErrQRReferenceBase is returned when the base of a QR reference is not 1 to 26 digits
+	ErrQRReferenceBase = errors.New("QR reference base should be 1 to 26 digits")
+	// ErrQRReferenceFormat is returned when a QR reference is not 27 digits
+	ErrQRReferenceFormat = errors.New("QR reference should be 27 digits")
+	// ErrQRReferenceChecksum is returned when the check digit of a QR reference is wrong
+	ErrQRReferenceChecksum = errors.New("QR reference has an invalid check digit")
+)
+
+// mod10Table is the table of the recursive modulo 10 check digit of the QR reference
+var mod10Table = [10]int{0, 9, 4, 6, 8, 2, 7, 1, 3, 5}
+
+// NewQRReference returns a QR reference of 27 digits for the base, eg. a customer and invoice number of up to
+// 26 digits; shorter bases are padded with leading zeros. Use FormatQRReference to print it in groups of 5 digits.
+func NewQRReference(base string) (string, error) {
+	base = normalizeReference(base)
+
+	if !qrReferenceBaseValidator.MatchString(base) {
+		return "", ErrQRReferenceBase
+	}
+
+	base = strings.Repeat("0", 26-len(base)) + base
+
+	return base + string(rune('0'+mod10(base))), nil
+}
+
+// ValidateQRReference returns an error when the reference is not a valid QR reference: 27 digits, the last of
+// which is the recursive modulo 10 check digit. Spaces are ignored.
+func ValidateQRReference(ref string) error {
+	ref = normalizeReference(ref)
+
+	if !qrReferenceValidator.MatchString(ref) {
+		return ErrQRReferenceFormat
+	}
+
+	if mod10(ref[:26]) != int(ref[26]-'0') {
+		return ErrQRReferenceChecksum
+	}
+
+	return nil
+}
+
+// IsQRReference returns whether the reference is a valid QR reference
+func IsQRReference(ref string) bool {
+	return ValidateQRReference(ref) == nil
+}
+
+// FormatQRReference returns the QR reference in print format: 2 digits, followed by groups of 5 digits
+func FormatQRReference(ref string) string {
+	ref = normalizeReference(ref)
+	if len(ref) != 27 {
+		return ref
+	}
+
+	groups := []string{ref[:2]}
+	for i := 2; i < len(ref); i += 5 {
+		groups = append(groups, ref[i:i+5])
+	}
+
+	return strings.Join(groups, " ")
+}
+
+// mod10 returns the recursive modulo 10 check digit of the digits
+func mod10(digits string) int {
+	carry := 0
+
+	for _, r := range digits {
+		carry = mod10Table[(carry+int(r-'0'))%10]
+	}
+
+	return (10 - carry) % 10
+}
+
+// normalizeReference removes the spaces from the reference and converts it to upper case
+func normalizeReference(ref string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(ref), ""))
+}
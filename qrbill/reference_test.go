@@ -0,0 +1,47 @@
+package qrbill_test
+
+import (
+	"testing"
+
+	"github.com/jovandeginste/payme/qrbill"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewQRReference(t *testing.T) {
+	for base, expected := range map[string]string{
+		"21000000000313947143000901": "210000000003139471430009017",
+		"1":                          "000000000000000000000000011",
+		"12 345":                     "000000000000000000000123457",
+	} {
+		ref, err := qrbill.NewQRReference(base)
+		require.NoError(t, err, base)
+		assert.Equal(t, expected, ref, base)
+		assert.True(t, qrbill.IsQRReference(ref), ref)
+	}
+
+	for _, base := range []string{"", "RF18", "123456789012345678901234567"} {
+		_, err := qrbill.NewQRReference(base)
+		require.ErrorIs(t, err, qrbill.ErrQRReferenceBase, base)
+	}
+}
+
+func TestValidateQRReference(t *testing.T) {
+	for _, ref := range []string{"210000000003139471430009017", "21 00000 00003 13947 14300 09017"} {
+		require.NoError(t, qrbill.ValidateQRReference(ref), ref)
+	}
+
+	for ref, expected := range map[string]error{
+		"":                            qrbill.ErrQRReferenceFormat,
+		"21000000000313947143000901":  qrbill.ErrQRReferenceFormat,
+		"RF18539007547034":            qrbill.ErrQRReferenceFormat,
+		"210000000003139471430009018": qrbill.ErrQRReferenceChecksum,
+	} {
+		require.ErrorIs(t, qrbill.ValidateQRReference(ref), expected, ref)
+	}
+}
+
+func TestFormatQRReference(t *testing.T) {
+	assert.Equal(t, "21 00000 00003 13947 14300 09017", qrbill.FormatQRReference("210000000003139471430009017"))
+	assert.Equal(t, "123", qrbill.FormatQRReference("123"))
+}
@@ -0,0 +1,165 @@
+package qrbill
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strings"
+
+	"github.com/jovandeginste/payme/qrcode"
+)
+
+const (
+	// maxVersion is the largest QR code version of a QR-bill
+	maxVersion = 25
+	// codeMM is the size of the QR code on the payment part, without the quiet zone, in mm
+	codeMM = 46
+	// crossMM is the size of the Swiss cross, including its white border, in mm
+	crossMM = 7
+)
+
+// rect is a rectangle of the Swiss cross, in the units of the drawing
+type rect struct {
+	x, y, w, h float64
+	white      bool
+}
+
+// QROptions returns the options of the QR code of a QR-bill: error correction level M and byte mode (UTF-8)
+func QROptions() qrcode.Options {
+	opts := qrcode.DefaultOptions()
+	opts.Mode = qrcode.ModeByte
+
+	return opts
+}
+
+// QRCode returns the QR code of the bill, without the Swiss cross; use ToQRPNG, ToQRSVG or ToQRBytes to
+// render it with the cross
+func (b *Bill) QRCode() (*qrcode.Code, error) {
+	s, err := b.ToString()
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := qrcode.Encode([]byte(s), QROptions())
+	if err != nil {
+		return nil, err
+	}
+
+	if code.Version > maxVersion {
+		return nil, fmt.Errorf("%w: version %d, the largest is %d", qrcode.ErrTooLong, code.Version, maxVersion)
+	}
+
+	return code, nil
+}
+
+// ToQRPNG returns a PNG image of the QR code with the Swiss cross
+func (b *Bill) ToQRPNG(size int) ([]byte, error) {
+	code, err := b.QRCode()
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := code.ScaledImage(size)
+	if err != nil {
+		return nil, err
+	}
+
+	module := size / (code.Size() + 2*code.QuietZone)
+	codeSize := float64(module * code.Size())
+
+	drawCross(img, swissCross(float64(size)/2, float64(size)/2, codeSize))
+
+	var buf bytes.Buffer
+
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ToQRSVG returns an SVG image of the QR code with the Swiss cross
+// Every module is one unit in the viewBox, so the image can be scaled to any size
+func (b *Bill) ToQRSVG(opts qrcode.SVGOptions) ([]byte, error) {
+	code, err := b.QRCode()
+	if err != nil {
+		return nil, err
+	}
+
+	d := float64(code.Size() + 2*code.QuietZone)
+
+	svg := bytes.TrimSuffix(code.SVG(opts), []byte("</svg>\n"))
+	buf := bytes.NewBuffer(svg)
+
+	for _, r := range swissCross(d/2, d/2, float64(code.Size())) {
+		fill := "#000000"
+		if r.white {
+			fill = "#ffffff"
+		}
+
+		fmt.Fprintf(buf, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s"/>`+"\n", num(r.x), num(r.y), num(r.w), num(r.h), fill)
+	}
+
+	buf.WriteString("</svg>\n")
+
+	return buf.Bytes(), nil
+}
+
+// ToQRBytes returns a text representation of the QR code with the Swiss cross, for the terminal
+// The cross is rounded to whole modules.
+func (b *Bill) ToQRBytes() ([]byte, error) {
+	code, err := b.QRCode()
+	if err != nil {
+		return nil, err
+	}
+
+	img := code.Image()
+	d := float64(img.Rect.Dx())
+
+	drawCross(img, swissCross(d/2, d/2, float64(code.Size())))
+
+	return qrcode.Terminal(img), nil
+}
+
+// swissCross returns the rectangles of the Swiss cross in the center cx, cy of a QR code of the given size
+// (without its quiet zone), with y pointing down: a white border, a black square, and the two bars of the
+// white cross. The cross is 7 mm on a code of 46 mm.
+func swissCross(cx, cy, codeSize float64) []rect {
+	unit := codeSize / codeMM
+
+	outer := crossMM * unit
+	square := (crossMM - 1) * unit
+	// The arms of the cross are 1/6 longer than they are wide, as on the flag: 6 by 7 on a square of 32
+	long, short := square*20/32, square*6/32
+
+	return []rect{
+		{x: cx - outer/2, y: cy - outer/2, w: outer, h: outer, white: true},
+		{x: cx - square/2, y: cy - square/2, w: square, h: square},
+		{x: cx - long/2, y: cy - short/2, w: long, h: short, white: true},
+		{x: cx - short/2, y: cy - long/2, w: short, h: long, white: true},
+	}
+}
+
+// drawCross draws the rectangles of the Swiss cross on the image, rounded to whole pixels
+func drawCross(img *image.Gray, rects []rect) {
+	for _, r := range rects {
+		c := color.Gray{}
+		if r.white {
+			c.Y = 0xff
+		}
+
+		bounds := image.Rect(int(math.Round(r.x)), int(math.Round(r.y)), int(math.Round(r.x+r.w)), int(math.Round(r.y+r.h)))
+		draw.Draw(img, bounds, image.NewUniform(c), image.Point{}, draw.Src)
+	}
+}
+
+// num formats a number with at most 2 decimals, without trailing zeros
+func num(v float64) string {
+	s := strings.TrimRight(fmt.Sprintf("%.2f", v), "0")
+
+	return strings.TrimSuffix(s, ".")
+}
@@ -0,0 +1,61 @@
+package qrbill_test
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/jovandeginste/payme/qrbill"
+	"github.com/jovandeginste/payme/qrcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToQRPNG(t *testing.T) {
+	b := exampleBill()
+
+	result, err := b.ToQRPNG(400)
+	require.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(result))
+	require.NoError(t, err)
+	assert.Equal(t, 400, img.Bounds().Dx())
+
+	r, g, bl, _ := img.At(200, 200).RGBA()
+	assert.Equal(t, [3]uint32{0xffff, 0xffff, 0xffff}, [3]uint32{r, g, bl}, "the center of the Swiss cross is white")
+
+	decoded, err := payment.DecodeQRImage(bytes.NewReader(result))
+	require.NoError(t, err, "the Swiss cross does not break the code")
+
+	s, err := b.ToString()
+	require.NoError(t, err)
+	assert.Equal(t, s, string(decoded))
+}
+
+func TestQRCode(t *testing.T) {
+	code, err := exampleBill().QRCode()
+	require.NoError(t, err)
+	assert.Equal(t, qrcode.LevelM, code.Level)
+
+	_, err = qrbill.New().QRCode()
+
+	var errs payment.ValidationErrors
+	require.ErrorAs(t, err, &errs)
+}
+
+func TestToQRSVG(t *testing.T) {
+	result, err := exampleBill().ToQRSVG(qrcode.DefaultSVGOptions())
+	require.NoError(t, err)
+
+	svg := string(result)
+	assert.True(t, strings.HasSuffix(svg, "</svg>\n"))
+	assert.Equal(t, 4, strings.Count(svg, `fill="#ffffff"/>`), "the background, the border and the two bars of the cross are white")
+}
+
+func TestToQRBytes(t *testing.T) {
+	result, err := exampleBill().ToQRBytes()
+	require.NoError(t, err)
+	assert.NotEmpty(t, result)
+}
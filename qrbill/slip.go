@@ -0,0 +1,340 @@
+package qrbill
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/jovandeginste/payme/internal/pdf"
+	"github.com/jovandeginste/payme/payment"
+	"github.com/jovandeginste/payme/qrcode"
+)
+
+// Dimensions of the payment part with receipt, in mm; the payment part is 148 mm wide, the receipt 62 mm
+const (
+	slipWidth    = 210
+	slipHeight   = 105
+	receiptWidth = 62
+	slipMargin   = 5
+	// codeTop is the distance from the top of the QR code to the top of the payment part
+	codeTop = 17
+	// amountTop is the distance from the top of the amount section to the top of the payment part
+	amountTop = 68
+	// infoLeft is the distance from the information section to the left of the payment part
+	infoLeft = receiptWidth + 56
+	// ptMM is the size of a point in mm
+	ptMM = 25.4 / 72
+)
+
+// Font sizes of the titles, the headings and the values, in points
+const (
+	titleSize          = 11
+	receiptHeadingSize = 6
+	receiptValueSize   = 8
+	headingSize        = 8
+	valueSize          = 10
+	furtherInfoSize    = 7
+)
+
+// canvas is a surface to draw the payment part on, in mm with the origin in the top left corner
+type canvas interface {
+	// text draws a line of text with its baseline starting at x, y; the size is in points
+	text(x, y float64, bold bool, size float64, s string)
+	// rect draws a filled rectangle with its top left corner at x, y
+	rect(r rect)
+	// line draws a straight line of the given width in points
+	line(x1, y1, x2, y2, width float64, dashed bool)
+}
+
+// ToPDF returns a printable PDF of the bill: the payment part with receipt at the bottom of an A4 page, below
+// a cutting line, or the payment part alone on an A6 page (landscape). The QR options are not used, as the
+// QR code of a QR-bill always has those of QROptions.
+func (b *Bill) ToPDF(opts payment.PDFOptions) ([]byte, error) {
+	code, err := b.QRCode()
+	if err != nil {
+		return nil, err
+	}
+
+	doc := pdf.New()
+
+	var page *pdf.Page
+
+	switch opts.PageSize {
+	case payment.PageSizeA4:
+		page = doc.AddPage(pdf.A4Width, pdf.A4Height)
+	case payment.PageSizeA6:
+		page = doc.AddPage(pdf.A6Height, pdf.A6Width)
+	default:
+		return nil, payment.ErrPageSize
+	}
+
+	c := &pdfCanvas{page: page}
+
+	if opts.PageSize == payment.PageSizeA6 {
+		c.dx = -receiptWidth
+		b.drawPaymentPart(c, code)
+
+		return doc.Bytes(), nil
+	}
+
+	c.line(0, 0, slipWidth, 0, 0.5, true)
+	b.drawSlip(c, code)
+
+	return doc.Bytes(), nil
+}
+
+// ToSVG returns an SVG image of the payment part with receipt, of 210 by 105 mm
+func (b *Bill) ToSVG() ([]byte, error) {
+	code, err := b.QRCode()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &svgCanvas{}
+
+	fmt.Fprintf(&c.buf, `<?xml version="1.0" encoding="UTF-8"?>`+"\n")
+	fmt.Fprintf(&c.buf, `<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="%dmm" height="%dmm" viewBox="0 0 %[1]d %[2]d">`+"\n",
+		slipWidth, slipHeight)
+	fmt.Fprintf(&c.buf, `<rect width="%d" height="%d" fill="#ffffff"/>`+"\n", slipWidth, slipHeight)
+	fmt.Fprintf(&c.buf, `<g font-family="Helvetica, Arial, sans-serif" fill="#000000">`+"\n")
+
+	b.drawSlip(c, code)
+
+	c.buf.WriteString("</g>\n</svg>\n")
+
+	return c.buf.Bytes(), nil
+}
+
+// drawSlip draws the receipt and the payment part
+func (b *Bill) drawSlip(c canvas, code *qrcode.Code) {
+	c.line(receiptWidth, 0, receiptWidth, slipHeight, 0.5, true)
+
+	b.drawReceipt(c)
+	b.drawPaymentPart(c, code)
+}
+
+// drawReceipt draws the receipt, on the left of the payment part
+func (b *Bill) drawReceipt(c canvas) {
+	const width = receiptWidth - 2*slipMargin
+
+	x := float64(slipMargin)
+	y := slipMargin + titleSize*ptMM
+
+	c.text(x, y, true, titleSize, "Receipt")
+
+	s := section{c: c, x: x, y: y + 3, width: width, headingSize: receiptHeadingSize, valueSize: receiptValueSize}
+	s.add("Account / Payable to", append([]string{b.AccountString()}, addressLines(&b.Creditor)...)...)
+
+	if b.ReferenceTypeString() != ReferenceNON {
+		s.add("Reference", b.ReferenceString())
+	}
+
+	if b.Debtor.IsEmpty() {
+		s.add("Payable by (name/address)")
+		cornerMarks(c, x, s.y, 52, 20)
+	} else {
+		s.add("Payable by", addressLines(&b.Debtor)...)
+	}
+
+	b.drawAmount(c, x, 13, receiptHeadingSize, receiptValueSize, 30, 10)
+
+	label := "Acceptance point"
+	c.text(receiptWidth-slipMargin-textWidth(true, receiptHeadingSize, label), 82+receiptHeadingSize*ptMM, true, receiptHeadingSize, label)
+}
+
+// drawPaymentPart draws the payment part, with the QR code and the Swiss cross
+func (b *Bill) drawPaymentPart(c canvas, code *qrcode.Code) {
+	x := float64(receiptWidth + slipMargin)
+
+	c.text(x, slipMargin+titleSize*ptMM, true, titleSize, "Payment part")
+
+	drawCode(c, code, x, codeTop)
+
+	b.drawAmount(c, x, 13, headingSize, valueSize, 40, 15)
+
+	s := section{c: c, x: infoLeft, y: slipMargin, width: slipWidth - infoLeft - slipMargin, headingSize: headingSize, valueSize: valueSize}
+	s.add("Account / Payable to", append([]string{b.AccountString()}, addressLines(&b.Creditor)...)...)
+
+	if b.ReferenceTypeString() != ReferenceNON {
+		s.add("Reference", b.ReferenceString())
+	}
+
+	if b.Message != "" || b.BillInformation != "" {
+		s.add("Additional information", b.Message, b.BillInformation)
+	}
+
+	if b.Debtor.IsEmpty() {
+		s.add("Payable by (name/address)")
+		cornerMarks(c, infoLeft, s.y, 65, 25)
+	} else {
+		s.add("Payable by", addressLines(&b.Debtor)...)
+	}
+
+	y := 90.0
+	for _, scheme := range b.AlternativeSchemes {
+		y += furtherInfoSize * ptMM * 1.2
+		c.text(x, y, false, furtherInfoSize, scheme)
+	}
+}
+
+// drawAmount draws the currency and the amount at the left x of the receipt or the payment part;
+// an open amount is a blank box of the given size to fill in by hand
+func (b *Bill) drawAmount(c canvas, x, amountLeft, headingSize, valueSize, boxWidth, boxHeight float64) {
+	y := amountTop + headingSize*ptMM
+
+	c.text(x, y, true, headingSize, "Currency")
+	c.text(x+amountLeft, y, true, headingSize, "Amount")
+
+	y += valueSize * ptMM * 1.5
+	c.text(x, y, false, valueSize, string(b.Currency))
+
+	if b.Amount == 0 {
+		cornerMarks(c, x+amountLeft, y-valueSize*ptMM, boxWidth, boxHeight)
+		return
+	}
+
+	c.text(x+amountLeft, y, false, valueSize, formatAmount(b.Amount))
+}
+
+// drawCode draws the QR code, without its quiet zone, in a square of 46 mm with its top left corner at x, y,
+// and the Swiss cross in its center
+func drawCode(c canvas, code *qrcode.Code, x, y float64) {
+	module := codeMM / float64(code.Size())
+	q := float64(code.QuietZone)
+
+	for _, r := range code.Runs() {
+		c.rect(rect{x: x + (float64(r.Min.X)-q)*module, y: y + (float64(r.Min.Y)-q)*module, w: float64(r.Dx()) * module, h: module})
+	}
+
+	for _, r := range swissCross(x+codeMM/2, y+codeMM/2, codeMM) {
+		c.rect(r)
+	}
+}
+
+// section draws the headings and values of the information of the receipt or the payment part, from top to bottom
+type section struct {
+	c           canvas
+	x, y, width float64
+	headingSize float64
+	valueSize   float64
+}
+
+// add draws the heading and the values below it, wrapped to the width of the section; empty values are skipped
+func (s *section) add(heading string, values ...string) {
+	s.y += s.headingSize * ptMM
+	s.c.text(s.x, s.y, true, s.headingSize, heading)
+
+	for _, v := range values {
+		for _, l := range pdf.Wrap(pdf.Helvetica, s.valueSize, s.width/ptMM, v) {
+			s.y += s.valueSize * ptMM * 1.15
+			s.c.text(s.x, s.y, false, s.valueSize, l)
+		}
+	}
+
+	s.y += s.valueSize * ptMM
+}
+
+// cornerMarks draws the corners of a blank box to fill in by hand, with its top left corner at x, y
+func cornerMarks(c canvas, x, y, w, h float64) {
+	const (
+		mark  = 3
+		width = 0.75
+	)
+
+	for _, corner := range [][4]float64{{x, y, 1, 1}, {x + w, y, -1, 1}, {x, y + h, 1, -1}, {x + w, y + h, -1, -1}} {
+		cx, cy, dx, dy := corner[0], corner[1], corner[2], corner[3]
+		c.line(cx, cy, cx+dx*mark, cy, width, false)
+		c.line(cx, cy, cx, cy+dy*mark, width, false)
+	}
+}
+
+// addressLines returns the lines of the address as it is printed: the name, the street and the town
+func addressLines(a *Address) []string {
+	return []string{
+		a.Name,
+		strings.TrimSpace(a.Street + " " + a.BuildingNumber),
+		strings.TrimSpace(a.PostalCode + " " + a.Town),
+	}
+}
+
+// formatAmount returns the amount with a space as thousands separator, eg. 1 949.75
+func formatAmount(a payment.Amount) string {
+	units, cents, _ := strings.Cut(a.String(), ".")
+
+	for i := len(units) - 3; i > 0; i -= 3 {
+		units = units[:i] + " " + units[i:]
+	}
+
+	return units + "." + cents
+}
+
+// textWidth returns the approximate width of the text in mm
+func textWidth(bold bool, size float64, s string) float64 {
+	return pdf.TextWidth(font(bold), size, s) * ptMM
+}
+
+// font returns the PDF font
+func font(bold bool) pdf.Font {
+	if bold {
+		return pdf.HelveticaBold
+	}
+
+	return pdf.Helvetica
+}
+
+// pdfCanvas draws on a PDF page, with the payment part at the bottom of the page, shifted dx mm to the right
+type pdfCanvas struct {
+	page *pdf.Page
+	dx   float64
+}
+
+func (c *pdfCanvas) text(x, y float64, bold bool, size float64, s string) {
+	c.page.Text(pdf.MM(c.dx+x), pdf.MM(slipHeight-y), font(bold), size, s)
+}
+
+func (c *pdfCanvas) rect(r rect) {
+	if r.white {
+		c.page.Fill(1)
+		defer c.page.Fill(0)
+	}
+
+	c.page.Rect(pdf.MM(c.dx+r.x), pdf.MM(slipHeight-r.y-r.h), pdf.MM(r.w), pdf.MM(r.h))
+}
+
+func (c *pdfCanvas) line(x1, y1, x2, y2, width float64, dashed bool) {
+	c.page.Line(pdf.MM(c.dx+x1), pdf.MM(slipHeight-y1), pdf.MM(c.dx+x2), pdf.MM(slipHeight-y2), width, dashed)
+}
+
+// svgCanvas draws SVG elements, in mm
+type svgCanvas struct {
+	buf bytes.Buffer
+}
+
+func (c *svgCanvas) text(x, y float64, bold bool, size float64, s string) {
+	weight := ""
+	if bold {
+		weight = ` font-weight="bold"`
+	}
+
+	fmt.Fprintf(&c.buf, `<text x="%s" y="%s" font-size="%s"%s>%s</text>`+"\n", num(x), num(y), num(size*ptMM), weight, html.EscapeString(s))
+}
+
+func (c *svgCanvas) rect(r rect) {
+	fill := ""
+	if r.white {
+		fill = ` fill="#ffffff"`
+	}
+
+	fmt.Fprintf(&c.buf, `<rect x="%s" y="%s" width="%s" height="%s"%s/>`+"\n", num(r.x), num(r.y), num(r.w), num(r.h), fill)
+}
+
+func (c *svgCanvas) line(x1, y1, x2, y2, width float64, dashed bool) {
+	dash := ""
+	if dashed {
+		dash = ` stroke-dasharray="1 1"`
+	}
+
+	fmt.Fprintf(&c.buf, `<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="#000000" stroke-width="%s"%s/>`+"\n",
+		num(x1), num(y1), num(x2), num(y2), num(width*ptMM), dash)
+}
@@ -0,0 +1,49 @@
+package qrbill_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/jovandeginste/payme/qrbill"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToPDF(t *testing.T) {
+	for _, size := range []payment.PageSize{payment.PageSizeA4, payment.PageSizeA6} {
+		result, err := exampleBill().ToPDF(payment.PDFOptions{PageSize: size})
+		require.NoError(t, err, size)
+
+		doc := string(result)
+		assert.True(t, strings.HasPrefix(doc, "%PDF-"), size)
+		assert.Contains(t, doc, "(Payment part)", size)
+		assert.Contains(t, doc, "(CH44 3199 9123 0008 8901 2)", size)
+		assert.Contains(t, doc, "(21 00000 00003 13947 14300 09017)", size)
+		assert.Contains(t, doc, "(1 949.75)", size)
+		assert.Contains(t, doc, "(Rue du Lac 1268)", size)
+		assert.Contains(t, doc, "(Order of 15 June 2020)", size)
+		assert.Equal(t, size == payment.PageSizeA4, strings.Contains(doc, "(Receipt)"), "the receipt is only on A4")
+	}
+
+	_, err := exampleBill().ToPDF(payment.PDFOptions{PageSize: "letter"})
+	require.ErrorIs(t, err, payment.ErrPageSize)
+}
+
+func TestToSVG(t *testing.T) {
+	b := exampleBill()
+	b.Amount = 0
+	b.Debtor = qrbill.Address{}
+
+	result, err := b.ToSVG()
+	require.NoError(t, err)
+
+	svg := string(result)
+	assert.True(t, bytes.HasPrefix(result, []byte("<?xml")))
+	assert.Contains(t, svg, `width="210mm" height="105mm" viewBox="0 0 210 105"`)
+	assert.Contains(t, svg, ">Receipt</text>")
+	assert.Contains(t, svg, ">Payable by (name/address)</text>")
+	assert.NotContains(t, svg, ">1 949.75</text>", "open amount")
+	assert.Contains(t, svg, `stroke-dasharray="1 1"`, "the cutting line between the receipt and the payment part")
+}
@@ -0,0 +1,94 @@
+package qrcode
+
+import "rsc.io/qr/coding"
+
+// Penalty weights of the mask evaluation rules (ISO/IEC 18004, 7.8.3)
+const (
+	penaltyRun     = 3
+	penaltyBlock   = 3
+	penaltyFinder  = 40
+	penaltyBalance = 10
+)
+
+// finderLike is the 1:1:3:1:1 pattern, preceded or followed by 4 light modules
+var finderLike = [2][11]bool{
+	{true, false, true, true, true, false, true, false, false, false, false},
+	{false, false, false, false, true, false, true, true, true, false, true},
+}
+
+// penalty evaluates the code according to the mask evaluation rules; the mask
+// with the lowest penalty is the best choice
+func penalty(c *coding.Code) int {
+	total := 0
+
+	for i := range c.Size {
+		total += linePenalty(c, i, func(c *coding.Code, i, j int) bool { return c.Black(j, i) })
+		total += linePenalty(c, i, func(c *coding.Code, i, j int) bool { return c.Black(i, j) })
+	}
+
+	dark := 0
+
+	for y := range c.Size {
+		for x := range c.Size {
+			if c.Black(x, y) {
+				dark++
+			}
+
+			if x+1 < c.Size && y+1 < c.Size {
+				b := c.Black(x, y)
+				if c.Black(x+1, y) == b && c.Black(x, y+1) == b && c.Black(x+1, y+1) == b {
+					total += penaltyBlock
+				}
+			}
+		}
+	}
+
+	// Deviation of the proportion of dark modules from 50%, in steps of 5%
+	deviation := dark*100/(c.Size*c.Size) - 50
+	if deviation < 0 {
+		deviation = -deviation
+	}
+
+	return total + deviation/5*penaltyBalance
+}
+
+// linePenalty evaluates the runs of same-colored modules and the finder-like patterns in one row or column
+func linePenalty(c *coding.Code, i int, black func(c *coding.Code, i, j int) bool) int {
+	total := 0
+	run := 0
+
+	for j := range c.Size {
+		if j > 0 && black(c, i, j) == black(c, i, j-1) {
+			run++
+		} else {
+			run = 1
+		}
+
+		if run == 5 {
+			total += penaltyRun
+		} else if run > 5 {
+			total++
+		}
+
+		if j+11 > c.Size {
+			continue
+		}
+
+		for _, pattern := range finderLike {
+			match := true
+
+			for k, b := range pattern {
+				if black(c, i, j+k) != b {
+					match = false
+					break
+				}
+			}
+
+			if match {
+				total += penaltyFinder
+			}
+		}
+	}
+
+	return total
+}
@@ -0,0 +1,377 @@
+// Package qrcode encodes data as a QR code with configurable error correction level,
+// symbol version, encoding mode, mask and quiet zone, and renders it in several formats.
+package qrcode
+
+import (
+	"errors"
+	"fmt"
+	"image"
+
+	"rsc.io/qr/coding"
+)
+
+// Level is the error correction level of the QR code
+type Level string
+
+const (
+	// LevelL recovers 7% of the data
+	LevelL Level = "L"
+	// LevelM recovers 15% of the data; this is the default
+	LevelM Level = "M"
+	// LevelQ recovers 25% of the data
+	LevelQ Level = "Q"
+	// LevelH recovers 30% of the data
+	LevelH Level = "H"
+)
+
+// Levels are all error correction levels, from least to most tolerant of errors
+var Levels = []Level{LevelL, LevelM, LevelQ, LevelH}
+
+// Mode is the encoding mode of the data in the QR code
+type Mode string
+
+const (
+	// ModeAuto selects the most compact mode that can encode the data; this is the default
+	ModeAuto Mode = "auto"
+	// ModeNumeric encodes digits only
+	ModeNumeric Mode = "numeric"
+	// ModeAlphanumeric encodes digits, upper case letters, space and $%*+-./:
+	ModeAlphanumeric Mode = "alphanumeric"
+	// ModeByte encodes arbitrary bytes
+	ModeByte Mode = "byte"
+)
+
+const (
+	// MinVersion is the smallest symbol version (21x21 modules)
+	MinVersion = int(coding.MinVersion)
+	// MaxVersion is the largest symbol version (177x177 modules)
+	MaxVersion = int(coding.MaxVersion)
+	// MaskAuto selects the mask pattern with the lowest penalty
+	MaskAuto = -1
+	// DefaultQuietZone is the width of the quiet zone required by the standard
+	DefaultQuietZone = 4
+	// MaxECI is the largest Extended Channel Interpretation assignment number
+	MaxECI = 999999
+)
+
+// ECI assignment numbers of character sets
+const (
+	// ECIISO88592 is the ECI of ISO 8859-2 (Latin-2)
+	ECIISO88592 = 4
+	// ECIUTF8 is the ECI of UTF-8
+	ECIUTF8 = 26
+)
+
+var (
+	// ErrLevel is returned when the error correction level is not L, M, Q or H
+	ErrLevel = errors.New("error correction level should be L, M, Q or H")
+	// ErrMode is returned when the encoding mode is unknown
+	ErrMode = errors.New("encoding mode should be auto, numeric, alphanumeric or byte")
+	// ErrModeData is returned when the data can not be encoded in the selected encoding mode
+	ErrModeData = errors.New("data can not be encoded in the selected encoding mode")
+	// ErrVersion is returned when the minimum symbol version is out of range
+	ErrVersion = fmt.Errorf("minimum symbol version should be 0 (auto) or %d..%d", MinVersion, MaxVersion)
+	// ErrMask is returned when the mask pattern is out of range
+	ErrMask = errors.New("mask pattern should be -1 (auto) or 0..7")
+	// ErrQuietZone is returned when the quiet zone is negative
+	ErrQuietZone = errors.New("quiet zone should not be negative")
+	// ErrECI is returned when the ECI assignment number is out of range
+	ErrECI = fmt.Errorf("ECI should be 0 (none) or 1..%d", MaxECI)
+	// ErrTooLong is returned when the data does not fit in a QR code
+	ErrTooLong = errors.New("data too long to encode as QR code")
+)
+
+// Options configures how data is encoded as a QR code
+// The zero value encodes with level M in the smallest version, using mask 0 and no quiet zone;
+// use DefaultOptions for the recommended settings.
+type Options struct {
+	// Level is the error correction level; leave empty for LevelM
+	Level Level
+	// MinVersion is the smallest symbol version to use (1..40); the version is increased
+	// when the data does not fit. Use 0 for the smallest version that fits the data.
+	MinVersion int
+	// Mode is the encoding mode; leave empty for ModeAuto
+	Mode Mode
+	// Mask is the mask pattern (0..7), or MaskAuto
+	Mask int
+	// QuietZone is the width of the blank border around the code, in modules
+	QuietZone int
+	// ECI is the Extended Channel Interpretation of the data, which tells readers its character set
+	// (eg. ECIISO88592); leave it 0 for none, so readers assume ISO 8859-1 or guess
+	ECI int
+}
+
+// DefaultOptions returns the recommended options: level M, the smallest version,
+// the best mask and the standard quiet zone of 4 modules
+func DefaultOptions() Options {
+	return Options{
+		Level:     LevelM,
+		Mode:      ModeAuto,
+		Mask:      MaskAuto,
+		QuietZone: DefaultQuietZone,
+	}
+}
+
+// Code is an encoded QR code
+type Code struct {
+	// Version is the symbol version of the code
+	Version int
+	// Level is the error correction level of the code
+	Level Level
+	// Mask is the mask pattern of the code
+	Mask int
+	// QuietZone is the width of the blank border around the code, in modules
+	QuietZone int
+
+	code *coding.Code
+}
+
+// Encode returns the data encoded as a QR code
+func Encode(data []byte, opts Options) (*Code, error) {
+	level, err := opts.level()
+	if err != nil {
+		return nil, err
+	}
+
+	enc, err := opts.encoding(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.ECI < 0 || opts.ECI > MaxECI {
+		return nil, ErrECI
+	}
+
+	if opts.ECI != 0 {
+		enc = segments{eci(opts.ECI), enc}
+	}
+
+	if opts.MinVersion != 0 && (opts.MinVersion < MinVersion || opts.MinVersion > MaxVersion) {
+		return nil, ErrVersion
+	}
+
+	if opts.Mask < MaskAuto || opts.Mask > 7 {
+		return nil, ErrMask
+	}
+
+	if opts.QuietZone < 0 {
+		return nil, ErrQuietZone
+	}
+
+	v, err := fit(enc, level, opts.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Code{Version: int(v), Level: Level(level.String()), QuietZone: opts.QuietZone}
+
+	masks := []int{opts.Mask}
+	if opts.Mask == MaskAuto {
+		masks = []int{0, 1, 2, 3, 4, 5, 6, 7}
+	}
+
+	best := -1
+
+	for _, m := range masks {
+		code, err := encode(v, level, coding.Mask(m), enc)
+		if err != nil {
+			return nil, err
+		}
+
+		if penalty := penalty(code); best < 0 || penalty < best {
+			best = penalty
+			c.code = code
+			c.Mask = m
+		}
+	}
+
+	return c, nil
+}
+
+// VersionFor returns the smallest symbol version that fits the data at the given level,
+// without building the code
+func VersionFor(data []byte, level Level) (int, error) {
+	opts := Options{Level: level}
+
+	l, err := opts.level()
+	if err != nil {
+		return 0, err
+	}
+
+	enc, err := opts.encoding(data)
+	if err != nil {
+		return 0, err
+	}
+
+	v, err := fit(enc, l, 0)
+
+	return int(v), err
+}
+
+// ModulesFor returns the number of modules on a side of a code of the given version
+func ModulesFor(version int) int {
+	return 17 + 4*version
+}
+
+// Size returns the number of modules on a side, without the quiet zone
+func (c *Code) Size() int {
+	return c.code.Size
+}
+
+// Black returns whether the module at x, y is dark; the coordinates exclude the quiet zone
+func (c *Code) Black(x, y int) bool {
+	return c.code.Black(x, y)
+}
+
+// Runs returns one rectangle per horizontal run of dark modules, in module
+// coordinates including the quiet zone
+func (c *Code) Runs() []image.Rectangle {
+	var runs []image.Rectangle
+
+	q := c.QuietZone
+
+	for y := range c.Size() {
+		for x := 0; x < c.Size(); {
+			if !c.Black(x, y) {
+				x++
+				continue
+			}
+
+			start := x
+			for x < c.Size() && c.Black(x, y) {
+				x++
+			}
+
+			runs = append(runs, image.Rect(start+q, y+q, x+q, y+q+1))
+		}
+	}
+
+	return runs
+}
+
+func (o Options) level() (coding.Level, error) {
+	switch o.Level {
+	case LevelL:
+		return coding.L, nil
+	case LevelM, "":
+		return coding.M, nil
+	case LevelQ:
+		return coding.Q, nil
+	case LevelH:
+		return coding.H, nil
+	}
+
+	return 0, ErrLevel
+}
+
+func (o Options) encoding(data []byte) (coding.Encoding, error) {
+	var enc coding.Encoding
+
+	switch o.Mode {
+	case ModeAuto, "":
+		// Pick the smallest encoding that can hold the data
+		switch {
+		case coding.Num(data).Check() == nil:
+			return coding.Num(data), nil
+		case coding.Alpha(data).Check() == nil:
+			return coding.Alpha(data), nil
+		default:
+			return coding.String(data), nil
+		}
+	case ModeNumeric:
+		enc = coding.Num(data)
+	case ModeAlphanumeric:
+		enc = coding.Alpha(data)
+	case ModeByte:
+		enc = coding.String(data)
+	default:
+		return nil, ErrMode
+	}
+
+	if err := enc.Check(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrModeData, err)
+	}
+
+	return enc, nil
+}
+
+// fit returns the smallest version, starting at minVersion, that can hold the encoded data
+func fit(enc coding.Encoding, level coding.Level, minVersion int) (coding.Version, error) {
+	for v := max(coding.Version(minVersion), coding.MinVersion); v <= coding.MaxVersion; v++ {
+		if enc.Bits(v) <= v.DataBytes(level)*8 {
+			return v, nil
+		}
+	}
+
+	return 0, ErrTooLong
+}
+
+// eci is the segment of an Extended Channel Interpretation, which sets the interpretation of the segments after it
+type eci int
+
+func (e eci) Check() error {
+	return nil
+}
+
+func (e eci) Bits(coding.Version) int {
+	switch {
+	case e < 1<<7:
+		return 4 + 8
+	case e < 1<<14:
+		return 4 + 16
+	}
+
+	return 4 + 24
+}
+
+// Encode writes the ECI mode indicator and the assignment number, in 1, 2 or 3 bytes with a prefix of 0, 10
+// or 110
+func (e eci) Encode(b *coding.Bits, _ coding.Version) {
+	b.Write(0b0111, 4)
+
+	switch {
+	case e < 1<<7:
+		b.Write(uint(e), 8)
+	case e < 1<<14:
+		b.Write(0b10<<14|uint(e), 16)
+	default:
+		b.Write(0b110<<21|uint(e), 24)
+	}
+}
+
+// segments are encodings that follow each other in one code
+type segments []coding.Encoding
+
+func (s segments) Check() error {
+	for _, enc := range s {
+		if err := enc.Check(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s segments) Bits(v coding.Version) int {
+	n := 0
+	for _, enc := range s {
+		n += enc.Bits(v)
+	}
+
+	return n
+}
+
+func (s segments) Encode(b *coding.Bits, v coding.Version) {
+	for _, enc := range s {
+		enc.Encode(b, v)
+	}
+}
+
+func encode(v coding.Version, level coding.Level, mask coding.Mask, enc coding.Encoding) (*coding.Code, error) {
+	p, err := coding.NewPlan(v, level, mask)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.Encode(enc)
+}
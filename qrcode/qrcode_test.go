@@ -0,0 +1,219 @@
+package qrcode_test
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/jovandeginste/payme/qrcode"
+	"github.com/makiuchi-d/gozxing"
+	gozxingqr "github.com/makiuchi-d/gozxing/qrcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const exampleData = "BCD\n002\n2\nSCT\n\nFranz Mustermann\nDE71 1102 2033 0123 4567 89\nEUR12.30\n\n\nInvoice 123\n"
+
+// decodePNG returns the content and error correction level of the QR code in a PNG image
+func decodePNG(t *testing.T, b []byte) (string, string) {
+	t.Helper()
+
+	img, err := png.Decode(bytes.NewReader(b))
+	require.NoError(t, err)
+
+	bmp, err := gozxing.NewBinaryBitmapFromImage(img)
+	require.NoError(t, err)
+
+	result, err := gozxingqr.NewQRCodeReader().Decode(bmp, nil)
+	require.NoError(t, err)
+
+	level, _ := result.GetResultMetadata()[gozxing.ResultMetadataType_ERROR_CORRECTION_LEVEL].(string)
+
+	return result.GetText(), level
+}
+
+func TestEncodeLevels(t *testing.T) {
+	previous := 0
+
+	for _, l := range qrcode.Levels {
+		opts := qrcode.DefaultOptions()
+		opts.Level = l
+
+		c, err := qrcode.Encode([]byte(exampleData), opts)
+		require.NoError(t, err)
+
+		assert.Equal(t, l, c.Level)
+		assert.GreaterOrEqual(t, c.Version, previous, "Level %s", l)
+		assert.Equal(t, qrcode.ModulesFor(c.Version), c.Size())
+
+		v, err := qrcode.VersionFor([]byte(exampleData), l)
+		require.NoError(t, err)
+		assert.Equal(t, c.Version, v)
+
+		b, err := c.PNG(400)
+		require.NoError(t, err)
+
+		text, level := decodePNG(t, b)
+		assert.Equal(t, exampleData, text)
+		assert.Equal(t, string(l), level)
+
+		previous = c.Version
+	}
+}
+
+func TestEncodeOptions(t *testing.T) {
+	c, err := qrcode.Encode([]byte(exampleData), qrcode.Options{MinVersion: 12, Mask: 3, Mode: qrcode.ModeByte, QuietZone: 2})
+	require.NoError(t, err)
+
+	assert.Equal(t, 12, c.Version)
+	assert.Equal(t, 3, c.Mask)
+	assert.Equal(t, qrcode.LevelM, c.Level)
+	assert.Equal(t, 2, c.QuietZone)
+	assert.Equal(t, 65+4, c.Image().Rect.Dx())
+
+	b, err := c.PNG(300)
+	require.NoError(t, err)
+
+	text, _ := decodePNG(t, b)
+	assert.Equal(t, exampleData, text)
+
+	// A minimum version that is too small for the data is increased
+	c, err = qrcode.Encode([]byte(exampleData), qrcode.Options{MinVersion: 1})
+	require.NoError(t, err)
+	assert.Greater(t, c.Version, 1)
+
+	// Numeric and alphanumeric data is encoded more compactly
+	c, err = qrcode.Encode([]byte(strings.Repeat("1234567890", 3)), qrcode.DefaultOptions())
+	require.NoError(t, err)
+	assert.Equal(t, 1, c.Version)
+
+	c, err = qrcode.Encode([]byte(strings.Repeat("1234567890", 3)), qrcode.Options{Mode: qrcode.ModeByte})
+	require.NoError(t, err)
+	assert.Equal(t, 3, c.Version)
+
+	c, err = qrcode.Encode([]byte("HELLO WORLD"), qrcode.Options{Mode: qrcode.ModeAlphanumeric})
+	require.NoError(t, err)
+	assert.Equal(t, 1, c.Version)
+}
+
+func TestEncodeECI(t *testing.T) {
+	// "Plačilo" in ISO 8859-2
+	data := []byte("Pla\xe8ilo")
+
+	c, err := qrcode.Encode(data, qrcode.Options{Mode: qrcode.ModeByte, ECI: qrcode.ECIISO88592, QuietZone: 4})
+	require.NoError(t, err)
+
+	b, err := c.PNG(300)
+	require.NoError(t, err)
+
+	text, _ := decodePNG(t, b)
+	assert.Equal(t, "Plačilo", text, "the reader decodes the data in the character set of the ECI")
+
+	// ECI 170 is ASCII, whose assignment number takes 2 bytes
+	c, err = qrcode.Encode([]byte("Placilo"), qrcode.Options{Mode: qrcode.ModeByte, ECI: 170, QuietZone: 4})
+	require.NoError(t, err)
+
+	b, err = c.PNG(300)
+	require.NoError(t, err)
+
+	text, _ = decodePNG(t, b)
+	assert.Equal(t, "Placilo", text)
+
+	plain, err := qrcode.Encode(bytes.Repeat([]byte("x"), 106), qrcode.Options{Mode: qrcode.ModeByte})
+	require.NoError(t, err)
+
+	withECI, err := qrcode.Encode(bytes.Repeat([]byte("x"), 106), qrcode.Options{Mode: qrcode.ModeByte, ECI: qrcode.ECIUTF8})
+	require.NoError(t, err)
+	assert.Greater(t, withECI.Version, plain.Version, "the ECI takes space in the code")
+}
+
+func TestEncodeErrors(t *testing.T) {
+	for _, tc := range []struct {
+		opts qrcode.Options
+		err  error
+	}{
+		{qrcode.Options{Level: "X"}, qrcode.ErrLevel},
+		{qrcode.Options{Mode: "kanji"}, qrcode.ErrMode},
+		{qrcode.Options{Mode: qrcode.ModeNumeric}, qrcode.ErrModeData},
+		{qrcode.Options{Mode: qrcode.ModeAlphanumeric}, qrcode.ErrModeData},
+		{qrcode.Options{MinVersion: 41}, qrcode.ErrVersion},
+		{qrcode.Options{MinVersion: -1}, qrcode.ErrVersion},
+		{qrcode.Options{Mask: 8}, qrcode.ErrMask},
+		{qrcode.Options{Mask: -2}, qrcode.ErrMask},
+		{qrcode.Options{QuietZone: -1}, qrcode.ErrQuietZone},
+		{qrcode.Options{ECI: -1}, qrcode.ErrECI},
+		{qrcode.Options{ECI: qrcode.MaxECI + 1}, qrcode.ErrECI},
+	} {
+		_, err := qrcode.Encode([]byte(exampleData), tc.opts)
+		require.ErrorIs(t, err, tc.err, "Options: %#v", tc.opts)
+	}
+
+	_, err := qrcode.Encode(bytes.Repeat([]byte("x"), 3000), qrcode.DefaultOptions())
+	require.ErrorIs(t, err, qrcode.ErrTooLong)
+
+	c, err := qrcode.Encode([]byte(exampleData), qrcode.DefaultOptions())
+	require.NoError(t, err)
+
+	_, err = c.PNG(20)
+	require.ErrorIs(t, err, qrcode.ErrImageSize)
+}
+
+func TestTerminal(t *testing.T) {
+	c, err := qrcode.Encode([]byte("hello"), qrcode.DefaultOptions())
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(string(c.Terminal()), "\n"), "\n")
+
+	// 21 modules and 2x4 modules quiet zone, two modules per line
+	require.Len(t, lines, 15)
+
+	for _, l := range lines {
+		assert.Equal(t, 29, len([]rune(l)))
+	}
+
+	assert.Equal(t, strings.Repeat("█", 29), lines[0])
+	assert.Equal(t, "████ ▄▄▄▄▄ █", string([]rune(lines[2])[:12]))
+}
+
+func TestSVG(t *testing.T) {
+	c, err := qrcode.Encode([]byte("hello"), qrcode.Options{QuietZone: 1})
+	require.NoError(t, err)
+
+	svg := string(c.SVG(qrcode.SVGOptions{Size: "10mm", Foreground: "red", Background: "white"}))
+
+	assert.Contains(t, svg, `viewBox="0 0 23 23" width="10mm" height="10mm"`)
+	assert.Contains(t, svg, `<rect width="23" height="23" fill="white"/>`)
+	assert.Contains(t, svg, `<path fill="red" d="M1 1h7v1h-7z`)
+	assert.Equal(t, len(c.Runs()), strings.Count(svg, "z"))
+}
+
+// encoder encodes its data with the options
+type encoder string
+
+func (e encoder) QRCode(opts qrcode.Options) (*qrcode.Code, error) {
+	return qrcode.Encode([]byte(e), opts)
+}
+
+func TestEncoder(t *testing.T) {
+	b, err := qrcode.ToPNG(encoder(exampleData), qrcode.DefaultOptions(), 300)
+	require.NoError(t, err)
+
+	text, _ := decodePNG(t, b)
+	assert.Equal(t, exampleData, text)
+
+	svg, err := qrcode.ToSVG(encoder("hello"), qrcode.DefaultOptions(), qrcode.DefaultSVGOptions())
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(svg), "<?xml"))
+
+	terminal, err := qrcode.ToBytes(encoder("hello"), qrcode.DefaultOptions())
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSuffix(string(terminal), "\n"), "\n"), 15)
+
+	terminal, err = qrcode.ToBytes(encoder("hello"), qrcode.Options{QuietZone: 1})
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSuffix(string(terminal), "\n"), "\n"), 12, "the options are passed to the encoder")
+
+	_, err = qrcode.ToPNG(encoder("hello"), qrcode.DefaultOptions(), 10)
+	require.ErrorIs(t, err, qrcode.ErrImageSize)
+}
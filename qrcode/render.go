@@ -0,0 +1,207 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// ErrImageSize is returned when the requested image is too small to hold one pixel per module
+var ErrImageSize = errors.New("image size is smaller than the QR code")
+
+// Terminal block characters; dark modules are printed as blanks, for terminals with a dark background
+const (
+	blockLightLight = "█"
+	blockLightDark  = "▀"
+	blockDarkLight  = "▄"
+	blockDarkDark   = " "
+)
+
+// Image returns the code, including its quiet zone, with one pixel per module
+func (c *Code) Image() *image.Gray {
+	q := c.QuietZone
+	d := c.Size() + 2*q
+	img := image.NewGray(image.Rect(0, 0, d, d))
+
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+
+	for _, r := range c.Runs() {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetGray(x, r.Min.Y, color.Gray{})
+		}
+	}
+
+	return img
+}
+
+// PNG returns a PNG image of size x size pixels; every module is scaled to the same
+// (integer) number of pixels, and the code is centered in the image
+func (c *Code) PNG(size int) ([]byte, error) {
+	img, err := c.ScaledImage(size)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+
+	if err := png.Encode(&b, img); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// ScaledImage returns an image of size x size pixels, as PNG does, eg. to draw a logo on top of the code
+func (c *Code) ScaledImage(size int) (*image.Gray, error) {
+	src := c.Image()
+	d := src.Rect.Dx()
+
+	factor := size / d
+	if factor < 1 {
+		return nil, fmt.Errorf("%w: %d < %d", ErrImageSize, size, d)
+	}
+
+	offset := (size - d*factor) / 2
+	img := image.NewGray(image.Rect(0, 0, size, size))
+
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+
+	for y := range d * factor {
+		for x := range d * factor {
+			img.SetGray(offset+x, offset+y, src.GrayAt(x/factor, y/factor))
+		}
+	}
+
+	return img, nil
+}
+
+// Terminal returns a text representation of the code, using half blocks so every
+// character holds two modules above each other
+func (c *Code) Terminal() []byte {
+	return Terminal(c.Image())
+}
+
+// Terminal returns a text representation of an image with one pixel per module, as Code.Image returns it,
+// using half blocks so every character holds two pixels above each other; pixels darker than middle gray are dark
+func Terminal(img *image.Gray) []byte {
+	var b bytes.Buffer
+
+	r := img.Rect
+
+	dark := func(x, y int) bool {
+		return image.Pt(x, y).In(r) && img.GrayAt(x, y).Y < 0x80
+	}
+
+	for y := r.Min.Y; y < r.Max.Y; y += 2 {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			switch top, bottom := dark(x, y), dark(x, y+1); {
+			case top && bottom:
+				b.WriteString(blockDarkDark)
+			case top:
+				b.WriteString(blockDarkLight)
+			case bottom:
+				b.WriteString(blockLightDark)
+			default:
+				b.WriteString(blockLightLight)
+			}
+		}
+
+		b.WriteByte('\n')
+	}
+
+	return b.Bytes()
+}
+
+// SVGOptions configures the SVG representation of the code
+type SVGOptions struct {
+	// Size is the width and height of the image, in any SVG length (eg. "300", "4cm");
+	// leave empty to scale the image to its container
+	Size string
+	// Foreground is the color of the dark modules
+	Foreground string
+	// Background is the color of the light modules and the quiet zone;
+	// leave empty for a transparent background
+	Background string
+}
+
+// DefaultSVGOptions returns options for black modules on a white background
+func DefaultSVGOptions() SVGOptions {
+	return SVGOptions{
+		Foreground: "#000000",
+		Background: "#ffffff",
+	}
+}
+
+// SVG returns an SVG image of the code, with one path per run of dark modules
+// Every module is one unit in the viewBox, so the image can be scaled to any size
+func (c *Code) SVG(opts SVGOptions) []byte {
+	var b bytes.Buffer
+
+	d := c.Size() + 2*c.QuietZone
+
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" version="1.1" viewBox="0 0 %d %d"`, d, d)
+
+	if opts.Size != "" {
+		fmt.Fprintf(&b, ` width="%[1]s" height="%[1]s"`, html.EscapeString(opts.Size))
+	}
+
+	b.WriteString(` shape-rendering="crispEdges">` + "\n")
+
+	if opts.Background != "" {
+		fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="%s"/>`+"\n", d, d, html.EscapeString(opts.Background))
+	}
+
+	fmt.Fprintf(&b, `<path fill="%s" d="`, html.EscapeString(opts.Foreground))
+
+	for _, r := range c.Runs() {
+		fmt.Fprintf(&b, "M%d %dh%dv1h-%[3]dz", r.Min.X, r.Min.Y, r.Dx())
+	}
+
+	b.WriteString(`"/>` + "\n</svg>\n")
+
+	return b.Bytes()
+}
+
+// Encoder is a payload that encodes itself as a QR code, such as the payment of one of the formats
+type Encoder interface {
+	QRCode(opts Options) (*Code, error)
+}
+
+// ToBytes returns a text representation of the QR code of e, for the terminal
+func ToBytes(e Encoder, opts Options) ([]byte, error) {
+	code, err := e.QRCode(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return code.Terminal(), nil
+}
+
+// ToPNG returns a PNG image of the QR code of e, of size x size pixels
+func ToPNG(e Encoder, opts Options, size int) ([]byte, error) {
+	code, err := e.QRCode(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return code.PNG(size)
+}
+
+// ToSVG returns an SVG image of the QR code of e
+func ToSVG(e Encoder, opts Options, svg SVGOptions) ([]byte, error) {
+	code, err := e.QRCode(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return code.SVG(svg), nil
+}
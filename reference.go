@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/spf13/cobra"
+)
+
+func referenceCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reference",
+		Short: "Generate structured creditor references",
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.AddCommand(referenceRFCmd())
+	cmd.AddCommand(referenceOGMCmd())
+
+	return cmd
+}
+
+func referenceRFCmd() *cobra.Command {
+	var printFormat bool
+
+	cmd := &cobra.Command{
+		Use:          "rf <invoice-number>",
+		Short:        "Generate an ISO 11649 (RF) creditor reference",
+		Long:         "Generate an ISO 11649 (RF) creditor reference from an invoice number or any other base of up to 21 letters and digits.",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ref, err := payment.NewRFReference(args[0])
+			if err != nil {
+				return err
+			}
+
+			if printFormat {
+				ref = payment.FormatRFReference(ref)
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout(), ref)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&printFormat, "print", false, "Print the reference in groups of 4 characters")
+
+	return cmd
+}
+
+func referenceOGMCmd() *cobra.Command {
+	var printFormat bool
+
+	cmd := &cobra.Command{
+		Use:          "ogm <invoice-number>",
+		Short:        "Generate a Belgian structured communication (OGM/VCS)",
+		Long:         "Generate a Belgian structured communication (OGM/VCS) from an invoice number of up to 10 digits.",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ref, err := payment.NewOGMReference(args[0])
+			if err != nil {
+				return err
+			}
+
+			if printFormat {
+				ref = payment.FormatOGMReference(ref)
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout(), ref)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&printFormat, "print", false, "Print the reference as +++123/4567/89012+++")
+
+	return cmd
+}
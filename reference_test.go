@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReferenceRFCommand(t *testing.T) {
+	for expected, args := range map[string][]string{
+		"RF18539007547034\n":    {"reference", "rf", "539007547034"},
+		"RF18 5390 0754 7034\n": {"reference", "rf", "--print", "539007547034"},
+	} {
+		q := qrParams{
+			Payment: payment.New(),
+		}
+
+		cmdRoot, err := newCommand(&q)
+		require.NoError(t, err)
+
+		actualOut := new(bytes.Buffer)
+
+		cmdRoot.SetOut(actualOut)
+		cmdRoot.SetArgs(args)
+
+		require.NoError(t, cmdRoot.Execute())
+		assert.Equal(t, expected, actualOut.String())
+	}
+}
+
+func TestReferenceOGMCommand(t *testing.T) {
+	for expected, args := range map[string][]string{
+		"090933755493\n":         {"reference", "ogm", "909337554"},
+		"+++090/9337/55493+++\n": {"reference", "ogm", "--print", "0909337554"},
+	} {
+		q := qrParams{
+			Payment: payment.New(),
+		}
+
+		cmdRoot, err := newCommand(&q)
+		require.NoError(t, err)
+
+		actualOut := new(bytes.Buffer)
+
+		cmdRoot.SetOut(actualOut)
+		cmdRoot.SetArgs(args)
+
+		require.NoError(t, cmdRoot.Execute())
+		assert.Equal(t, expected, actualOut.String())
+	}
+}
+
+func TestStructuredAuto(t *testing.T) {
+	for remittance, structured := range map[string]bool{
+		"RF18539007547034":     true,
+		"RF19539007547034":     false,
+		"+++090/9337/55493+++": true,
+		"+++090/9337/55494+++": false,
+		"Invoice 1234":         false,
+	} {
+		q := qrParams{
+			Payment: payment.New(),
+		}
+
+		cmdRoot, err := newCommand(&q)
+		require.NoError(t, err)
+
+		cmdRoot.SetArgs([]string{
+			"--structured=auto",
+			"--name", "Franz Mustermänn",
+			"--iban", "DE71110220330123456789",
+			"--amount", "12.3",
+			"--remittance", remittance,
+			"--file", filepath.Join(t.TempDir(), "qr.txt"),
+		})
+
+		require.NoError(t, cmdRoot.Execute())
+		assert.Equal(t, structured, q.Payment.RemittanceIsStructured, remittance)
+	}
+}
+
+func TestStructuredFlag(t *testing.T) {
+	q := qrParams{
+		Payment: payment.New(),
+	}
+
+	cmdRoot, err := newCommand(&q)
+	require.NoError(t, err)
+
+	require.NoError(t, cmdRoot.ParseFlags([]string{"--structured"}))
+	assert.True(t, q.Payment.RemittanceIsStructured)
+	assert.Equal(t, "true", q.Structured.String())
+
+	require.NoError(t, cmdRoot.ParseFlags([]string{"--structured=false"}))
+	assert.False(t, q.Payment.RemittanceIsStructured)
+
+	require.NoError(t, cmdRoot.ParseFlags([]string{"--structured=AUTO"}))
+	assert.Equal(t, "auto", q.Structured.String())
+
+	require.Error(t, cmdRoot.ParseFlags([]string{"--structured=maybe"}))
+}
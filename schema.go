@@ -0,0 +1,18 @@
+package main
+
+import (
+	"github.com/jovandeginste/payme/payment"
+	"github.com/spf13/cobra"
+)
+
+func schemaCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema of the payment files read with --from",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			_, err := cmd.OutOrStdout().Write(payment.JSONSchema)
+			return err
+		},
+	}
+}
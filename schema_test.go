@@ -0,0 +1,25 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaCommand(t *testing.T) {
+	cmdRoot, err := newCommand(&qrParams{Payment: payment.New()})
+	require.NoError(t, err)
+
+	out := new(bytes.Buffer)
+
+	cmdRoot.SetOut(out)
+	cmdRoot.SetArgs([]string{"schema"})
+
+	require.NoError(t, cmdRoot.Execute())
+	assert.True(t, json.Valid(out.Bytes()))
+	assert.Equal(t, payment.JSONSchema, out.Bytes())
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/jovandeginste/payme/server"
+	"github.com/spf13/cobra"
+)
+
+// serveParams are the settings of the HTTP server, on top of the base payment
+type serveParams struct {
+	Listen  string
+	Options server.Options
+}
+
+func serveCmd(q *qrParams) *cobra.Command {
+	s := serveParams{Options: server.DefaultOptions()}
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve QR codes over HTTP",
+		Long: `Serve QR codes over HTTP:
+
+  GET  /qr?iban=...&name=...&amount=...&remittance=...&format=png|svg|txt
+  POST /qr?format=png|svg|txt, with a JSON payment as the body
+  GET  /openapi.json, the OpenAPI document of the API
+
+The payment flags set the base payment that every request is applied to, eg. the name and IBAN of the beneficiary.
+Invalid payments are answered with 422 Unprocessable Entity and the problems by field.`,
+		Example: `  payme serve --listen localhost:8080 --name "Franz Mustermänn" --iban DE71110220330123456789
+  curl "http://localhost:8080/qr?amount=12.3&remittance=Invoice+1234" > QR.png`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return q.applyConfig(cmd)
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			l, err := net.Listen("tcp", s.Listen)
+			if err != nil {
+				return err
+			}
+
+			log.Printf("Listening on http://%s", l.Addr())
+
+			return s.serve(ctx, q, l)
+		},
+	}
+
+	cmd.Flags().StringVar(&s.Listen, "listen", "localhost:8080", "Address to listen on")
+	cmd.Flags().IntVar(&s.Options.PNGSize, "png-size", qrSize, "Width and height of PNG images, in pixels")
+	cmd.Flags().Int64Var(&s.Options.MaxBodyBytes, "max-body-size", s.Options.MaxBodyBytes, "Largest request body to accept, in bytes")
+	cmd.Flags().DurationVar(&s.Options.Timeout, "timeout", s.Options.Timeout, "Longest time a request may take")
+	cmd.Flags().DurationVar(&s.Options.CacheMaxAge, "cache-max-age", s.Options.CacheMaxAge, "How long clients may cache a code")
+
+	q.addPaymentFlags(cmd.Flags())
+
+	return cmd
+}
+
+// serve answers requests on the listener until the context is done, and then shuts down gracefully
+func (s *serveParams) serve(ctx context.Context, q *qrParams, l net.Listener) error {
+	if err := q.prepare(); err != nil {
+		return err
+	}
+
+	s.Options.Base = q.Payment
+	s.Options.QR = q.QR
+
+	srv := &http.Server{
+		Handler:           server.New(s.Options),
+		ReadHeaderTimeout: s.Options.Timeout,
+		ReadTimeout:       s.Options.Timeout,
+		WriteTimeout:      2 * s.Options.Timeout,
+	}
+
+	errs := make(chan error, 1)
+
+	go func() {
+		errs <- srv.Serve(l)
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/jovandeginste/payme/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServe(t *testing.T) {
+	q := qrParams{Payment: payment.New()}
+	q.Payment.NameBeneficiary = "Franz Mustermänn"
+	q.Payment.IBANBeneficiary = "DE71110220330123456789"
+
+	s := serveParams{Options: server.DefaultOptions()}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error, 1)
+
+	go func() {
+		done <- s.serve(ctx, &q, l)
+	}()
+
+	res, err := http.Get("http://" + l.Addr().String() + "/qr?amount=12.3&remittance=Invoice+1234")
+	require.NoError(t, err)
+
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	p, err := payment.FromQRImage(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "Franz Mustermänn", p.NameBeneficiary)
+	assert.Equal(t, "Invoice 1234", p.Remittance)
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestServeFlags(t *testing.T) {
+	writeTestConfig(t, testConfig)
+
+	q := qrParams{Payment: payment.New()}
+
+	cmdRoot, err := newCommand(&q)
+	require.NoError(t, err)
+
+	cmd, _, err := cmdRoot.Find([]string{"serve"})
+	require.NoError(t, err)
+
+	require.NoError(t, cmd.ParseFlags([]string{"--listen", ":0", "--timeout", "1s"}))
+	require.NoError(t, q.applyConfig(cmd))
+
+	assert.Equal(t, "Franz Mustermänn", q.Payment.NameBeneficiary)
+	assert.Equal(t, "1s", cmd.Flags().Lookup("timeout").Value.String())
+}
@@ -0,0 +1,373 @@
+// Package server provides an http.Handler that generates SEPA payment QR codes,
+// so they can be served from a web shop or intranet without running the payme CLI.
+//
+// Routes:
+//   - GET /qr?iban=...&name=...&amount=...&remittance=...&format=png|svg|txt
+//   - POST /qr?format=png|svg|txt, with a JSON payment as the body
+//   - GET /openapi.json, the OpenAPI document of the API
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	_ "embed" // for the OpenAPI document
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/jovandeginste/payme/qrcode"
+)
+
+// Format is the representation of the QR code in a response
+type Format string
+
+const (
+	// FormatPNG is a PNG image; this is the default
+	FormatPNG Format = "png"
+	// FormatSVG is an SVG image
+	FormatSVG Format = "svg"
+	// FormatText is the QR code drawn with block characters, to print on a terminal
+	FormatText Format = "txt"
+)
+
+// contentTypes are the content types of the formats
+var contentTypes = map[Format]string{
+	FormatPNG:  "image/png",
+	FormatSVG:  "image/svg+xml",
+	FormatText: "text/plain; charset=utf-8",
+}
+
+//go:embed openapi.json
+var openAPI []byte
+
+var (
+	// ErrFormat is returned when the format is not png, svg or txt
+	ErrFormat = errors.New("format should be png, svg or txt")
+	// ErrParameter is returned when a query parameter is not a payment field
+	ErrParameter = errors.New("unknown parameter")
+	// ErrStructured is returned when the structured parameter is not true, false or auto
+	ErrStructured = errors.New("structured should be true, false or auto")
+	// ErrBodyTooLarge is returned when the request body is larger than Options.MaxBodyBytes
+	ErrBodyTooLarge = errors.New("request body too large")
+)
+
+// Options configures the handler
+type Options struct {
+	// Base is the payment the fields of every request are applied to, eg. with the name and IBAN of the
+	// beneficiary; leave nil for payment.New()
+	Base *payment.Payment
+	// QR configures how the QR codes are encoded; the ec_level query parameter overrides its level
+	QR qrcode.Options
+	// PNGSize is the width and height of PNG images, in pixels
+	PNGSize int
+	// MaxBodyBytes is the largest request body that is accepted
+	MaxBodyBytes int64
+	// Timeout is the longest time a request may take; slower requests get 503 Service Unavailable
+	Timeout time.Duration
+	// CacheMaxAge is how long clients and proxies may cache a code
+	CacheMaxAge time.Duration
+}
+
+// DefaultOptions returns the recommended options
+func DefaultOptions() Options {
+	return Options{
+		QR:           qrcode.DefaultOptions(),
+		PNGSize:      300,
+		MaxBodyBytes: 64 << 10,
+		Timeout:      10 * time.Second,
+		CacheMaxAge:  24 * time.Hour,
+	}
+}
+
+// Server is an http.Handler that generates payment QR codes
+type Server struct {
+	opts    Options
+	handler http.Handler
+}
+
+// New returns a Server with the options
+func New(opts Options) *Server {
+	if opts.Base == nil {
+		opts.Base = payment.New()
+	}
+
+	s := &Server{opts: opts}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /qr", s.handleGet)
+	mux.HandleFunc("POST /qr", s.handlePost)
+	mux.HandleFunc("GET /openapi.json", s.handleOpenAPI)
+
+	s.handler = mux
+	if opts.Timeout > 0 {
+		s.handler = jsonTimeout(http.TimeoutHandler(mux, opts.Timeout, `{"error":"request timed out"}`))
+	}
+
+	return s
+}
+
+// jsonTimeout sets the content type of the JSON body that http.TimeoutHandler writes when a request times out
+func jsonTimeout(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(timeoutWriter{w}, r)
+	})
+}
+
+// timeoutWriter adds the JSON content type to a 503 Service Unavailable response without one
+type timeoutWriter struct {
+	http.ResponseWriter
+}
+
+func (w timeoutWriter) WriteHeader(code int) {
+	if code == http.StatusServiceUnavailable && w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// errorResponse is the body of an error response; validation errors are listed by field
+type errorResponse struct {
+	Error  string                   `json:"error"`
+	Errors payment.ValidationErrors `json:"errors,omitempty"`
+}
+
+// handleGet generates a code from the payment fields in the query parameters
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	p, err := s.paymentFromQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	opts, err := s.qrOptions(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s.serveCode(w, r, p, opts)
+}
+
+// handlePost generates a code from the JSON payment in the body
+func (s *Server) handlePost(w http.ResponseWriter, r *http.Request) {
+	p := *s.opts.Base
+
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.opts.MaxBodyBytes))
+
+	if err := dec.Decode(&p); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("%w: more than %d bytes", ErrBodyTooLarge, maxBytesErr.Limit))
+			return
+		}
+
+		if isAmountError(err) {
+			writeError(w, http.StatusUnprocessableEntity,
+				payment.ValidationErrors{payment.NewValidationError("EuroAmount", "AT-04", payment.RuleFormat, "", err)})
+
+			return
+		}
+
+		writeError(w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	s.serveCode(w, r, &p, s.opts.QR)
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(openAPI)
+}
+
+// serveCode validates the payment and writes its code in the requested format, with an ETag
+// derived from the payload, so unchanged codes are answered with 304 Not Modified
+func (s *Server) serveCode(w http.ResponseWriter, r *http.Request, p *payment.Payment, opts qrcode.Options) {
+	format := Format(r.URL.Query().Get("format"))
+	if format == "" {
+		format = FormatPNG
+	}
+
+	contentType, ok := contentTypes[format]
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("%w: %q", ErrFormat, format))
+		return
+	}
+
+	payload, err := p.ToBytes()
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+
+	code, err := s.render(p, opts, format)
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", s.etag(payload, opts, format))
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(s.opts.CacheMaxAge.Seconds())))
+
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(code))
+}
+
+// render returns the QR code of the payment in the format
+func (s *Server) render(p *payment.Payment, opts qrcode.Options, format Format) ([]byte, error) {
+	switch format {
+	case FormatSVG:
+		return qrcode.ToSVG(p, opts, qrcode.DefaultSVGOptions())
+	case FormatText:
+		return qrcode.ToBytes(p, opts)
+	}
+
+	return qrcode.ToPNG(p, opts, s.opts.PNGSize)
+}
+
+// etag returns a strong ETag for the code: a hash of the payload and of everything that changes its rendering
+func (s *Server) etag(payload []byte, opts qrcode.Options, format Format) string {
+	h := sha256.New()
+	h.Write(payload)
+	fmt.Fprintf(h, "\x00%s\x00%d\x00%+v", format, s.opts.PNGSize, opts)
+
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// queryFields sets a payment field from a query parameter
+var queryFields = map[string]func(p *payment.Payment, v string) error{
+	"name":            func(p *payment.Payment, v string) error { p.NameBeneficiary = v; return nil },
+	"iban":            func(p *payment.Payment, v string) error { p.IBANBeneficiary = v; return nil },
+	"bic":             func(p *payment.Payment, v string) error { p.BICBeneficiary = v; return nil },
+	"amount":          func(p *payment.Payment, v string) error { return p.EuroAmount.UnmarshalText([]byte(v)) },
+	"purpose":         func(p *payment.Payment, v string) error { p.Purpose = v; return nil },
+	"remittance":      func(p *payment.Payment, v string) error { p.Remittance = v; return nil },
+	"b2o_information": func(p *payment.Payment, v string) error { p.B2OInformation = v; return nil },
+	"structured":      setStructured,
+	"character_set":   setInt(func(p *payment.Payment) *int { return &p.CharacterSet }),
+	"version":         setInt(func(p *payment.Payment) *int { return &p.Version }),
+}
+
+// paymentFromQuery returns a copy of the base payment with the fields of the query parameters;
+// structured=auto is resolved after every field is set
+func (s *Server) paymentFromQuery(query url.Values) (*payment.Payment, error) {
+	p := *s.opts.Base
+
+	for key, values := range query {
+		if key == "format" || key == "ec_level" {
+			continue
+		}
+
+		set, ok := queryFields[key]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrParameter, key)
+		}
+
+		v := values[len(values)-1]
+		if key == "structured" && v == "auto" {
+			continue
+		}
+
+		if err := set(&p, v); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+
+	if query.Get("structured") == "auto" {
+		p.RemittanceIsStructured = payment.IsStructuredReference(p.Remittance)
+	}
+
+	return &p, nil
+}
+
+func setStructured(p *payment.Payment, v string) error {
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return fmt.Errorf("%w: %q", ErrStructured, v)
+	}
+
+	p.RemittanceIsStructured = b
+
+	return nil
+}
+
+// qrOptions returns the QR code options of the server, with the level of the ec_level query parameter
+func (s *Server) qrOptions(query url.Values) (qrcode.Options, error) {
+	opts := s.opts.QR
+
+	values := query["ec_level"]
+	if len(values) == 0 {
+		return opts, nil
+	}
+
+	v := values[len(values)-1]
+
+	level := qrcode.Level(strings.ToUpper(v))
+	if !slices.Contains(qrcode.Levels, level) {
+		return opts, fmt.Errorf("ec_level: %w: %q", qrcode.ErrLevel, v)
+	}
+
+	opts.Level = level
+
+	return opts, nil
+}
+
+func setInt(field func(p *payment.Payment) *int) func(p *payment.Payment, v string) error {
+	return func(p *payment.Payment, v string) error {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+
+		*field(p) = i
+
+		return nil
+	}
+}
+
+// isAmountError returns whether the amount could not be parsed, eg. because it has more than 2 decimals
+func isAmountError(err error) bool {
+	return errors.Is(err, payment.ErrAmountFormat) || errors.Is(err, payment.ErrAmountDecimals) ||
+		errors.Is(err, payment.ErrAmountLocale)
+}
+
+// statusCode returns 422 Unprocessable Entity for validation errors, and 500 Internal Server Error otherwise
+func statusCode(err error) int {
+	var errs payment.ValidationErrors
+	if errors.As(err, &errs) {
+		return http.StatusUnprocessableEntity
+	}
+
+	return http.StatusInternalServerError
+}
+
+// writeError writes the error as JSON; validation errors are listed by field
+func writeError(w http.ResponseWriter, status int, err error) {
+	res := errorResponse{Error: err.Error()}
+
+	if errors.As(err, &res.Errors) {
+		res.Error = "payment is not valid"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(res)
+}
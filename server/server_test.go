@@ -0,0 +1,255 @@
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/jovandeginste/payme/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testQuery = url.Values{
+	"name":       {"François D'Alsace S.A."},
+	"iban":       {"BE68539007547034"},
+	"amount":     {"12.3"},
+	"remittance": {"RF18539007547034"},
+	"structured": {"auto"},
+}
+
+type testError struct {
+	Error  string `json:"error"`
+	Errors []struct {
+		Field   string `json:"field"`
+		Code    string `json:"code"`
+		Rule    string `json:"rule"`
+		Limit   string `json:"limit"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func serve(t *testing.T, s http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+
+	return w
+}
+
+func decodeError(t *testing.T, w *httptest.ResponseRecorder) testError {
+	t.Helper()
+
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var res testError
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&res))
+
+	return res
+}
+
+func TestGet(t *testing.T) {
+	s := server.New(server.DefaultOptions())
+
+	w := serve(t, s, httptest.NewRequest(http.MethodGet, "/qr?"+testQuery.Encode(), nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Equal(t, "public, max-age=86400", w.Header().Get("Cache-Control"))
+
+	p, err := payment.FromQRImage(w.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "François D'Alsace S.A.", p.NameBeneficiary)
+	assert.Equal(t, payment.Amount(1230), p.EuroAmount)
+	assert.True(t, p.RemittanceIsStructured)
+}
+
+func TestGetFormats(t *testing.T) {
+	s := server.New(server.DefaultOptions())
+
+	tests := map[string]string{
+		"svg": "image/svg+xml",
+		"txt": "text/plain; charset=utf-8",
+	}
+
+	for format, contentType := range tests {
+		q := url.Values{"format": {format}}
+		for k, v := range testQuery {
+			q[k] = v
+		}
+
+		w := serve(t, s, httptest.NewRequest(http.MethodGet, "/qr?"+q.Encode(), nil))
+		require.Equal(t, http.StatusOK, w.Code, format)
+		assert.Equal(t, contentType, w.Header().Get("Content-Type"), format)
+	}
+
+	w := serve(t, s, httptest.NewRequest(http.MethodGet, "/qr?format=gif&"+testQuery.Encode(), nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, decodeError(t, w).Error, server.ErrFormat.Error())
+}
+
+func TestGetBadParameter(t *testing.T) {
+	s := server.New(server.DefaultOptions())
+
+	for _, q := range []string{"colour=red", "amount=12.345", "structured=maybe", "ec_level=X", "character_set=two"} {
+		w := serve(t, s, httptest.NewRequest(http.MethodGet, "/qr?"+q, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, q)
+		assert.NotEmpty(t, decodeError(t, w).Error, q)
+	}
+}
+
+func TestETag(t *testing.T) {
+	s := server.New(server.DefaultOptions())
+
+	w := serve(t, s, httptest.NewRequest(http.MethodGet, "/qr?"+testQuery.Encode(), nil))
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	req := httptest.NewRequest(http.MethodGet, "/qr?"+testQuery.Encode(), nil)
+	req.Header.Set("If-None-Match", etag)
+
+	w = serve(t, s, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.Bytes())
+
+	w = serve(t, s, httptest.NewRequest(http.MethodGet, "/qr?format=svg&"+testQuery.Encode(), nil))
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+
+	w = serve(t, s, httptest.NewRequest(http.MethodGet, "/qr?ec_level=H&"+testQuery.Encode(), nil))
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+}
+
+func TestPost(t *testing.T) {
+	s := server.New(server.DefaultOptions())
+
+	body := `{"name": "Jane Doe", "iban": "BE68539007547034", "amount": 5, "remittance": "Invoice 1234"}`
+
+	w := serve(t, s, httptest.NewRequest(http.MethodPost, "/qr?format=txt", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.NotEmpty(t, w.Header().Get("ETag"))
+}
+
+func TestPostBase(t *testing.T) {
+	opts := server.DefaultOptions()
+	opts.Base = payment.New()
+	opts.Base.NameBeneficiary = "Franz Mustermänn"
+	opts.Base.IBANBeneficiary = "DE71110220330123456789"
+
+	s := server.New(opts)
+
+	w := serve(t, s, httptest.NewRequest(http.MethodPost, "/qr", strings.NewReader(`{"amount": "1", "remittance": "Invoice 1"}`)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	p, err := payment.FromQRImage(w.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "Franz Mustermänn", p.NameBeneficiary)
+	assert.Equal(t, "Invoice 1", p.Remittance)
+
+	assert.Empty(t, opts.Base.Remittance)
+}
+
+func TestPostBadRequest(t *testing.T) {
+	s := server.New(server.DefaultOptions())
+
+	for _, body := range []string{`{"name": `, `{"ibn": "BE68539007547034"}`} {
+		w := serve(t, s, httptest.NewRequest(http.MethodPost, "/qr", strings.NewReader(body)))
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		assert.NotEmpty(t, decodeError(t, w).Error, body)
+	}
+}
+
+func TestPostBadAmount(t *testing.T) {
+	s := server.New(server.DefaultOptions())
+
+	for _, body := range []string{`{"amount": "12.345"}`, `{"amount": 12.345}`, `{"amount": "1,5"}`} {
+		w := serve(t, s, httptest.NewRequest(http.MethodPost, "/qr", strings.NewReader(body)))
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code, body)
+
+		res := decodeError(t, w)
+		require.Len(t, res.Errors, 1, body)
+		assert.Equal(t, "amount", res.Errors[0].Field, body)
+		assert.Equal(t, "AT-04", res.Errors[0].Code, body)
+		assert.Equal(t, string(payment.RuleFormat), res.Errors[0].Rule, body)
+	}
+}
+
+func TestPostTooLarge(t *testing.T) {
+	opts := server.DefaultOptions()
+	opts.MaxBodyBytes = 32
+
+	s := server.New(opts)
+
+	body := `{"name": "` + strings.Repeat("a", 64) + `"}`
+
+	w := serve(t, s, httptest.NewRequest(http.MethodPost, "/qr", strings.NewReader(body)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, decodeError(t, w).Error, server.ErrBodyTooLarge.Error())
+}
+
+func TestValidationError(t *testing.T) {
+	s := server.New(server.DefaultOptions())
+
+	body := `{"name": "", "iban": "BE00539007547034", "amount": "12.30"}`
+
+	w := serve(t, s, httptest.NewRequest(http.MethodPost, "/qr", strings.NewReader(body)))
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+	res := decodeError(t, w)
+	require.Len(t, res.Errors, 3)
+
+	assert.Equal(t, "name", res.Errors[0].Field)
+	assert.Equal(t, "AT-21", res.Errors[0].Code)
+	assert.Equal(t, string(payment.RuleRequired), res.Errors[0].Rule)
+
+	assert.Equal(t, "iban", res.Errors[1].Field)
+	assert.Equal(t, "AT-20", res.Errors[1].Code)
+	assert.Equal(t, string(payment.RuleFormat), res.Errors[1].Rule)
+
+	assert.Equal(t, "remittance", res.Errors[2].Field)
+	assert.Equal(t, "AT-05", res.Errors[2].Code)
+	assert.Equal(t, payment.ErrValidationRemittanceRequired.Error(), res.Errors[2].Message)
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	s := server.New(server.DefaultOptions())
+
+	w := serve(t, s, httptest.NewRequest(http.MethodDelete, "/qr", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestTimeout(t *testing.T) {
+	opts := server.DefaultOptions()
+	opts.Timeout = time.Nanosecond
+
+	s := server.New(opts)
+
+	w := serve(t, s, httptest.NewRequest(http.MethodGet, "/qr?"+testQuery.Encode(), nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "request timed out", decodeError(t, w).Error)
+}
+
+func TestOpenAPI(t *testing.T) {
+	s := server.New(server.DefaultOptions())
+
+	w := serve(t, s, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var doc struct {
+		OpenAPI string                    `json:"openapi"`
+		Paths   map[string]map[string]any `json:"paths"`
+	}
+
+	require.NoError(t, json.NewDecoder(bytes.NewReader(w.Body.Bytes())).Decode(&doc))
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	assert.Contains(t, doc.Paths["/qr"], "get")
+	assert.Contains(t, doc.Paths["/qr"], "post")
+}
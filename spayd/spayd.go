@@ -0,0 +1,434 @@
+// Package spayd encodes and parses Short Payment Descriptors (SPAYD), the QR payment format of the Czech Banking
+// Association: SPD*1.0*ACC:CZ2806000000000168540115*AM:450.00*CC:CZK*MSG:PAYMENT*X-VS:1234567890
+//
+// See: https://qr-platba.cz/pro-vyvojare/specifikace-formatu/
+package spayd
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/jovandeginste/payme/qrcode"
+)
+
+const (
+	// header is the start of every descriptor: the SPD tag and version 1.0
+	header = "SPD*1.0*"
+	// dateLayout is the format of the due date
+	dateLayout = "20060102"
+
+	// CurrencyCZK is the Czech koruna; this is the default
+	CurrencyCZK = "CZK"
+	// MaxAmount is the largest amount of a descriptor: 9999999.99, as it has at most 10 characters
+	MaxAmount payment.Amount = 999999999
+
+	// maxName is the largest name of the beneficiary, in characters
+	maxName = 35
+	// maxMessage is the largest message to the beneficiary, in characters
+	maxMessage = 60
+	// maxSymbol is the largest variable, constant or specific symbol, in digits
+	maxSymbol = 10
+)
+
+// Keys of the descriptor
+const (
+	keyAccount        = "ACC"
+	keyAmount         = "AM"
+	keyCurrency       = "CC"
+	keyCRC32          = "CRC32"
+	keyDueDate        = "DT"
+	keyMessage        = "MSG"
+	keyName           = "RN"
+	keyConstantSymbol = "X-KS"
+	keySpecificSymbol = "X-SS"
+	keyVariableSymbol = "X-VS"
+)
+
+var (
+	currencyValidator = regexp.MustCompile(`^[A-Z]{3}$`)
+	symbolValidator   = regexp.MustCompile(`^\d{1,10}$`)
+	keyValidator      = regexp.MustCompile(`^[A-Z][A-Z0-9-]*$`)
+
+	// ErrValidationAccount is returned when the account is not a valid IBAN
+	ErrValidationAccount = errors.New("field 'IBANBeneficiary' should be a valid IBAN")
+	// ErrValidationBIC is returned when the BIC next to the IBAN is not a valid BIC
+	ErrValidationBIC = errors.New("field 'BICBeneficiary' should be a valid BIC (ISO 9362)")
+	// ErrValidationAmount is returned when the amount is not 0 (open amount), or 0.01 or more and 9999999.99 or less
+	ErrValidationAmount = errors.New("field 'EuroAmount' must be 0 (open amount), or 0.01 or more and 9999999.99 or less")
+	// ErrValidationCurrency is returned when the currency is not a 3-letter ISO 4217 code
+	ErrValidationCurrency = errors.New("field 'Currency' should be a 3-letter currency code (ISO 4217), eg. CZK")
+	// ErrValidationNameTooLong is returned when the name of the beneficiary exceeds 35 characters
+	ErrValidationNameTooLong = errors.New("field 'NameBeneficiary' should not exceed 35 characters")
+	// ErrValidationMessageTooLong is returned when the message exceeds 60 characters
+	ErrValidationMessageTooLong = errors.New("field 'Remittance' should not exceed 60 characters")
+	// ErrValidationCharacters is returned when a text field contains control characters
+	ErrValidationCharacters = errors.New("field should only contain printable characters")
+	// ErrValidationSymbol is returned when a variable, constant or specific symbol is not 1 to 10 digits
+	ErrValidationSymbol = errors.New("field should be 1 to 10 digits")
+
+	// ErrParseHeader is returned when the descriptor does not start with SPD*1.0*
+	ErrParseHeader = errors.New("descriptor should start with " + header)
+	// ErrParseAttribute is returned when an attribute is not of the form KEY:value
+	ErrParseAttribute = errors.New("attribute should be of the form KEY:value")
+	// ErrParseDuplicate is returned when a key occurs more than once
+	ErrParseDuplicate = errors.New("attribute occurs more than once")
+	// ErrParseEscape is returned when a value has a % that is not followed by 2 hexadecimal digits
+	ErrParseEscape = errors.New("% should be followed by 2 hexadecimal digits")
+	// ErrParseAccount is returned when the descriptor has no account
+	ErrParseAccount = errors.New("attribute ACC is required")
+	// ErrParseAmount is returned when the amount is not a number with at most 2 decimals
+	ErrParseAmount = errors.New("attribute AM should be a number with at most 2 decimals, eg. 450.00")
+	// ErrParseDueDate is returned when the due date is not of the form YYYYMMDD
+	ErrParseDueDate = errors.New("attribute DT should be a date of the form YYYYMMDD")
+	// ErrParseCRC32 is returned when the checksum does not match the descriptor
+	ErrParseCRC32 = errors.New("attribute CRC32 does not match the descriptor")
+)
+
+// Payment is a payment in the SPAYD format: the account, name, amount and message of a payment.Payment, with
+// the Czech symbols and a due date
+type Payment struct {
+	// Payment holds the IBAN and BIC (ACC), the name (RN), the amount (AM) and the remittance (MSG); its other
+	// fields are not part of the descriptor
+	Payment *payment.Payment
+	// Currency is the 3-letter ISO 4217 code of the amount (CC), eg. CZK
+	Currency string
+	// VariableSymbol identifies the payment for the beneficiary, eg. the invoice number (X-VS, up to 10 digits)
+	VariableSymbol string
+	// ConstantSymbol is the kind of payment (X-KS, up to 10 digits)
+	ConstantSymbol string
+	// SpecificSymbol identifies the payer for the beneficiary, eg. the customer number (X-SS, up to 10 digits)
+	SpecificSymbol string
+	// DueDate is the date the payment is due (DT); leave it zero to pay at once
+	DueDate time.Time
+	// CRC32 adds the checksum of the descriptor (CRC32), so a reader can detect a corrupted code
+	CRC32 bool
+}
+
+// New returns a new Payment in Czech koruna
+func New() *Payment {
+	return &Payment{Payment: payment.New(), Currency: CurrencyCZK}
+}
+
+// attribute is a key and its (unescaped) value
+type attribute struct {
+	key   string
+	value string
+}
+
+// attributes returns the attributes of the descriptor that are set, sorted by key, without the checksum
+func (s *Payment) attributes() []attribute {
+	p := s.Payment
+
+	account := p.IBANBeneficiary
+	if i, err := p.IBAN(); err == nil {
+		account = i.Code
+	}
+
+	if p.BICBeneficiary != "" {
+		account += "+" + p.BICBeneficiary
+	}
+
+	attrs := []attribute{{keyAccount, account}}
+
+	if !p.IsOpenAmount() {
+		attrs = append(attrs, attribute{keyAmount, p.EuroAmount.String()})
+	}
+
+	attrs = append(attrs, attribute{keyCurrency, s.Currency})
+
+	if !s.DueDate.IsZero() {
+		attrs = append(attrs, attribute{keyDueDate, s.DueDate.Format(dateLayout)})
+	}
+
+	for _, a := range []attribute{
+		{keyMessage, p.Remittance},
+		{keyName, p.NameBeneficiary},
+		{keyConstantSymbol, s.ConstantSymbol},
+		{keySpecificSymbol, s.SpecificSymbol},
+		{keyVariableSymbol, s.VariableSymbol},
+	} {
+		if a.value != "" {
+			attrs = append(attrs, a)
+		}
+	}
+
+	return attrs
+}
+
+// encode returns the descriptor of the attributes, with the values escaped
+func encode(attrs []attribute) string {
+	return join(escaped(attrs))
+}
+
+// escaped returns the attributes with their values escaped
+func escaped(attrs []attribute) []attribute {
+	raw := make([]attribute, 0, len(attrs))
+
+	for _, a := range attrs {
+		raw = append(raw, attribute{a.key, escape(a.value)})
+	}
+
+	return raw
+}
+
+// join returns the descriptor of the attributes, with the values as they are
+func join(attrs []attribute) string {
+	parts := make([]string, 0, len(attrs))
+
+	for _, a := range attrs {
+		parts = append(parts, a.key+":"+a.value)
+	}
+
+	return header + strings.Join(parts, "*")
+}
+
+// checksum returns the CRC32 of the canonical form of the descriptor: its attributes sorted by key, without the
+// checksum, as 8 upper case hexadecimal digits
+func checksum(attrs []attribute) string {
+	return rawChecksum(escaped(attrs))
+}
+
+// rawChecksum returns the CRC32 of the attributes with their values as they appear in the descriptor, still
+// escaped; a reader checks the text it got, whichever way the writer escaped it
+func rawChecksum(attrs []attribute) string {
+	sorted := slices.Clone(attrs)
+	slices.SortFunc(sorted, func(a, b attribute) int { return strings.Compare(a.key, b.key) })
+
+	return fmt.Sprintf("%08X", crc32.ChecksumIEEE([]byte(join(sorted))))
+}
+
+// ToString returns the descriptor, after validating the payment
+func (s *Payment) ToString() (string, error) {
+	if err := s.IsValid(); err != nil {
+		return "", err
+	}
+
+	attrs := s.attributes()
+
+	if s.CRC32 {
+		attrs = append(attrs, attribute{keyCRC32, checksum(attrs)})
+	}
+
+	return encode(attrs), nil
+}
+
+// QRCode returns the QR code of the descriptor, encoded with the options
+// Upper case descriptors fit in alphanumeric mode, which makes the code smaller.
+func (s *Payment) QRCode(opts qrcode.Options) (*qrcode.Code, error) {
+	str, err := s.ToString()
+	if err != nil {
+		return nil, err
+	}
+
+	return qrcode.Encode([]byte(str), opts)
+}
+
+// IsValid checks the payment against the SPAYD specification.
+// It returns nil if all is well, or payment.ValidationErrors with every problem it finds.
+func (s *Payment) IsValid() error {
+	p := s.Payment
+
+	var errs payment.ValidationErrors
+
+	if _, err := p.IBAN(); err != nil {
+		errs = append(errs, payment.NewValidationError("IBANBeneficiary", keyAccount, payment.RuleFormat, "IBAN",
+			fmt.Errorf("%w: %w", ErrValidationAccount, err)))
+	}
+
+	if p.BICBeneficiary != "" {
+		if err := payment.ValidateBIC(p.BICBeneficiary); err != nil {
+			errs = append(errs, payment.NewValidationError("BICBeneficiary", keyAccount, payment.RuleFormat, "ISO 9362",
+				fmt.Errorf("%w: %w", ErrValidationBIC, err)))
+		}
+	}
+
+	if p.EuroAmount < 0 || p.EuroAmount > MaxAmount {
+		errs = append(errs, payment.NewValidationError("EuroAmount", keyAmount, payment.RuleRange, "0.01..9999999.99", ErrValidationAmount))
+	}
+
+	if !currencyValidator.MatchString(s.Currency) {
+		errs = append(errs, payment.NewValidationError("Currency", keyCurrency, payment.RuleFormat, "ISO 4217",
+			fmt.Errorf("%w: %q", ErrValidationCurrency, s.Currency)))
+	}
+
+	errs = append(errs, textRule(keyName, maxName, ErrValidationNameTooLong).Validate("NameBeneficiary", p.NameBeneficiary)...)
+	errs = append(errs, textRule(keyMessage, maxMessage, ErrValidationMessageTooLong).Validate("Remittance", p.Remittance)...)
+
+	for _, f := range []struct {
+		field string
+		key   string
+		value string
+	}{
+		{"VariableSymbol", keyVariableSymbol, s.VariableSymbol},
+		{"ConstantSymbol", keyConstantSymbol, s.ConstantSymbol},
+		{"SpecificSymbol", keySpecificSymbol, s.SpecificSymbol},
+	} {
+		if f.value != "" && !symbolValidator.MatchString(f.value) {
+			errs = append(errs, payment.NewValidationError(f.field, f.key, payment.RuleFormat, strconv.Itoa(maxSymbol),
+				fmt.Errorf("%w: %s: %q", ErrValidationSymbol, f.field, f.value)))
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+// Parse reads a descriptor into a Payment. Unknown attributes are ignored, as the specification requires, and
+// the checksum is verified when it is present. Parse only checks the syntax of the descriptor; use IsValid to
+// check the content.
+func Parse(s string) (*Payment, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(s), header)
+	if !ok {
+		return nil, ErrParseHeader
+	}
+
+	var (
+		attrs, raw []attribute
+		sum        string
+	)
+
+	seen := map[string]bool{}
+
+	for part := range strings.SplitSeq(strings.TrimSuffix(rest, "*"), "*") {
+		key, value, ok := strings.Cut(part, ":")
+		if !ok || !keyValidator.MatchString(key) {
+			return nil, fmt.Errorf("%w: %q", ErrParseAttribute, part)
+		}
+
+		if seen[key] {
+			return nil, fmt.Errorf("%w: %s", ErrParseDuplicate, key)
+		}
+
+		seen[key] = true
+
+		unescaped, err := unescape(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+
+		if key == keyCRC32 {
+			sum = unescaped
+			continue
+		}
+
+		attrs = append(attrs, attribute{key, unescaped})
+		raw = append(raw, attribute{key, value})
+	}
+
+	if sum != "" && !strings.EqualFold(sum, rawChecksum(raw)) {
+		return nil, fmt.Errorf("%w: %s", ErrParseCRC32, sum)
+	}
+
+	sp := New()
+	sp.CRC32 = sum != ""
+
+	if err := sp.set(attrs); err != nil {
+		return nil, err
+	}
+
+	return sp, nil
+}
+
+// set sets the fields of the payment from the attributes of a descriptor
+func (s *Payment) set(attrs []attribute) error {
+	p := s.Payment
+
+	for _, a := range attrs {
+		switch a.key {
+		case keyAccount:
+			p.IBANBeneficiary, p.BICBeneficiary, _ = strings.Cut(a.value, "+")
+		case keyAmount:
+			amount, err := payment.ParseAmount(a.value, payment.LocaleNone)
+			if err != nil {
+				return fmt.Errorf("%w: %w", ErrParseAmount, err)
+			}
+
+			p.EuroAmount = amount
+		case keyCurrency:
+			s.Currency = a.value
+		case keyDueDate:
+			t, err := time.Parse(dateLayout, a.value)
+			if err != nil {
+				return fmt.Errorf("%w: %q", ErrParseDueDate, a.value)
+			}
+
+			s.DueDate = t
+		case keyMessage:
+			p.Remittance = a.value
+		case keyName:
+			p.NameBeneficiary = a.value
+		case keyConstantSymbol:
+			s.ConstantSymbol = a.value
+		case keySpecificSymbol:
+			s.SpecificSymbol = a.value
+		case keyVariableSymbol:
+			s.VariableSymbol = a.value
+		}
+	}
+
+	if p.IBANBeneficiary == "" {
+		return ErrParseAccount
+	}
+
+	return nil
+}
+
+// escape escapes the characters of a value that have a meaning in the descriptor: * separates the attributes,
+// and % starts an escape sequence
+func escape(s string) string {
+	return strings.NewReplacer("%", "%25", "*", "%2A").Replace(s)
+}
+
+// unescape decodes the escape sequences (%XX, with XX the hexadecimal value of a byte) of a value
+func unescape(s string) (string, error) {
+	if !strings.Contains(s, "%") {
+		return s, nil
+	}
+
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		if i+3 > len(s) {
+			return "", fmt.Errorf("%w: %q", ErrParseEscape, s[i:])
+		}
+
+		v, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+		if err != nil {
+			return "", fmt.Errorf("%w: %q", ErrParseEscape, s[i:i+3])
+		}
+
+		b.WriteByte(byte(v))
+		i += 2
+	}
+
+	return b.String(), nil
+}
+
+// textRule returns the rule of a text field: at most limit printable characters; code is the key of the
+// field, and tooLong the error when it is longer
+func textRule(code string, limit int, tooLong error) payment.TextRule {
+	return payment.TextRule{
+		Code:          code,
+		MaxLength:     limit,
+		Charset:       "printable",
+		Allowed:       unicode.IsPrint,
+		ErrTooLong:    tooLong,
+		ErrCharacters: ErrValidationCharacters,
+	}
+}
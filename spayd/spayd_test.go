@@ -0,0 +1,213 @@
+package spayd_test
+
+import (
+	"bytes"
+	"fmt"
+	"hash/crc32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/jovandeginste/payme/qrcode"
+	"github.com/jovandeginste/payme/spayd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const exampleIBAN = "CZ2806000000000168540115"
+
+// examplePayment returns the example of the specification, with a due date and all symbols
+func examplePayment() *spayd.Payment {
+	s := spayd.New()
+	s.Payment.IBANBeneficiary = "CZ28 0600 0000 0001 6854 0115"
+	s.Payment.EuroAmount = 45000
+	s.Payment.Remittance = "PLATBA ZA ZBOZI"
+	s.VariableSymbol = "1234567890"
+	s.ConstantSymbol = "0308"
+	s.SpecificSymbol = "42"
+	s.DueDate = time.Date(2026, time.November, 30, 0, 0, 0, 0, time.UTC)
+
+	return s
+}
+
+func TestToString(t *testing.T) {
+	s, err := examplePayment().ToString()
+	require.NoError(t, err)
+	assert.Equal(t, "SPD*1.0*ACC:"+exampleIBAN+"*AM:450.00*CC:CZK*DT:20261130*MSG:PLATBA ZA ZBOZI*X-KS:0308*X-SS:42*X-VS:1234567890", s)
+}
+
+func TestToStringMinimal(t *testing.T) {
+	sp := spayd.New()
+	sp.Payment.IBANBeneficiary = exampleIBAN
+	sp.Payment.BICBeneficiary = "GIBACZPX"
+	sp.Payment.NameBeneficiary = "Jan Novák"
+	sp.Currency = "EUR"
+
+	s, err := sp.ToString()
+	require.NoError(t, err)
+	assert.Equal(t, "SPD*1.0*ACC:"+exampleIBAN+"+GIBACZPX*CC:EUR*RN:Jan Novák", s, "an open amount is left out")
+}
+
+func TestToStringEscape(t *testing.T) {
+	sp := examplePayment()
+	sp.Payment.Remittance = "5*100% done"
+
+	s, err := sp.ToString()
+	require.NoError(t, err)
+	assert.Contains(t, s, "*MSG:5%2A100%25 done*")
+
+	parsed, err := spayd.Parse(s)
+	require.NoError(t, err)
+	assert.Equal(t, "5*100% done", parsed.Payment.Remittance)
+}
+
+func TestToStringCRC32(t *testing.T) {
+	sp := examplePayment()
+	sp.CRC32 = true
+
+	s, err := sp.ToString()
+	require.NoError(t, err)
+
+	body, sum, ok := strings.Cut(s, "*CRC32:")
+	require.True(t, ok)
+	assert.Len(t, sum, 8)
+	assert.Equal(t, strings.ToUpper(sum), sum)
+
+	parsed, err := spayd.Parse(s)
+	require.NoError(t, err)
+	assert.True(t, parsed.CRC32)
+
+	// The checksum is over the attributes sorted by key, so the order in the descriptor does not matter
+	_, err = spayd.Parse("SPD*1.0*X-VS:1234567890*" + strings.TrimPrefix(strings.Replace(body, "*X-VS:1234567890", "", 1), "SPD*1.0*") + "*CRC32:" + sum)
+	require.NoError(t, err)
+
+	_, err = spayd.Parse(strings.Replace(s, "450.00", "460.00", 1))
+	require.ErrorIs(t, err, spayd.ErrParseCRC32)
+}
+
+// TestParseCRC32Escaped checks that the checksum is over the descriptor as written, whichever way its values
+// are escaped
+func TestParseCRC32Escaped(t *testing.T) {
+	body := "SPD*1.0*ACC:CZ5855000000001265098001*AM:450.00*MSG:5%2a100%25 %44one"
+	s := fmt.Sprintf("%s*CRC32:%08x", body, crc32.ChecksumIEEE([]byte(body)))
+
+	parsed, err := spayd.Parse(s)
+	require.NoError(t, err)
+	assert.True(t, parsed.CRC32)
+	assert.Equal(t, "5*100% Done", parsed.Payment.Remittance)
+
+	_, err = spayd.Parse(strings.Replace(s, "%44one", "Done", 1))
+	require.ErrorIs(t, err, spayd.ErrParseCRC32)
+}
+
+func TestIsValid(t *testing.T) {
+	require.NoError(t, examplePayment().IsValid())
+
+	for name, tc := range map[string]struct {
+		change   func(s *spayd.Payment)
+		field    string
+		expected error
+	}{
+		"IBAN":     {func(s *spayd.Payment) { s.Payment.IBANBeneficiary = "CZ2906000000000168540115" }, "IBANBeneficiary", spayd.ErrValidationAccount},
+		"BIC":      {func(s *spayd.Payment) { s.Payment.BICBeneficiary = "GIBA" }, "BICBeneficiary", spayd.ErrValidationBIC},
+		"amount":   {func(s *spayd.Payment) { s.Payment.EuroAmount = spayd.MaxAmount + 1 }, "EuroAmount", spayd.ErrValidationAmount},
+		"currency": {func(s *spayd.Payment) { s.Currency = "Kč" }, "Currency", spayd.ErrValidationCurrency},
+		"name":     {func(s *spayd.Payment) { s.Payment.NameBeneficiary = strings.Repeat("x", 36) }, "NameBeneficiary", spayd.ErrValidationNameTooLong},
+		"message":  {func(s *spayd.Payment) { s.Payment.Remittance = strings.Repeat("x", 61) }, "Remittance", spayd.ErrValidationMessageTooLong},
+		"control":  {func(s *spayd.Payment) { s.Payment.Remittance = "line\nbreak" }, "Remittance", spayd.ErrValidationCharacters},
+		"variable": {func(s *spayd.Payment) { s.VariableSymbol = "12345678901" }, "VariableSymbol", spayd.ErrValidationSymbol},
+		"constant": {func(s *spayd.Payment) { s.ConstantSymbol = "03A8" }, "ConstantSymbol", spayd.ErrValidationSymbol},
+	} {
+		s := examplePayment()
+		tc.change(s)
+
+		err := s.IsValid()
+		require.ErrorIs(t, err, tc.expected, name)
+
+		var errs payment.ValidationErrors
+		require.ErrorAs(t, err, &errs, name)
+		assert.Equal(t, tc.field, errs[0].Field, name)
+	}
+}
+
+func TestParse(t *testing.T) {
+	s, err := spayd.Parse("SPD*1.0*ACC:" + exampleIBAN + "+GIBACZPX*AM:1234.5*CC:CZK*RN:JAN NOVAK*DT:20261130*X-VS:42*X-URL:HTTPS://EXAMPLE.COM*")
+	require.NoError(t, err)
+
+	assert.Equal(t, exampleIBAN, s.Payment.IBANBeneficiary)
+	assert.Equal(t, "GIBACZPX", s.Payment.BICBeneficiary)
+	assert.Equal(t, payment.Amount(123450), s.Payment.EuroAmount)
+	assert.Equal(t, "CZK", s.Currency)
+	assert.Equal(t, "JAN NOVAK", s.Payment.NameBeneficiary)
+	assert.Equal(t, "2026-11-30", s.DueDate.Format(time.DateOnly))
+	assert.Equal(t, "42", s.VariableSymbol)
+	assert.False(t, s.CRC32)
+	require.NoError(t, s.IsValid())
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	s, err := examplePayment().ToString()
+	require.NoError(t, err)
+
+	parsed, err := spayd.Parse(s)
+	require.NoError(t, err)
+
+	again, err := parsed.ToString()
+	require.NoError(t, err)
+	assert.Equal(t, s, again)
+}
+
+func TestParseErrors(t *testing.T) {
+	for s, expected := range map[string]error{
+		"":                           spayd.ErrParseHeader,
+		"BCD\n002\n1\nSCT":           spayd.ErrParseHeader,
+		"SPD*2.0*ACC:" + exampleIBAN: spayd.ErrParseHeader,
+		"SPD*1.0*ACC" + exampleIBAN:  spayd.ErrParseAttribute,
+		"SPD*1.0*acc:" + exampleIBAN: spayd.ErrParseAttribute,
+		"SPD*1.0*AM:12*AM:13":        spayd.ErrParseDuplicate,
+		"SPD*1.0*ACC:" + exampleIBAN + "*MSG:100%":  spayd.ErrParseEscape,
+		"SPD*1.0*ACC:" + exampleIBAN + "*MSG:%ZZ":   spayd.ErrParseEscape,
+		"SPD*1.0*AM:12.00":                          spayd.ErrParseAccount,
+		"SPD*1.0*ACC:" + exampleIBAN + "*AM:12,00":  spayd.ErrParseAmount,
+		"SPD*1.0*ACC:" + exampleIBAN + "*DT:301126": spayd.ErrParseDueDate,
+	} {
+		_, err := spayd.Parse(s)
+		require.ErrorIs(t, err, expected, s)
+	}
+}
+
+func TestQRCodeRoundTrip(t *testing.T) {
+	s := examplePayment()
+
+	result, err := qrcode.ToPNG(s, qrcode.DefaultOptions(), 300)
+	require.NoError(t, err)
+
+	decoded, err := payment.DecodeQRImage(bytes.NewReader(result))
+	require.NoError(t, err)
+
+	parsed, err := spayd.Parse(string(decoded))
+	require.NoError(t, err)
+	assert.Equal(t, s.VariableSymbol, parsed.VariableSymbol)
+	assert.Equal(t, s.Payment.EuroAmount, parsed.Payment.EuroAmount)
+}
+
+func TestQRCode(t *testing.T) {
+	s := examplePayment()
+
+	opts := qrcode.DefaultOptions()
+
+	code, err := s.QRCode(opts)
+	require.NoError(t, err)
+
+	opts.Mode = qrcode.ModeByte
+
+	byteCode, err := s.QRCode(opts)
+	require.NoError(t, err)
+	assert.Less(t, code.Version, byteCode.Version, "an upper case descriptor fits in alphanumeric mode")
+
+	_, err = spayd.New().QRCode(qrcode.DefaultOptions())
+
+	var errs payment.ValidationErrors
+	require.ErrorAs(t, err, &errs)
+}
@@ -0,0 +1,98 @@
+package upn
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jovandeginste/payme/payment"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Parse reads a payload into a Payment. The payload is text, as readers return it after decoding the ISO 8859-2
+// of the code (see ParseBytes for the raw content); the padding after the checksum is ignored. A reference with
+// model SI99 makes the purpose the unstructured remittance; any other reference is a structured remittance, next
+// to the purpose as the description. Parse only checks the syntax of the payload; use IsValid to check the content.
+func Parse(s string) (*Payment, error) {
+	fields := strings.Split(s, "\n")
+	if fields[0] != header {
+		return nil, ErrParseHeader
+	}
+
+	// The fields, the checksum, and the padding after the last line feed
+	if len(fields) != fieldCount+2 || strings.TrimSpace(fields[fieldCount+1]) != "" {
+		return nil, ErrParseFields
+	}
+
+	if c := checksum(fields[:fieldCount]); fields[fieldCount] != c {
+		return nil, fmt.Errorf("%w: %q, expected %s", ErrParseChecksum, fields[fieldCount], c)
+	}
+
+	u := New()
+	if err := u.set(fields[:fieldCount]); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// ParseBytes reads the raw content of a code (as returned by ToBytes) into a Payment, decoding it from ISO 8859-2
+func ParseBytes(b []byte) (*Payment, error) {
+	s, err := charmap.ISO8859_2.NewDecoder().Bytes(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(string(s))
+}
+
+// set sets the fields of the payment from the 19 fields of a payload
+func (u *Payment) set(fields []string) error {
+	p := u.Payment
+
+	amount, err := strconv.ParseInt(fields[8], 10, 64)
+	if err != nil || len(fields[8]) != amountDigits || amount < 0 {
+		return fmt.Errorf("%w: %q", ErrParseAmount, fields[8])
+	}
+
+	if u.PaymentDate, err = parseDate(fields[9]); err != nil {
+		return err
+	}
+
+	if u.DueDate, err = parseDate(fields[13]); err != nil {
+		return err
+	}
+
+	u.Payer = Payer{IBAN: fields[1], Reference: fields[4], Name: fields[5], Street: fields[6], Place: fields[7]}
+	u.Urgent = fields[10] == marker
+	u.PayeeStreet = fields[17]
+	u.PayeePlace = fields[18]
+
+	p.EuroAmount = payment.Amount(amount)
+	p.Purpose = fields[11]
+	p.IBANBeneficiary = fields[14]
+	p.NameBeneficiary = fields[16]
+	p.Remittance = fields[12]
+
+	if ref := fields[15]; ref != ModelNone {
+		p.Remittance, p.RemittanceIsStructured = ref, true
+		u.Description = fields[12]
+	}
+
+	return nil
+}
+
+// parseDate returns the date of a field, or the zero date when it is empty
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %q", ErrParseDate, s)
+	}
+
+	return t, nil
+}
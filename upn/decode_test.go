@@ -0,0 +1,95 @@
+package upn_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jovandeginste/payme/upn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRoundTrip(t *testing.T) {
+	u := examplePayment()
+	u.Payer.IBAN = "SI56191000000123438"
+	u.PaymentDate = time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC)
+	u.Urgent = true
+
+	s, err := u.ToString()
+	require.NoError(t, err)
+
+	parsed, err := upn.Parse(s)
+	require.NoError(t, err)
+	require.NoError(t, parsed.IsValid())
+
+	assert.Equal(t, u.Payer, parsed.Payer)
+	assert.Equal(t, u.PayeeStreet, parsed.PayeeStreet)
+	assert.Equal(t, u.PayeePlace, parsed.PayeePlace)
+	assert.Equal(t, u.Description, parsed.Description)
+	assert.Equal(t, u.PaymentDate, parsed.PaymentDate)
+	assert.Equal(t, u.DueDate, parsed.DueDate)
+	assert.True(t, parsed.Urgent)
+
+	p := parsed.Payment
+	assert.Equal(t, "SI56020170014356205", p.IBANBeneficiary)
+	assert.Equal(t, u.Payment.NameBeneficiary, p.NameBeneficiary)
+	assert.Equal(t, u.Payment.EuroAmount, p.EuroAmount)
+	assert.Equal(t, "ELEC", p.Purpose)
+	assert.Equal(t, "SI121234560", p.Remittance)
+	assert.True(t, p.RemittanceIsStructured)
+
+	again, err := parsed.ToString()
+	require.NoError(t, err)
+	assert.Equal(t, s, again)
+}
+
+func TestParseBytes(t *testing.T) {
+	u := examplePayment()
+
+	b, err := u.ToBytes()
+	require.NoError(t, err)
+
+	parsed, err := upn.ParseBytes(b)
+	require.NoError(t, err)
+	assert.Equal(t, u.Description, parsed.Description, "the payload is decoded from ISO 8859-2")
+}
+
+func TestParseUnstructured(t *testing.T) {
+	parsed, err := upn.Parse("UPNQR\n\n\n\n\n\n\n\n00000000000\n\n\nOTHR\nDonacija\n\nSI56191000000123438\nSI99\nDruštvo\nTrg 1\n4000 Kranj\n092\n")
+	require.NoError(t, err, "readers may trim the padding")
+
+	assert.Equal(t, "Donacija", parsed.Payment.Remittance)
+	assert.False(t, parsed.Payment.RemittanceIsStructured)
+	assert.Empty(t, parsed.Description)
+	assert.True(t, parsed.Payment.IsOpenAmount())
+	assert.True(t, parsed.PaymentDate.IsZero())
+}
+
+func TestParseErrors(t *testing.T) {
+	s, err := examplePayment().ToString()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		payload string
+		err     error
+	}{
+		{"empty", "", upn.ErrParseHeader},
+		{"header", strings.Replace(s, "UPNQR", "UPNQX", 1), upn.ErrParseHeader},
+		{"missing field", strings.Replace(s, "\nELEC\n", "\n", 1), upn.ErrParseFields},
+		{"extra field", strings.Replace(s, "190\n", "190\n\n", 1), upn.ErrParseFields},
+		{"padding", s + "x", upn.ErrParseFields},
+		{"checksum", strings.Replace(s, "Janez", "Jan", 1), upn.ErrParseChecksum},
+		{"amount", strings.Replace(s, "00000012355", "0000001235x", 1), upn.ErrParseAmount},
+		{"amount digits", strings.Replace(strings.Replace(s, "00000012355", "0000012355", 1), "190", "189", 1), upn.ErrParseAmount},
+		{"date", strings.Replace(s, "15.02.2026", "2026-02-15", 1), upn.ErrParseDate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := upn.Parse(tt.payload)
+			require.ErrorIs(t, err, tt.err)
+		})
+	}
+}
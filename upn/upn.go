@@ -0,0 +1,433 @@
+// Package upn encodes and decodes UPN QR, the QR code of the Slovenian universal payment order (by the Bank
+// Association of Slovenia): 19 fields of the payment on lines of their own and a checksum, in ISO 8859-2, padded
+// to a fixed length and rendered as a QR code of version 15.
+//
+// See: https://www.upn-qr.si
+package upn
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/almerlucke/go-iban/iban"
+	"github.com/jovandeginste/payme/payment"
+	"github.com/jovandeginste/payme/qrcode"
+	"golang.org/x/text/encoding/charmap"
+)
+
+const (
+	// header is the first field of every payload
+	header = "UPNQR"
+	// dateLayout is the format of the dates: DD.MM.YYYY
+	dateLayout = "02.01.2006"
+	// marker is the value of a check box that is ticked, eg. an urgent payment
+	marker = "X"
+	// version is the symbol version of every UPN QR code, which holds exactly the 411 bytes of a payload
+	version = 15
+
+	// ModelNone is the model of a payment without a reference number
+	ModelNone = "SI99"
+	// ModelCheckDigit is the model of a reference number that ends with a check digit (modulo 11)
+	ModelCheckDigit = "SI12"
+	// PurposeOther is the purpose code of a payment without one: other
+	PurposeOther = "OTHR"
+)
+
+// Sizes of the payload and its fields, in characters
+const (
+	// payloadLength is the length of every payload: the fields are padded with spaces
+	payloadLength  = 411
+	fieldCount     = 19
+	checksumDigits = 3
+	amountDigits   = 11
+
+	maxName         = 33
+	maxStreet       = 33
+	maxPlace        = 33
+	maxReference    = 26
+	maxDescription  = 42
+	referenceGroups = 3
+)
+
+var (
+	purposeValidator   = regexp.MustCompile(`^[A-Z]{4}$`)
+	modelValidator     = regexp.MustCompile(`^(SI\d{2}|RF\d{2})$`)
+	referenceValidator = regexp.MustCompile(`^\d+(-\d+)*$`)
+	digitsValidator    = regexp.MustCompile(`^\d+$`)
+
+	// ErrValidationAccount is returned when an account is not a valid IBAN
+	ErrValidationAccount = errors.New("field should be a valid IBAN, eg. SI56 1910 0000 0123 438")
+	// ErrValidationAmount is returned when the amount is not 0 (open amount), or 0.01 or more and 999999999.99 or less
+	ErrValidationAmount = errors.New("field 'EuroAmount' must be 0 (open amount), or 0.01 or more and 999999999.99 or less")
+	// ErrValidationRequired is returned when a required field is empty
+	ErrValidationRequired = errors.New("field is required")
+	// ErrValidationTooLong is returned when a field exceeds its number of characters
+	ErrValidationTooLong = errors.New("field is too long")
+	// ErrValidationCharacters is returned when a field has characters outside ISO 8859-2, or control characters
+	ErrValidationCharacters = errors.New("field should only contain printable characters of ISO 8859-2")
+	// ErrValidationPurpose is returned when the purpose code is not 4 upper case letters
+	ErrValidationPurpose = errors.New("field 'Purpose' should be a purpose code of 4 upper case letters (ISO 20022), eg. OTHR")
+	// ErrValidationModel is returned when the model of the reference is not SI00..SI99 or RF followed by its
+	// check digits
+	ErrValidationModel = errors.New("model of the reference should be SI00..SI99, or RF and the check digits of an RF creditor reference")
+	// ErrValidationReference is returned when the reference number does not fit its model
+	ErrValidationReference = errors.New("reference number should be up to 22 digits, in at most 3 groups separated by -, and empty for model SI99")
+	// ErrValidationCheckDigit is returned when a reference with model SI12 does not end with its check digit
+	ErrValidationCheckDigit = errors.New("reference with model SI12 should be digits that end with their check digit (modulo 11)")
+	// ErrValidationRFReference is returned when a reference with model RF is not a valid ISO 11649 reference
+	ErrValidationRFReference = errors.New("reference with model RF is not a valid RF creditor reference")
+	// ErrValidationPayloadTooLong is returned when the fields do not fit in the payload
+	ErrValidationPayloadTooLong = fmt.Errorf("fields should fit in a payload of %d characters", payloadLength)
+
+	// ErrParseHeader is returned when the payload does not start with the UPNQR header
+	ErrParseHeader = errors.New("payload should start with " + header)
+	// ErrParseFields is returned when the payload does not have 19 fields and a checksum, each on a line of its own
+	ErrParseFields = errors.New("payload should have 19 fields and a checksum, each followed by a line feed")
+	// ErrParseChecksum is returned when the checksum does not match the length of the fields
+	ErrParseChecksum = errors.New("checksum does not match the length of the fields")
+	// ErrParseAmount is returned when the amount is not 11 digits
+	ErrParseAmount = errors.New("amount should be 11 digits, in cent")
+	// ErrParseDate is returned when a date is not of the form DD.MM.YYYY
+	ErrParseDate = errors.New("date should be of the form DD.MM.YYYY")
+)
+
+// Payer is the account, reference and address of the payer, as printed on the order
+type Payer struct {
+	// IBAN is the account of the payer
+	IBAN string
+	// Reference is the reference of the payer, eg. SI00 1234 (up to 26 characters)
+	Reference string
+	// Name is the name of the payer (up to 33 characters)
+	Name string
+	// Street is the street and building number (up to 33 characters)
+	Street string
+	// Place is the postal code and town (up to 33 characters)
+	Place string
+}
+
+// Payment is a payment in the UPN QR format: the account, name, amount, purpose and remittance of a
+// payment.Payment, with the address of the payee, the payer and the dates of the order. UPN payments are in euro.
+type Payment struct {
+	// Payment holds the IBAN and name of the payee, the amount and the purpose code, and the remittance: the
+	// model and reference number when it is structured (eg. SI12 1234560), and the description otherwise; its
+	// other fields are not part of the payload
+	Payment *payment.Payment
+	// Payer is the account and address of the payer; leave it empty for the payer to fill in
+	Payer Payer
+	// PayeeStreet is the street and building number of the payee (up to 33 characters)
+	PayeeStreet string
+	// PayeePlace is the postal code and town of the payee (up to 33 characters)
+	PayeePlace string
+	// Description is the purpose of a payment with a structured remittance (up to 42 characters)
+	Description string
+	// PaymentDate is the date of the payment; leave it zero for the payer to choose
+	PaymentDate time.Time
+	// DueDate is the date the payment is due; leave it zero when there is none
+	DueDate time.Time
+	// Urgent asks the bank of the payer to execute the payment urgently
+	Urgent bool
+}
+
+// New returns a new Payment
+func New() *Payment {
+	return &Payment{Payment: payment.New()}
+}
+
+// Reference returns the model and the reference number: the structured remittance split after its model, or
+// SI99 without a reference number when the remittance is unstructured
+func (u *Payment) Reference() (string, string) {
+	p := u.Payment
+	if !p.RemittanceIsStructured {
+		return ModelNone, ""
+	}
+
+	ref := strings.ToUpper(strings.TrimSpace(p.Remittance))
+	if strings.HasPrefix(ref, "RF") {
+		ref = p.RemittanceStructured()
+	}
+
+	if len(ref) < 4 {
+		return ref, ""
+	}
+
+	return ref[:4], strings.ReplaceAll(ref[4:], " ", "")
+}
+
+// IsModelReference returns whether the remittance starts with a Slovenian model, SI00..SI99, so it is a
+// structured remittance of a UPN payment
+func IsModelReference(remittance string) bool {
+	ref := strings.ToUpper(strings.TrimSpace(remittance))
+
+	return len(ref) >= 4 && modelValidator.MatchString(ref[:4]) && strings.HasPrefix(ref, "SI")
+}
+
+// CheckDigit returns the check digit of a reference number with model SI12: 11 minus the remainder of the sum of
+// the digits, weighted 2, 3, 4, ... from the right, modulo 11, where 10 and 11 become 0
+func CheckDigit(digits string) int {
+	sum := 0
+	for i := range len(digits) {
+		sum += int(digits[len(digits)-1-i]-'0') * (i + 2)
+	}
+
+	if d := 11 - sum%11; d < 10 {
+		return d
+	}
+
+	return 0
+}
+
+// hasCheckDigit returns whether the reference number is digits that end with their check digit
+func hasCheckDigit(ref string) bool {
+	return len(ref) >= 2 && digitsValidator.MatchString(ref) && CheckDigit(ref[:len(ref)-1]) == int(ref[len(ref)-1]-'0')
+}
+
+// DescriptionString returns the purpose of the payment: the unstructured remittance, or the description next to
+// a structured one
+func (u *Payment) DescriptionString() string {
+	if u.Payment.RemittanceIsStructured {
+		return u.Description
+	}
+
+	return u.Payment.Remittance
+}
+
+// PurposeString returns the purpose code, or OTHR when it is empty
+func (u *Payment) PurposeString() string {
+	if purpose := u.Payment.PurposeString(); purpose != "" {
+		return purpose
+	}
+
+	return PurposeOther
+}
+
+// fields returns the 19 fields of the payload, in order
+func (u *Payment) fields() []string {
+	p := u.Payment
+
+	model, ref := u.Reference()
+
+	return []string{
+		header,
+		compactIBAN(u.Payer.IBAN),
+		"", // deposit
+		"", // withdrawal
+		strings.ToUpper(strings.ReplaceAll(u.Payer.Reference, " ", "")),
+		u.Payer.Name,
+		u.Payer.Street,
+		u.Payer.Place,
+		fmt.Sprintf("%0*d", amountDigits, int64(p.EuroAmount)),
+		formatDate(u.PaymentDate),
+		check(u.Urgent),
+		u.PurposeString(),
+		u.DescriptionString(),
+		formatDate(u.DueDate),
+		compactIBAN(p.IBANBeneficiary),
+		model + ref,
+		p.NameBeneficiary,
+		u.PayeeStreet,
+		u.PayeePlace,
+	}
+}
+
+// payload returns the fields and the checksum, each followed by a line feed, without the padding
+func (u *Payment) payload() string {
+	fields := u.fields()
+
+	return strings.Join(fields, "\n") + "\n" + checksum(fields) + "\n"
+}
+
+// checksum returns the checksum of the fields: the sum of their lengths and the line feeds after them, in 3 digits
+func checksum(fields []string) string {
+	n := len(fields)
+	for _, f := range fields {
+		n += utf8.RuneCountInString(f)
+	}
+
+	return fmt.Sprintf("%0*d", checksumDigits, n)
+}
+
+// ToString returns the payload, padded with spaces to 411 characters, after validating the payment
+func (u *Payment) ToString() (string, error) {
+	if err := u.IsValid(); err != nil {
+		return "", err
+	}
+
+	s := u.payload()
+
+	return s + strings.Repeat(" ", payloadLength-utf8.RuneCountInString(s)), nil
+}
+
+// ToBytes returns the payload in ISO 8859-2, as the QR code holds it
+func (u *Payment) ToBytes() ([]byte, error) {
+	s, err := u.ToString()
+	if err != nil {
+		return nil, err
+	}
+
+	return charmap.ISO8859_2.NewEncoder().Bytes([]byte(s))
+}
+
+// QRCode returns the QR code of the payload: version 15 with error correction level M, in byte mode behind the
+// ECI of ISO 8859-2, as the specification requires. Only the mask and quiet zone of the options are used.
+func (u *Payment) QRCode(opts qrcode.Options) (*qrcode.Code, error) {
+	b, err := u.ToBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	opts.Level = qrcode.LevelM
+	opts.MinVersion = version
+	opts.Mode = qrcode.ModeByte
+	opts.ECI = qrcode.ECIISO88592
+
+	return qrcode.Encode(b, opts)
+}
+
+// IsValid checks the payment against the UPN QR specification.
+// It returns nil if all is well, or payment.ValidationErrors with every problem it finds.
+func (u *Payment) IsValid() error {
+	p := u.Payment
+
+	var errs payment.ValidationErrors
+
+	if _, err := p.IBAN(); err != nil {
+		errs = append(errs, payment.NewValidationError("IBANBeneficiary", "", payment.RuleFormat, "IBAN",
+			fmt.Errorf("%w: %w", ErrValidationAccount, err)))
+	}
+
+	if u.Payer.IBAN != "" {
+		if _, err := iban.NewIBAN(compactIBAN(u.Payer.IBAN)); err != nil {
+			errs = append(errs, payment.NewValidationError("Payer.IBAN", "", payment.RuleFormat, "IBAN",
+				fmt.Errorf("%w: %w", ErrValidationAccount, err)))
+		}
+	}
+
+	if p.EuroAmount < 0 || p.EuroAmount > payment.MaxAmount {
+		errs = append(errs, payment.NewValidationError("EuroAmount", "", payment.RuleRange, "0.01..999999999.99", ErrValidationAmount))
+	}
+
+	if purpose := u.PurposeString(); !purposeValidator.MatchString(purpose) {
+		errs = append(errs, payment.NewValidationError("Purpose", "", payment.RuleFormat, "ISO 20022",
+			fmt.Errorf("%w: %q", ErrValidationPurpose, purpose)))
+	}
+
+	for _, f := range []struct {
+		field    string
+		value    string
+		limit    int
+		required bool
+	}{
+		{"Payer.Reference", u.Payer.Reference, maxReference, false},
+		{"Payer.Name", u.Payer.Name, maxName, false},
+		{"Payer.Street", u.Payer.Street, maxStreet, false},
+		{"Payer.Place", u.Payer.Place, maxPlace, false},
+		{"NameBeneficiary", p.NameBeneficiary, maxName, true},
+		{"PayeeStreet", u.PayeeStreet, maxStreet, true},
+		{"PayeePlace", u.PayeePlace, maxPlace, true},
+		{u.descriptionField(), u.DescriptionString(), maxDescription, true},
+	} {
+		errs = append(errs, textRule(f.limit, f.required).Validate(f.field, f.value)...)
+	}
+
+	errs = append(errs, u.validateReference()...)
+
+	if n := utf8.RuneCountInString(u.payload()); len(errs) == 0 && n > payloadLength {
+		errs = append(errs, payment.NewValidationError("Payload", "", payment.RuleMaxLength, strconv.Itoa(payloadLength),
+			fmt.Errorf("%w: %d characters", ErrValidationPayloadTooLong, n)))
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+// descriptionField returns the name of the field that holds the purpose of the payment
+func (u *Payment) descriptionField() string {
+	if u.Payment.RemittanceIsStructured {
+		return "Description"
+	}
+
+	return "Remittance"
+}
+
+// validateReference checks the model and the reference number of a structured remittance
+func (u *Payment) validateReference() payment.ValidationErrors {
+	model, ref := u.Reference()
+
+	switch {
+	case !modelValidator.MatchString(model):
+		return payment.ValidationErrors{payment.NewValidationError("Remittance", "", payment.RuleFormat, "SI00..SI99, RF",
+			fmt.Errorf("%w: %q", ErrValidationModel, model))}
+	case strings.HasPrefix(model, "RF"):
+		if err := payment.ValidateRFReference(model + ref); err != nil {
+			return payment.ValidationErrors{payment.NewValidationError("Remittance", "", payment.RuleFormat, "ISO 11649",
+				fmt.Errorf("%w: %w", ErrValidationRFReference, err))}
+		}
+
+		return nil
+	case model == ModelNone:
+		if ref == "" {
+			return nil
+		}
+	case len(model+ref) <= maxReference && referenceValidator.MatchString(ref) && strings.Count(ref, "-") < referenceGroups:
+		if model != ModelCheckDigit || hasCheckDigit(ref) {
+			return nil
+		}
+
+		return payment.ValidationErrors{payment.NewValidationError("Remittance", "", payment.RuleFormat, "modulo 11",
+			fmt.Errorf("%w: %q", ErrValidationCheckDigit, ref))}
+	}
+
+	return payment.ValidationErrors{payment.NewValidationError("Remittance", "", payment.RuleFormat, strconv.Itoa(maxReference-len(model)),
+		fmt.Errorf("%w: %s %q", ErrValidationReference, model, ref))}
+}
+
+// textRule returns the rule of a text field: at most limit printable characters of ISO 8859-2
+func textRule(limit int, required bool) payment.TextRule {
+	return payment.TextRule{
+		Required:      required,
+		MaxLength:     limit,
+		Charset:       "ISO 8859-2",
+		Allowed:       func(r rune) bool { return unicode.IsPrint(r) && fitsISO88592(r) },
+		ErrRequired:   ErrValidationRequired,
+		ErrTooLong:    ErrValidationTooLong,
+		ErrCharacters: ErrValidationCharacters,
+	}
+}
+
+// fitsISO88592 returns whether the character is part of ISO 8859-2
+func fitsISO88592(r rune) bool {
+	_, ok := charmap.ISO8859_2.EncodeRune(r)
+	return ok
+}
+
+// compactIBAN returns the IBAN in upper case without spaces, as the payload holds it
+func compactIBAN(s string) string {
+	return strings.ToUpper(strings.ReplaceAll(s, " ", ""))
+}
+
+// formatDate returns the date as DD.MM.YYYY, or an empty string for the zero date
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(dateLayout)
+}
+
+// check returns the value of a check box
+func check(b bool) string {
+	if b {
+		return marker
+	}
+
+	return ""
+}
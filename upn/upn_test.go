@@ -0,0 +1,223 @@
+package upn_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/jovandeginste/payme/qrcode"
+	"github.com/jovandeginste/payme/upn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// examplePayment returns a payment with a reference with model SI12, a purpose code, the payer and a due date
+func examplePayment() *upn.Payment {
+	u := upn.New()
+	u.Payment.IBANBeneficiary = "SI56 0201 7001 4356 205"
+	u.Payment.NameBeneficiary = "Elektro d.d."
+	u.Payment.EuroAmount = 12355
+	u.Payment.Purpose = "ELEC"
+	u.Payment.Remittance = "SI12 1234560"
+	u.Payment.RemittanceIsStructured = true
+	u.PayeeStreet = "Slovenska cesta 58"
+	u.PayeePlace = "1000 Ljubljana"
+	u.Payer = upn.Payer{Name: "Janez Novak", Street: "Dunajska cesta 1", Place: "1000 Ljubljana"}
+	u.Description = "Račun za elektriko 01/2026"
+	u.DueDate = time.Date(2026, time.February, 15, 0, 0, 0, 0, time.UTC)
+
+	return u
+}
+
+func TestToString(t *testing.T) {
+	s, err := examplePayment().ToString()
+	require.NoError(t, err)
+	assert.Equal(t, 411, utf8.RuneCountInString(s), "the payload is padded to a fixed length")
+	assert.Equal(t, strings.Join([]string{
+		"UPNQR",
+		"",
+		"",
+		"",
+		"",
+		"Janez Novak",
+		"Dunajska cesta 1",
+		"1000 Ljubljana",
+		"00000012355",
+		"",
+		"",
+		"ELEC",
+		"Račun za elektriko 01/2026",
+		"15.02.2026",
+		"SI56020170014356205",
+		"SI121234560",
+		"Elektro d.d.",
+		"Slovenska cesta 58",
+		"1000 Ljubljana",
+		"190",
+	}, "\n")+"\n", strings.TrimRight(s, " "))
+}
+
+func TestToStringUnstructured(t *testing.T) {
+	u := upn.New()
+	u.Payment.IBANBeneficiary = "SI56191000000123438"
+	u.Payment.NameBeneficiary = "Društvo"
+	u.Payment.Remittance = "Donacija"
+	u.PayeeStreet = "Trg 1"
+	u.PayeePlace = "4000 Kranj"
+
+	s, err := u.ToString()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(s, "UPNQR\n\n\n\n\n\n\n\n00000000000\n\n\nOTHR\nDonacija\n\nSI56191000000123438\nSI99\nDruštvo\nTrg 1\n4000 Kranj\n092\n  "),
+		"an open amount is zero, the purpose code defaults to OTHR, and an unstructured remittance is the purpose without a reference")
+}
+
+func TestToStringOptionalFields(t *testing.T) {
+	u := examplePayment()
+	u.Payer.IBAN = "si56 1910 0000 0123 438"
+	u.Payer.Reference = "SI00 1234"
+	u.PaymentDate = time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC)
+	u.Urgent = true
+
+	s, err := u.ToString()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(s, "UPNQR\nSI56191000000123438\n\n\nSI001234\nJanez Novak\n"))
+	assert.Contains(t, s, "\n00000012355\n01.02.2026\nX\nELEC\n")
+}
+
+func TestToBytes(t *testing.T) {
+	b, err := examplePayment().ToBytes()
+	require.NoError(t, err)
+	assert.Len(t, b, 411)
+	assert.Contains(t, string(b), "Ra\xE8un za elektriko", "the payload is ISO 8859-2")
+}
+
+func TestReference(t *testing.T) {
+	u := examplePayment()
+
+	model, ref := u.Reference()
+	assert.Equal(t, "SI12", model)
+	assert.Equal(t, "1234560", ref)
+
+	u.Payment.Remittance = "RF18 5390 0754 7034"
+	model, ref = u.Reference()
+	assert.Equal(t, "RF18", model)
+	assert.Equal(t, "539007547034", ref)
+	require.NoError(t, u.IsValid())
+
+	u.Payment.RemittanceIsStructured = false
+	model, ref = u.Reference()
+	assert.Equal(t, upn.ModelNone, model)
+	assert.Empty(t, ref)
+}
+
+func TestIsModelReference(t *testing.T) {
+	assert.True(t, upn.IsModelReference("SI12 1234560"))
+	assert.True(t, upn.IsModelReference("si00 11-22"))
+	assert.False(t, upn.IsModelReference("RF18 5390 0754 7034"))
+	assert.False(t, upn.IsModelReference("HR01 1234"))
+	assert.False(t, upn.IsModelReference("SI"))
+}
+
+func TestCheckDigit(t *testing.T) {
+	assert.Equal(t, 0, upn.CheckDigit("123456"), "11 becomes 0")
+	assert.Equal(t, 6, upn.CheckDigit("1000"))
+	assert.Equal(t, 0, upn.CheckDigit("6"), "10 becomes 0")
+	assert.Equal(t, 9, upn.CheckDigit("1"))
+}
+
+func TestIsValid(t *testing.T) {
+	require.NoError(t, examplePayment().IsValid())
+
+	tests := []struct {
+		name   string
+		modify func(u *upn.Payment)
+		field  string
+		rule   payment.Rule
+		err    error
+	}{
+		{"iban", func(u *upn.Payment) { u.Payment.IBANBeneficiary = "SI56 0000" }, "IBANBeneficiary", payment.RuleFormat, upn.ErrValidationAccount},
+		{"payer iban", func(u *upn.Payment) { u.Payer.IBAN = "SI00 1910" }, "Payer.IBAN", payment.RuleFormat, upn.ErrValidationAccount},
+		{"amount", func(u *upn.Payment) { u.Payment.EuroAmount = payment.MaxAmount + 1 }, "EuroAmount", payment.RuleRange, upn.ErrValidationAmount},
+		{"purpose", func(u *upn.Payment) { u.Payment.Purpose = "elec" }, "Purpose", payment.RuleFormat, upn.ErrValidationPurpose},
+		{"name required", func(u *upn.Payment) { u.Payment.NameBeneficiary = "" }, "NameBeneficiary", payment.RuleRequired, upn.ErrValidationRequired},
+		{"street required", func(u *upn.Payment) { u.PayeeStreet = "" }, "PayeeStreet", payment.RuleRequired, upn.ErrValidationRequired},
+		{"place required", func(u *upn.Payment) { u.PayeePlace = "" }, "PayeePlace", payment.RuleRequired, upn.ErrValidationRequired},
+		{"description required", func(u *upn.Payment) { u.Description = "" }, "Description", payment.RuleRequired, upn.ErrValidationRequired},
+		{"purpose required", func(u *upn.Payment) {
+			u.Payment.Remittance, u.Payment.RemittanceIsStructured = "", false
+		}, "Remittance", payment.RuleRequired, upn.ErrValidationRequired},
+		{"payer name", func(u *upn.Payment) { u.Payer.Name = strings.Repeat("a", 34) }, "Payer.Name", payment.RuleMaxLength, upn.ErrValidationTooLong},
+		{"description", func(u *upn.Payment) { u.Description = strings.Repeat("a", 43) }, "Description", payment.RuleMaxLength, upn.ErrValidationTooLong},
+		{"characters", func(u *upn.Payment) { u.PayeePlace = "1000 Ljubljana €" }, "PayeePlace", payment.RuleCharacters, upn.ErrValidationCharacters},
+		{"control characters", func(u *upn.Payment) { u.Payer.Street = "Dunajska\ncesta" }, "Payer.Street", payment.RuleCharacters, upn.ErrValidationCharacters},
+		{"model", func(u *upn.Payment) { u.Payment.Remittance = "HR01 1234" }, "Remittance", payment.RuleFormat, upn.ErrValidationModel},
+		{"check digit", func(u *upn.Payment) { u.Payment.Remittance = "SI12 1234561" }, "Remittance", payment.RuleFormat, upn.ErrValidationCheckDigit},
+		{"check digit groups", func(u *upn.Payment) { u.Payment.Remittance = "SI12 123-4560" }, "Remittance", payment.RuleFormat, upn.ErrValidationCheckDigit},
+		{"reference letters", func(u *upn.Payment) { u.Payment.Remittance = "SI00 12A" }, "Remittance", payment.RuleFormat, upn.ErrValidationReference},
+		{"reference groups", func(u *upn.Payment) { u.Payment.Remittance = "SI00 1-2-3-4" }, "Remittance", payment.RuleFormat, upn.ErrValidationReference},
+		{"reference length", func(u *upn.Payment) { u.Payment.Remittance = "SI00 " + strings.Repeat("1", 23) }, "Remittance", payment.RuleFormat, upn.ErrValidationReference},
+		{"SI99 with number", func(u *upn.Payment) { u.Payment.Remittance = "SI99 1234" }, "Remittance", payment.RuleFormat, upn.ErrValidationReference},
+		{"rf", func(u *upn.Payment) { u.Payment.Remittance = "RF00 5390 0754 7034" }, "Remittance", payment.RuleFormat, upn.ErrValidationRFReference},
+		{"payload", func(u *upn.Payment) {
+			u.Payer.IBAN = "MT84 MALT 0110 0001 2345 MTLC AST0 01S"
+			u.Payment.IBANBeneficiary = u.Payer.IBAN
+			u.Payer.Reference = "SI00" + strings.Repeat("1", 22)
+			u.Payment.Remittance = u.Payer.Reference
+			u.Payer.Name, u.Payer.Street, u.Payer.Place = strings.Repeat("a", 33), strings.Repeat("b", 33), strings.Repeat("c", 33)
+			u.Payment.NameBeneficiary, u.PayeeStreet, u.PayeePlace = strings.Repeat("d", 33), strings.Repeat("e", 33), strings.Repeat("f", 33)
+			u.Description = strings.Repeat("g", 42)
+			u.PaymentDate, u.Urgent = u.DueDate, true
+		}, "Payload", payment.RuleMaxLength, upn.ErrValidationPayloadTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := examplePayment()
+			tt.modify(u)
+
+			err := u.IsValid()
+			require.ErrorIs(t, err, tt.err)
+
+			var errs payment.ValidationErrors
+			require.ErrorAs(t, err, &errs)
+			require.Len(t, errs, 1)
+			assert.Equal(t, tt.field, errs[0].Field)
+			assert.Equal(t, tt.rule, errs[0].Rule)
+
+			_, err = u.ToString()
+			require.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestQRCodeRoundTrip(t *testing.T) {
+	u := examplePayment()
+
+	result, err := qrcode.ToPNG(u, qrcode.DefaultOptions(), 600)
+	require.NoError(t, err)
+
+	decoded, err := payment.DecodeQRImage(bytes.NewReader(result))
+	require.NoError(t, err)
+
+	parsed, err := upn.ParseBytes(decoded)
+	require.NoError(t, err)
+	assert.Equal(t, u.Description, parsed.Description)
+	assert.Equal(t, u.Payment.EuroAmount, parsed.Payment.EuroAmount)
+}
+
+func TestQRCode(t *testing.T) {
+	u := examplePayment()
+
+	code, err := u.QRCode(qrcode.Options{Level: qrcode.LevelL, Mode: qrcode.ModeAlphanumeric})
+	require.NoError(t, err)
+	assert.Equal(t, 15, code.Version)
+	assert.Equal(t, qrcode.LevelM, code.Level, "the version and level are fixed, whatever the options")
+
+	_, err = upn.New().QRCode(qrcode.DefaultOptions())
+
+	var errs payment.ValidationErrors
+	require.ErrorAs(t, err, &errs)
+}
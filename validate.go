@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/jovandeginste/payme/payment"
+	"github.com/spf13/cobra"
+)
+
+// validateParams are the settings of the validate command
+type validateParams struct {
+	Payload string
+	JSON    bool
+}
+
+// validateReport is the result of the validate command, as JSON
+type validateReport struct {
+	Valid  bool                     `json:"valid"`
+	Error  string                   `json:"error,omitempty"`
+	Errors payment.ValidationErrors `json:"errors,omitempty"`
+}
+
+func validateCmd(q *qrParams) *cobra.Command {
+	v := validateParams{}
+
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check whether a payment is valid, and print every problem",
+		Long: `Check whether a payment is valid, and print every problem.
+
+The payment is given by the flags and --from, as for generating a code, or as the raw EPC payload (the content of
+the QR code) with --payload.
+
+` + exitCodesHelp,
+		Example: `  payme validate --name "Franz Mustermänn" --iban DE71110220330123456789 --amount 12.3 --remittance "Invoice 1234"
+  payme validate --from payment.yaml --json
+  payme validate --payload payload.txt`,
+		Args: cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, _ []string) error {
+			return q.applyConfig(cmd)
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cmd.SilenceUsage = true
+			return v.run(q, cmd.InOrStdin(), cmd.OutOrStdout())
+		},
+	}
+
+	cmd.Flags().StringVar(&v.Payload, "payload", "", "File with the raw EPC payload to check instead of the flags, or - for stdin")
+	cmd.Flags().BoolVar(&v.JSON, "json", false, "Print the result as JSON")
+
+	q.addPaymentFlags(cmd.Flags())
+
+	return cmd
+}
+
+// run checks the payment and prints the result; it returns the validation error, marked as reported,
+// or any other error as is
+func (v *validateParams) run(q *qrParams, stdin io.Reader, w io.Writer) error {
+	err := v.validate(q, stdin)
+	if err != nil && exitCode(nil, err) != exitValidation {
+		return err
+	}
+
+	if err := v.report(w, err); err != nil {
+		return err
+	}
+
+	if err != nil {
+		return &reportedError{err: err}
+	}
+
+	return nil
+}
+
+// validate checks the payload, or else the payment of the flags
+func (v *validateParams) validate(q *qrParams, stdin io.Reader) error {
+	if v.Payload == "" {
+		if err := q.prepare(); err != nil {
+			return err
+		}
+
+		return q.Payment.IsValid()
+	}
+
+	b, err := readInput(v.Payload, stdin)
+	if err != nil {
+		return err
+	}
+
+	p, err := payment.ParseBytes(b)
+	if err != nil {
+		return err
+	}
+
+	return p.IsValid()
+}
+
+// report prints the result of the validation, as text or as JSON
+func (v *validateParams) report(w io.Writer, err error) error {
+	r := validateReport{Valid: err == nil}
+
+	if err != nil {
+		r.Error = err.Error()
+
+		if errors.As(err, &r.Errors) {
+			r.Error = "payment is not valid"
+		}
+	}
+
+	if v.JSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(r)
+	}
+
+	switch {
+	case r.Valid:
+		_, err = fmt.Fprintln(w, "Payment is valid")
+	case len(r.Errors) == 0:
+		_, err = fmt.Fprintln(w, r.Error)
+	default:
+		for _, e := range r.Errors {
+			if _, err = fmt.Fprintln(w, e); err != nil {
+				break
+			}
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPayload = "BCD\n002\n1\nSCT\n\nFranz Mustermänn\nDE71110220330123456789\nEUR12.3\n\n\nInvoice 1234"
+
+func TestValidateFlags(t *testing.T) {
+	writeTestConfig(t, "")
+
+	out, code := runExit(t, "", "validate",
+		"--name", "Franz Mustermänn", "--iban", "DE71110220330123456789", "--amount", "12.3", "--remittance", "Invoice 1234")
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "Payment is valid\n", out)
+
+	out, code = runExit(t, "", "validate", "--name", "Franz # Mustermänn", "--iban", "DE00110220330123456789", "--purpose", "ABCDEF")
+	assert.Equal(t, exitValidation, code)
+	assert.Equal(t, []string{"AT-21: ", "AT-20: ", "AT-44: ", "AT-05: "}, linePrefixes(out, 7))
+}
+
+func TestValidateJSON(t *testing.T) {
+	writeTestConfig(t, "")
+
+	out, code := runExit(t, "", "validate", "--json", "--name", "Jane Doe", "--iban", "BE68539007547034", "--purpose", "ABCDEF")
+	assert.Equal(t, exitValidation, code)
+
+	var r struct {
+		Valid  bool `json:"valid"`
+		Errors []struct {
+			Field string `json:"field"`
+			Code  string `json:"code"`
+		} `json:"errors"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(out), &r))
+	assert.False(t, r.Valid)
+	require.Len(t, r.Errors, 2)
+	assert.Equal(t, "purpose", r.Errors[0].Field)
+	assert.Equal(t, "AT-05", r.Errors[1].Code)
+}
+
+func TestValidatePayload(t *testing.T) {
+	writeTestConfig(t, "")
+
+	path := filepath.Join(t.TempDir(), "payload.txt")
+	require.NoError(t, os.WriteFile(path, []byte(testPayload), 0o600))
+
+	out, code := runExit(t, "", "validate", "--payload", path)
+	assert.Equal(t, exitOK, code, out)
+
+	out, code = runExit(t, "BCD\n002\n1\nSCT\n", "validate", "--payload", "-", "--json")
+	assert.Equal(t, exitValidation, code)
+	assert.Contains(t, out, `"valid": false`)
+	assert.Contains(t, out, `"error": "line`)
+
+	_, code = runExit(t, "", "validate", "--payload", filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Equal(t, exitIO, code)
+}
+
+// linePrefixes returns the first n characters of every line
+func linePrefixes(s string, n int) []string {
+	var prefixes []string
+
+	for _, line := range strings.Split(strings.TrimSpace(s), "\n") {
+		prefixes = append(prefixes, line[:min(n, len(line))])
+	}
+
+	return prefixes
+}